@@ -0,0 +1,270 @@
+package log
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// 告警的默认配置。
+const (
+	DefaultAlertWindow  = 5 * time.Minute
+	DefaultAlertTimeout = 5 * time.Second
+)
+
+// AlertRule 是一条告警规则，日志需要同时满足所有设置了的条件才会触发告警。
+type AlertRule struct {
+	Name  string   `config:"name"`  // Name 是规则名，会出现在告警中。
+	Level string   `config:"level"` // Level 是触发告警的最低日志级别，默认是 error。
+	Tags  []string `config:"tags"`  // Tags 不为空时，只有 tag 在其中的日志才会触发告警。
+}
+
+// Alert 是一次告警。
+type Alert struct {
+	Entry
+
+	Rule       string // Rule 是触发告警的规则名。
+	Suppressed int    // Suppressed 是上一次告警之后，在去重窗口内被合并掉的相同告警次数。
+}
+
+// AlertConfig 是 AlertSink 的配置。
+type AlertConfig struct {
+	Rules []AlertRule `config:"rules"` // Rules 是告警规则，一条日志只会触发第一条匹配的规则。
+
+	// Window 是去重窗口，同一条规则、同一个 tag 和调用位置的告警在窗口内只触发一次，
+	// 默认是 DefaultAlertWindow。
+	Window time.Duration `config:"window"`
+
+	Callback func(alert *Alert) `config:"-"`       // Callback 在触发告警时同步调用，不能阻塞太久。
+	Webhook  string             `config:"webhook"` // Webhook 不为空时，触发告警会异步 POST 一个 JSON 到这个地址。
+	Timeout  time.Duration      `config:"timeout"` // Timeout 是 Webhook 请求的超时时间，默认是 DefaultAlertTimeout。
+}
+
+// AlertSink 是一个 Sink，匹配告警规则的日志会触发回调或者 webhook，
+// 相同的告警在去重窗口内只触发一次，可以直接用日志搭建简单的告警。
+//
+// Webhook 请求的内容格式如下，entry 和 FormatJSON 的格式相同：
+//
+//	{"rule":"db","suppressed":3,"entry":{"level":"ERROR","time":"...","tag":"db","msg":"..."}}
+type AlertSink struct {
+	rules    []alertRule
+	window   time.Duration
+	callback func(alert *Alert)
+	webhook  string
+	client   *http.Client
+
+	mu        sync.Mutex
+	states    map[alertKey]*alertState
+	lastSweep time.Time
+
+	wg     sync.WaitGroup
+	health sinkHealth
+}
+
+var _ Sink = new(AlertSink)
+
+type alertRule struct {
+	name  string
+	level Level
+	tags  map[string]bool
+}
+
+type alertKey struct {
+	rule   int
+	tag    string
+	caller string
+}
+
+type alertState struct {
+	last       time.Time
+	suppressed int
+}
+
+// NewAlertSink 创建一个 AlertSink。
+func NewAlertSink(config *AlertConfig) *AlertSink {
+	s := &AlertSink{
+		window:   config.Window,
+		callback: config.Callback,
+		webhook:  config.Webhook,
+		states:   map[alertKey]*alertState{},
+	}
+
+	if s.window <= 0 {
+		s.window = DefaultAlertWindow
+	}
+
+	timeout := config.Timeout
+
+	if timeout <= 0 {
+		timeout = DefaultAlertTimeout
+	}
+
+	s.client = &http.Client{Timeout: timeout}
+
+	for _, rc := range config.Rules {
+		r := alertRule{
+			name:  rc.Name,
+			level: LogError,
+		}
+
+		if rc.Level != "" {
+			r.level = parseLevel(rc.Level)
+		}
+
+		if len(rc.Tags) != 0 {
+			r.tags = map[string]bool{}
+
+			for _, tag := range rc.Tags {
+				r.tags[tag] = true
+			}
+		}
+
+		s.rules = append(s.rules, r)
+	}
+
+	return s
+}
+
+// Write 检查 entry 是否需要触发告警。
+func (s *AlertSink) Write(entry *Entry) error {
+	if entry.Level <= logPrint {
+		return nil
+	}
+
+	for i := range s.rules {
+		if r := &s.rules[i]; r.match(entry) {
+			s.trigger(i, entry)
+			break
+		}
+	}
+
+	return nil
+}
+
+func (r *alertRule) match(entry *Entry) bool {
+	if !entry.Level.within(r.level) {
+		return false
+	}
+
+	if r.tags != nil && !r.tags[entry.Tag] {
+		return false
+	}
+
+	return true
+}
+
+// trigger 在去重之后触发告警。
+func (s *AlertSink) trigger(rule int, entry *Entry) {
+	now := entry.Time
+
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	key := alertKey{
+		rule:   rule,
+		tag:    entry.Tag,
+		caller: entry.Caller,
+	}
+
+	s.mu.Lock()
+	state := s.states[key]
+
+	if state != nil && now.Sub(state.last) < s.window {
+		state.suppressed++
+		s.mu.Unlock()
+		return
+	}
+
+	suppressed := 0
+
+	if state != nil {
+		suppressed = state.suppressed
+	}
+
+	s.states[key] = &alertState{last: now}
+	s.sweep(now)
+	s.mu.Unlock()
+
+	alert := &Alert{
+		Entry:      *entry,
+		Rule:       s.rules[rule].name,
+		Suppressed: suppressed,
+	}
+	alert.Fields = append([]Info(nil), entry.Fields...)
+
+	if s.callback != nil {
+		s.callback(alert)
+	}
+
+	if s.webhook != "" {
+		s.wg.Add(1)
+		go s.post(alert)
+	}
+}
+
+// sweep 定期清理很久没有触发的告警，避免占用过多内存。
+// 过了去重窗口的告警会多保留一个窗口，让再次触发时还能带上被合并掉的次数。
+func (s *AlertSink) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < s.window {
+		return
+	}
+
+	s.lastSweep = now
+
+	for key, state := range s.states {
+		if now.Sub(state.last) >= 2*s.window {
+			delete(s.states, key)
+		}
+	}
+}
+
+// post 将告警发送给 webhook。
+func (s *AlertSink) post(alert *Alert) {
+	defer s.wg.Done()
+
+	buf := &bytes.Buffer{}
+	buf.WriteString(`{"rule":`)
+	writeJSONString(buf, alert.Rule)
+	buf.WriteString(`,"suppressed":`)
+	buf.WriteString(strconv.Itoa(alert.Suppressed))
+	buf.WriteString(`,"entry":`)
+	jsonEncoder{}.Encode(buf, &alert.Entry)
+	buf.WriteByte('}')
+
+	resp, err := s.client.Post(s.webhook, "application/json", buf)
+
+	if err == nil {
+		resp.Body.Close()
+
+		if resp.StatusCode/100 != 2 {
+			err = fmt.Errorf("go-log: alert webhook responds %v", resp.Status)
+		}
+	}
+
+	s.health.record(err)
+}
+
+// Flush 等待所有正在发送的 webhook 请求完成。
+func (s *AlertSink) Flush() error {
+	s.wg.Wait()
+	return nil
+}
+
+// Close 等待所有正在发送的 webhook 请求完成。
+func (s *AlertSink) Close() error {
+	return s.Flush()
+}
+
+// SinkStats 返回 webhook 的健康状况。
+func (s *AlertSink) SinkStats() []SinkStats {
+	stats := SinkStats{
+		Name:      fmt.Sprintf("%T", s),
+		Connected: true,
+	}
+	s.health.fill(&stats)
+	return []SinkStats{stats}
+}
@@ -0,0 +1,99 @@
+package log
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestAlertSink(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	var alerts []*Alert
+	sink := NewAlertSink(&AlertConfig{
+		Rules: []AlertRule{
+			{Name: "db", Level: "warn", Tags: []string{"db"}},
+			{Name: "error"},
+		},
+		Window: time.Minute,
+		Callback: func(alert *Alert) {
+			alerts = append(alerts, alert)
+		},
+	})
+	l, _ := newTestLogger(&Config{
+		LogLevel: "debug",
+		Sinks:    []Sink{sink},
+		Clock: func() time.Time {
+			return now
+		},
+	})
+
+	ctx := context.Background()
+	dbCtx := WithTag(ctx, "db")
+	logAlerts := func() {
+		l.Infof(ctx, "info")
+		l.Warnf(ctx, "warn")
+		l.Warnf(dbCtx, "db warn")
+		l.Errorf(ctx, "error")
+	}
+
+	for i := 0; i < 3; i++ {
+		logAlerts()
+	}
+
+	if len(alerts) != 2 {
+		t.Fatalf("alerts must be deduplicated. [count:%v]", len(alerts))
+	}
+
+	if alerts[0].Rule != "db" || alerts[0].Message != "db warn" || alerts[1].Rule != "error" {
+		t.Fatalf("unexpected alerts. [alerts:%+v]", alerts)
+	}
+
+	now = now.Add(time.Minute)
+	logAlerts()
+
+	if len(alerts) != 4 || alerts[2].Suppressed != 2 || alerts[3].Suppressed != 2 {
+		t.Fatalf("alerts must be triggered after window. [alerts:%+v]", alerts)
+	}
+}
+
+func TestAlertWebhook(t *testing.T) {
+	bodies := make(chan []byte, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := ioutil.ReadAll(r.Body)
+		bodies <- data
+	}))
+	defer server.Close()
+
+	sink := NewAlertSink(&AlertConfig{
+		Rules:   []AlertRule{{Name: "error"}},
+		Webhook: server.URL,
+	})
+	l, _ := newTestLogger(&Config{
+		Sinks: []Sink{sink},
+	})
+
+	l.Errorf(context.Background(), "boom")
+	sink.Flush()
+
+	var body struct {
+		Rule       string                 `json:"rule"`
+		Suppressed int                    `json:"suppressed"`
+		Entry      map[string]interface{} `json:"entry"`
+	}
+
+	if err := json.Unmarshal(<-bodies, &body); err != nil {
+		t.Fatalf("webhook body must be JSON. [err:%v]", err)
+	}
+
+	if body.Rule != "error" || body.Entry["msg"] != "boom" || body.Entry["level"] != "ERROR" {
+		t.Fatalf("unexpected webhook body. [body:%+v]", body)
+	}
+
+	if stats := sink.SinkStats()[0]; !stats.Healthy {
+		t.Fatalf("webhook must be healthy. [stats:%+v]", stats)
+	}
+}
@@ -0,0 +1,97 @@
+package log
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 日志文件的写入方式。
+const (
+	FileBackendLumberjack = "lumberjack" // FileBackendLumberjack 使用 lumberjack 写文件，这是默认方式。
+	FileBackendAppend     = "append"     // FileBackendAppend 用 O_APPEND 直接写文件，每次写入没有额外的大小检查，适合日志量很大的场景。
+	FileBackendDated      = "dated"      // FileBackendDated 每天写入一个带日期的文件，例如 "./log/all.log.2024-05-21"，日志路径本身是指向当前文件的符号链接。
+)
+
+// backupTimeFormat 是 Rotate 之后备份文件名中的时间格式，和 lumberjack 保持一致。
+const backupTimeFormat = "2006-01-02T15-04-05.000"
+
+// appendFile 用 O_APPEND 方式直接写入文件，文件在第一次写入时才打开。
+// Rotate 和 lumberjack 一样把当前文件改名成带时间的备份文件，下次写入时重新创建文件。
+type appendFile struct {
+	mu    sync.Mutex
+	path  string
+	modes fileModes
+	file  *os.File
+}
+
+var _ logFile = new(appendFile)
+
+func newAppendFile(path string, modes fileModes) *appendFile {
+	return &appendFile{
+		path:  path,
+		modes: modes,
+	}
+}
+
+func (f *appendFile) Write(data []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		file, err := f.modes.openFile(f.path)
+
+		if err != nil {
+			return 0, err
+		}
+
+		f.file = file
+	}
+
+	return f.file.Write(data)
+}
+
+func (f *appendFile) Rotate() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var err error
+
+	if f.file != nil {
+		err = f.file.Close()
+		f.file = nil
+	}
+
+	if _, e := os.Stat(f.path); e != nil {
+		return err
+	}
+
+	if e := os.Rename(f.path, backupName(f.path, time.Now().UTC())); e != nil {
+		err = e
+	}
+
+	return err
+}
+
+func (f *appendFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return nil
+	}
+
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
+
+// backupName 返回 path 在 t 时刻备份的文件名，例如 "./log/all-2006-01-02T15-04-05.000.log"。
+func backupName(path string, t time.Time) string {
+	dir, name := filepath.Split(path)
+	ext := filepath.Ext(name)
+	prefix := strings.TrimSuffix(name, ext)
+	return filepath.Join(dir, prefix+"-"+t.Format(backupTimeFormat)+ext)
+}
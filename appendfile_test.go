@@ -0,0 +1,56 @@
+package log
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAppendFile(t *testing.T) {
+	dir := filepath.Join(os.TempDir(), "go-log-appendfile-test")
+	os.RemoveAll(dir)
+	defer os.RemoveAll(dir)
+
+	logPath := filepath.Join(dir, "all.log")
+	l := newLogger(&Config{
+		LogPath:      logPath,
+		ErrorLogPath: logPath,
+		AuditLogPath: filepath.Join(dir, "audit.log"),
+		FileBackend:  FileBackendAppend,
+	})
+
+	if _, ok := l.files[0].(*appendFile); !ok {
+		t.Fatalf("log file must use append backend. [file:%T]", l.files[0])
+	}
+
+	ctx := context.Background()
+	l.Infof(ctx, "before rotate")
+	l.Flush()
+
+	if err := l.Rotate(); err != nil {
+		t.Fatalf("fail to rotate. [err:%v]", err)
+	}
+
+	l.Infof(ctx, "after rotate")
+	l.Close()
+
+	if lines := readLines(t, logPath); len(lines) != 1 {
+		t.Fatalf("log file must only contain lines after rotation. [lines:%v]", lines)
+	}
+
+	backups, _ := filepath.Glob(filepath.Join(dir, "all-*.log"))
+
+	if len(backups) != 1 || len(readLines(t, backups[0])) != 1 {
+		t.Fatalf("rotated file must be kept as a backup. [backups:%v]", backups)
+	}
+}
+
+func TestBackupName(t *testing.T) {
+	tm := time.Date(2020, 1, 2, 3, 4, 5, 6000000, time.UTC)
+
+	if name := backupName("log/all.log", tm); name != filepath.Join("log", "all-2020-01-02T03-04-05.006.log") {
+		t.Fatalf("unexpected backup name. [name:%v]", name)
+	}
+}
@@ -1,30 +1,63 @@
 package log
 
 import (
+	"context"
 	"errors"
 	"io"
+	"net"
 	"sync/atomic"
 )
 
+// maxBatchLines 是 AsyncWriter 一次合并写入的最多条数。
+const maxBatchLines = 64
+
 // AsyncWriter 包装了一个 writer，让所有写入变成异步写。
 type AsyncWriter struct {
-	ch      chan []byte
+	// 以下字段需要 64 位对齐，必须放在最前面。
+	bytes     int64 // bytes 是缓冲区中尚未写入的字节数。
+	maxBytes  int64 // maxBytes 是缓冲区最多能缓存的字节数，0 表示不限制。
+	peakLen   int64 // peakLen 是缓冲区中曾经出现过的最多条数。
+	peakBytes int64 // peakBytes 是缓冲区中曾经出现过的最多字节数。
+
+	ch      chan asyncItem
 	closing chan bool
-	flushed chan bool
 	done    chan bool
 	writer  io.WriteCloser
 
-	closed int32
+	closed  int32
+	noCopy  int32
+	handler atomic.Value
+	batch   net.Buffers
 }
 
+// asyncItem 是缓冲区中的一条数据，如果 flushed 不为 nil，表示这是一个刷新请求，
+// 之前的数据都写入之后会向 flushed 发送信号。
+type asyncItem struct {
+	data    []byte
+	flushed chan bool
+}
+
+// ErrorHandler 处理写入失败的数据，err 是失败的原因，data 是没有写入成功的数据。
+type ErrorHandler func(err error, data []byte)
+
 var _ io.WriteCloser = new(AsyncWriter)
 
+// flusher 是自带缓冲区的 writer，AsyncWriter 刷新时会同时刷新它的缓冲区。
+type flusher interface {
+	Flush() error
+}
+
+// buffersWriter 是可以一次写入多段数据的 writer，例如基于 socket 的 writer 可以用 writev 一次写入。
+// 写入之后 bufs 中只剩下没有写入的数据。
+type buffersWriter interface {
+	WriteBuffers(bufs *net.Buffers) (int64, error)
+}
+
 // NewAsyncWriter 创建一个异步 writer，使用 size 作为缓冲区的条数。
 func NewAsyncWriter(writer io.WriteCloser, size int) *AsyncWriter {
 	w := &AsyncWriter{
-		ch:      make(chan []byte, size),
+		ch:      make(chan asyncItem, size),
 		closing: make(chan bool, 1),
-		flushed: make(chan bool, 1),
 		done:    make(chan bool),
 		writer:  writer,
 	}
@@ -38,8 +71,15 @@ var (
 )
 
 // Write 写入 data 到异步队列里面，任何情况下这个函数不会阻塞。
+// 默认 data 会被复制一份再放入队列，调用者在函数返回后可以随意重用 data；
+// 如果通过 SetCopy 关闭了复制，data 的所有权转交给 w，调用者不能再修改 data。
 // 如果缓冲区满了或者 w 已经被关闭，返回错误。
 func (w *AsyncWriter) Write(data []byte) (written int, err error) {
+	return w.enqueue(data, atomic.LoadInt32(&w.noCopy) == 0)
+}
+
+// enqueue 将 data 放入异步队列，copyData 为 true 时先复制 data。
+func (w *AsyncWriter) enqueue(data []byte, copyData bool) (written int, err error) {
 	if len(data) == 0 {
 		return
 	}
@@ -49,39 +89,199 @@ func (w *AsyncWriter) Write(data []byte) (written int, err error) {
 		return
 	}
 
+	size := int64(len(data))
+	bytes := atomic.AddInt64(&w.bytes, size)
+
+	if max := atomic.LoadInt64(&w.maxBytes); max > 0 && bytes > max {
+		atomic.AddInt64(&w.bytes, -size)
+		err = errAsyncWriterFull
+		w.handleError(err, data)
+		return
+	}
+
+	cp := data
+
+	if copyData {
+		cp = make([]byte, len(data))
+		copy(cp, data)
+	}
+
 	select {
-	case w.ch <- data:
+	case w.ch <- asyncItem{data: cp}:
 		written = len(data)
+		storeMax(&w.peakLen, int64(len(w.ch)))
+		storeMax(&w.peakBytes, bytes)
 	default:
 		// 已经 close 或者缓冲区撑爆了。
+		atomic.AddInt64(&w.bytes, -size)
 		err = errAsyncWriterFull
+		w.handleError(err, data)
 	}
 
 	return
 }
 
+// SetCopy 设置 Write 是否复制 data 再放入队列，默认复制，可以在任何时候调用。
+// 只有所有调用者写入之后都不会再修改 data 时才能关闭复制，这样可以省掉一次内存分配和复制；
+// 使用 buffer 池的调用者必须保持复制，否则队列中的数据可能被后来的写入覆盖。
+func (w *AsyncWriter) SetCopy(enabled bool) {
+	var noCopy int32
+
+	if !enabled {
+		noCopy = 1
+	}
+
+	atomic.StoreInt32(&w.noCopy, noCopy)
+}
+
+// SetMaxBytes 设置缓冲区最多能缓存的字节数，超过之后新写入的数据会被丢弃，可以在任何时候调用。
+// n 不大于 0 时不限制字节数，只受 Cap 限制。
+func (w *AsyncWriter) SetMaxBytes(n int) {
+	if n < 0 {
+		n = 0
+	}
+
+	atomic.StoreInt64(&w.maxBytes, int64(n))
+}
+
+// storeMax 在 v 大于 *addr 时将 *addr 设置成 v。
+func storeMax(addr *int64, v int64) {
+	for {
+		old := atomic.LoadInt64(addr)
+
+		if v <= old || atomic.CompareAndSwapInt64(addr, old, v) {
+			return
+		}
+	}
+}
+
+// ReadFrom 从 r 中读取数据直到 EOF，每次读到的数据作为一条数据放入异步队列。
+// 和 Write 一样，缓冲区满了或者 w 已经被关闭时返回错误。
+// 读取用的 buffer 会被重复使用，所以无论 SetCopy 如何设置，读到的数据总是会被复制。
+func (w *AsyncWriter) ReadFrom(r io.Reader) (n int64, err error) {
+	buf := make([]byte, 32*1024)
+
+	for {
+		read, e := r.Read(buf)
+
+		if read > 0 {
+			if _, err = w.enqueue(buf[:read], true); err != nil {
+				return
+			}
+
+			n += int64(read)
+		}
+
+		if e == io.EOF {
+			return
+		}
+
+		if e != nil {
+			err = e
+			return
+		}
+	}
+}
+
+// SetErrorHandler 设置写入失败时的回调，可以在任何时候调用。
+// 缓冲区已满导致数据被丢弃，或者内部 writer 写入失败时，都会调用 handler。
+// handler 可能在调用 Write 的 goroutine 或者内部写数据的 goroutine 中被调用，不能阻塞太久。
+func (w *AsyncWriter) SetErrorHandler(handler ErrorHandler) {
+	w.handler.Store(handler)
+}
+
+func (w *AsyncWriter) handleError(err error, data []byte) {
+	if handler, ok := w.handler.Load().(ErrorHandler); ok && handler != nil {
+		handler(err, data)
+	}
+}
+
+func (w *AsyncWriter) write(data []byte) {
+	if _, err := w.writer.Write(data); err != nil {
+		w.handleError(err, data)
+	}
+
+	atomic.AddInt64(&w.bytes, -int64(len(data)))
+}
+
+// writeBatch 把 data 和缓冲区中积压的数据合并成一次写入，最多合并 maxBatchLines 条。
+// 如果遇到了 Flush 插入的刷新请求，合并会提前结束，并且返回这个请求的 flushed。
+func (w *AsyncWriter) writeBatch(bw buffersWriter, data []byte) (flushed chan bool) {
+	bufs := append(w.batch[:0], data)
+
+collect:
+	for len(bufs) < maxBatchLines {
+		select {
+		case item := <-w.ch:
+			if item.flushed != nil {
+				flushed = item.flushed
+				break collect
+			}
+
+			bufs = append(bufs, item.data)
+		default:
+			break collect
+		}
+	}
+
+	// WriteBuffers 会修改 bufs，先记下来以便复用底层数组。
+	w.batch = bufs[:0]
+	var size int64
+
+	for _, data := range bufs {
+		size += int64(len(data))
+	}
+
+	defer atomic.AddInt64(&w.bytes, -size)
+
+	if _, err := bw.WriteBuffers(&bufs); err != nil {
+		for _, data := range bufs {
+			w.handleError(err, data)
+		}
+	}
+
+	return
+}
+
+// flushWriter 刷新内部 writer 的缓冲区，然后通知等待的 Flush。
+// flushed 有一个缓冲区，FlushContext 超时之后没有人接收信号也不会阻塞。
+func (w *AsyncWriter) flushWriter(flushed chan bool) {
+	if f, ok := w.writer.(flusher); ok {
+		f.Flush()
+	}
+
+	flushed <- true
+}
+
 // Flush 用来刷新当前缓存的数据。
 func (w *AsyncWriter) Flush() error {
+	return w.FlushContext(context.Background())
+}
+
+// FlushContext 和 Flush 一样刷新当前缓存的数据，但最多等到 ctx 结束，超时返回 ctx.Err()。
+// 超时之后缓冲区中的数据依然会在后台继续写入。
+func (w *AsyncWriter) FlushContext(ctx context.Context) error {
 	if w.isClosed() {
 		return errAsyncWriterClosed
 	}
 
-	// 插入一个特殊数据，必须得写入才行。
+	// 插入一个刷新请求，每个请求有自己的信号，并发的 Flush 不会互相干扰。
+	flushed := make(chan bool, 1)
+
 	select {
-	case w.ch <- nil:
+	case w.ch <- asyncItem{flushed: flushed}:
 	case <-w.done:
 		return errAsyncWriterClosed
+	case <-ctx.Done():
+		return ctx.Err()
 	}
 
-	// 说明一定会刷新，无需再等了。
-	if w.isClosed() {
-		return nil
-	}
-
-	// 触发 flush 流程，等待至少一个特殊数据被消费。
+	// 等待之前的数据全部写入，关闭时所有数据都会在 done 之前写入。
 	select {
-	case <-w.flushed:
+	case <-flushed:
 	case <-w.done:
+	case <-ctx.Done():
+		return ctx.Err()
 	}
 
 	return nil
@@ -107,16 +307,49 @@ func (w *AsyncWriter) Close() error {
 	return nil
 }
 
+// Len 返回缓冲区中尚未写入的数据条数。
+func (w *AsyncWriter) Len() int {
+	return len(w.ch)
+}
+
+// Cap 返回缓冲区的容量，当 Len 达到 Cap 时，新写入的数据会被丢弃。
+func (w *AsyncWriter) Cap() int {
+	return cap(w.ch)
+}
+
+// Bytes 返回缓冲区中尚未写入的字节数。
+func (w *AsyncWriter) Bytes() int {
+	return int(atomic.LoadInt64(&w.bytes))
+}
+
+// MaxBytes 返回缓冲区最多能缓存的字节数，0 表示不限制。
+func (w *AsyncWriter) MaxBytes() int {
+	return int(atomic.LoadInt64(&w.maxBytes))
+}
+
+// Peak 返回缓冲区中曾经出现过的最多条数和最多字节数，用于评估 BufferedLines 和 BufferedBytes 是否合适。
+func (w *AsyncWriter) Peak() (lines, bytes int) {
+	return int(atomic.LoadInt64(&w.peakLen)), int(atomic.LoadInt64(&w.peakBytes))
+}
+
 func (w *AsyncWriter) flush() {
 	for {
 		select {
-		case data := <-w.ch:
-			if len(data) == 0 {
-				w.flushed <- true
+		case item := <-w.ch:
+			if item.flushed != nil {
+				w.flushWriter(item.flushed)
 				continue
 			}
 
-			w.writer.Write(data)
+			if bw, ok := w.writer.(buffersWriter); ok {
+				if flushed := w.writeBatch(bw, item.data); flushed != nil {
+					w.flushWriter(flushed)
+				}
+
+				continue
+			}
+
+			w.write(item.data)
 
 		case <-w.closing:
 			atomic.StoreInt32(&w.closed, 1)
@@ -124,12 +357,21 @@ func (w *AsyncWriter) flush() {
 			// 清空缓存。
 			for {
 				select {
-				case data := <-w.ch:
-					if len(data) == 0 {
+				case item := <-w.ch:
+					if item.flushed != nil {
+						w.flushWriter(item.flushed)
+						continue
+					}
+
+					if bw, ok := w.writer.(buffersWriter); ok {
+						if flushed := w.writeBatch(bw, item.data); flushed != nil {
+							w.flushWriter(flushed)
+						}
+
 						continue
 					}
 
-					w.writer.Write(data)
+					w.write(item.data)
 				default:
 					w.writer.Close()
 					close(w.done)
@@ -0,0 +1,211 @@
+package log
+
+import (
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type failWriter struct{}
+
+func (failWriter) Write(data []byte) (int, error) {
+	return 0, errors.New("disk is full")
+}
+
+func (failWriter) Close() error {
+	return nil
+}
+
+func TestAsyncWriterErrorHandler(t *testing.T) {
+	var mu sync.Mutex
+	var failed []string
+	w := NewAsyncWriter(failWriter{}, 16)
+	w.SetErrorHandler(func(err error, data []byte) {
+		mu.Lock()
+		defer mu.Unlock()
+		failed = append(failed, string(data))
+	})
+
+	w.Write([]byte("line1"))
+	w.Write([]byte("line2"))
+	w.Close()
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if len(failed) != 2 || failed[0] != "line1" || failed[1] != "line2" {
+		t.Fatalf("error handler should receive all failed lines. [failed:%v]", failed)
+	}
+}
+
+type batchWriter struct {
+	gate    chan bool
+	mu      sync.Mutex
+	data    []byte
+	batches int
+}
+
+func (w *batchWriter) Write(data []byte) (int, error) {
+	bufs := net.Buffers{data}
+	n, err := w.WriteBuffers(&bufs)
+	return int(n), err
+}
+
+func (w *batchWriter) WriteBuffers(bufs *net.Buffers) (n int64, err error) {
+	if w.gate != nil {
+		<-w.gate
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.batches++
+
+	for _, data := range *bufs {
+		w.data = append(w.data, data...)
+		n += int64(len(data))
+	}
+
+	*bufs = nil
+	return
+}
+
+func (w *batchWriter) Close() error {
+	return nil
+}
+
+func TestAsyncWriterBatch(t *testing.T) {
+	// 第一次写入会被阻塞，直到所有数据都进入缓冲区，剩下的数据应该合并写入。
+	bw := &batchWriter{gate: make(chan bool)}
+	w := NewAsyncWriter(bw, 1024)
+	var expected []string
+
+	for i := 0; i < 200; i++ {
+		line := strings.Repeat(string(rune('a'+i%26)), i%7+1) + "\n"
+		expected = append(expected, line)
+		w.Write([]byte(line))
+	}
+
+	close(bw.gate)
+	w.Flush()
+	w.Write([]byte("last\n"))
+	expected = append(expected, "last\n")
+	w.Close()
+
+	if actual := string(bw.data); actual != strings.Join(expected, "") {
+		t.Fatalf("all lines must be written in order. [actual:%v]", actual)
+	}
+
+	if max := 2 + 200/maxBatchLines + 1; bw.batches > max {
+		t.Fatalf("lines must be written in batches. [batches:%v] [max:%v]", bw.batches, max)
+	}
+}
+
+func TestAsyncWriterReadFrom(t *testing.T) {
+	bw := &batchWriter{}
+	w := NewAsyncWriter(bw, 16)
+	n, err := w.ReadFrom(strings.NewReader("line1\nline2\n"))
+	w.Close()
+
+	if err != nil || n != 12 || string(bw.data) != "line1\nline2\n" {
+		t.Fatalf("fail to read from reader. [n:%v] [err:%v] [data:%q]", n, err, bw.data)
+	}
+}
+
+// lineReader 每次 Read 只返回一行。
+type lineReader struct {
+	lines []string
+}
+
+func (r *lineReader) Read(p []byte) (int, error) {
+	if len(r.lines) == 0 {
+		return 0, io.EOF
+	}
+
+	n := copy(p, r.lines[0])
+	r.lines = r.lines[1:]
+	return n, nil
+}
+
+func TestAsyncWriterReadFromNoCopy(t *testing.T) {
+	// 写入被阻塞时，队列中的数据不能被 ReadFrom 之后的读取覆盖。
+	bw := &batchWriter{gate: make(chan bool)}
+	w := NewAsyncWriter(bw, 16)
+	w.SetCopy(false)
+	n, err := w.ReadFrom(&lineReader{lines: []string{"line1\n", "line2\n", "line3\n"}})
+	close(bw.gate)
+	w.Close()
+
+	if err != nil || n != 18 || string(bw.data) != "line1\nline2\nline3\n" {
+		t.Fatalf("fail to read from reader. [n:%v] [err:%v] [data:%q]", n, err, bw.data)
+	}
+}
+
+func TestAsyncWriterMaxBytes(t *testing.T) {
+	bw := &batchWriter{gate: make(chan bool)}
+	w := NewAsyncWriter(bw, 16)
+	w.SetMaxBytes(10)
+
+	for i, expected := range []bool{true, true, false} {
+		if _, err := w.Write([]byte("12345")); (err == nil) != expected {
+			t.Fatalf("unexpected write result. [i:%v] [err:%v]", i, err)
+		}
+	}
+
+	if bytes := w.Bytes(); bytes != 10 {
+		t.Fatalf("unexpected buffered bytes. [bytes:%v]", bytes)
+	}
+
+	close(bw.gate)
+	w.Close()
+
+	if _, peakBytes := w.Peak(); w.Bytes() != 0 || peakBytes != 10 {
+		t.Fatalf("unexpected stats after close. [bytes:%v] [peak:%v]", w.Bytes(), peakBytes)
+	}
+}
+
+func TestBufferedLinesOf(t *testing.T) {
+	cases := []struct {
+		config   Config
+		expected int
+	}{
+		{Config{}, DefaultBufferedLines},
+		{Config{BufferedLines: 100, BufferedBytes: 1 << 20}, 100},
+		{Config{BufferedBytes: 1 << 20}, 1 << 20 / minBufferedLineSize},
+		{Config{BufferedBytes: 1024}, minBufferedLines},
+		{Config{BufferedBytes: 1 << 30}, DefaultBufferedLines},
+	}
+
+	for i, c := range cases {
+		if actual := bufferedLinesOf(&c.config); actual != c.expected {
+			t.Fatalf("unexpected buffered lines. [i:%v] [expected:%v] [actual:%v]", i, c.expected, actual)
+		}
+	}
+}
+
+func TestAsyncWriterCopy(t *testing.T) {
+	for _, copied := range []bool{true, false} {
+		bw := &batchWriter{gate: make(chan bool)}
+		w := NewAsyncWriter(bw, 16)
+		w.SetCopy(copied)
+
+		buf := []byte("line1\n")
+		w.Write(buf)
+		copy(buf, "LINE2\n")
+		close(bw.gate)
+		w.Close()
+
+		expected := "line1\n"
+
+		if !copied {
+			expected = "LINE2\n"
+		}
+
+		if actual := string(bw.data); actual != expected {
+			t.Fatalf("unexpected data. [copy:%v] [expected:%q] [actual:%q]", copied, expected, actual)
+		}
+	}
+}
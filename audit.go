@@ -0,0 +1,80 @@
+package log
+
+import (
+	"context"
+	"os"
+	"sync"
+)
+
+func (l *logger) auditf(ctx context.Context, format string, args ...interface{}) error {
+	pc := callerPC(loggerSkipLevel)
+	entry := l.newEntry(ctx, pc, logAudit, format, args...)
+
+	buf := getBuffer()
+	defer putBuffer(buf)
+	l.encoder.Encode(buf, entry)
+
+	// 审计日志是普通文件，即使 l 的日志由 FrameWriter 分隔，也需要换行符分隔每条日志。
+	if !selfDelimited(l.encoder) {
+		buf.WriteByte('\n')
+	}
+
+	_, err := l.audit.Write(buf.Bytes())
+	return err
+}
+
+// auditFile 同步写入审计日志，每次写入之后都调用 fsync 保证数据落盘。
+// 文件在第一次写入时才打开，Rotate 之后会在下次写入时重新打开。
+type auditFile struct {
+	mu    sync.Mutex
+	path  string
+	modes fileModes
+	file  *os.File
+}
+
+func newAuditFile(path string, modes fileModes) *auditFile {
+	return &auditFile{
+		path:  path,
+		modes: modes,
+	}
+}
+
+func (f *auditFile) Write(data []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		file, err := f.modes.openFile(f.path)
+
+		if err != nil {
+			return 0, err
+		}
+
+		f.file = file
+	}
+
+	n, err := f.file.Write(data)
+
+	if err != nil {
+		return n, err
+	}
+
+	return n, f.file.Sync()
+}
+
+func (f *auditFile) Rotate() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return nil
+	}
+
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
+
+func (f *auditFile) Close() error {
+	return f.Rotate()
+}
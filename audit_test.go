@@ -0,0 +1,84 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAuditf(t *testing.T) {
+	dir := filepath.Join(os.TempDir(), "go-log-audit-test")
+	os.RemoveAll(dir)
+	defer os.RemoveAll(dir)
+
+	auditPath := filepath.Join(dir, "audit.log")
+	l := newLogger(&Config{
+		LogPath:      filepath.Join(dir, "all.log"),
+		ErrorLogPath: filepath.Join(dir, "error.log"),
+		LogLevel:     "fatal",
+		AuditLogPath: auditPath,
+	})
+	defer l.Close()
+
+	if err := l.auditf(context.Background(), "user %v logged in", "alice"); err != nil {
+		t.Fatalf("fail to write audit log. [err:%v]", err)
+	}
+
+	// 审计日志同步写入，不需要 Flush 就可以读到。
+	data, err := ioutil.ReadFile(auditPath)
+
+	if err != nil {
+		t.Fatalf("fail to read audit log. [err:%v]", err)
+	}
+
+	line := string(data)
+
+	if !strings.HasPrefix(line, "[AUDIT]") || !strings.HasSuffix(line, "user alice logged in\n") {
+		t.Fatalf("invalid audit log. [line:%v]", line)
+	}
+}
+
+func TestAuditfSidecar(t *testing.T) {
+	dir := filepath.Join(os.TempDir(), "go-log-audit-sidecar-test")
+	os.RemoveAll(dir)
+	defer os.RemoveAll(dir)
+
+	// sidecar 的日志由 FrameWriter 分隔，但是审计日志和最近日志依然需要换行符分隔。
+	auditPath := filepath.Join(dir, "audit.log")
+	l := newLogger(&Config{
+		Output:        OutputSidecar,
+		SidecarPath:   filepath.Join(dir, "sidecar.log"),
+		Format:        FormatJSON,
+		AuditLogPath:  auditPath,
+		RecentEntries: 10,
+	})
+	defer l.Close()
+
+	ctx := context.Background()
+	l.auditf(ctx, "audit %v", 1)
+	l.auditf(ctx, "audit %v", 2)
+
+	data, err := ioutil.ReadFile(auditPath)
+
+	if err != nil {
+		t.Fatalf("fail to read audit log. [err:%v]", err)
+	}
+
+	if lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n"); len(lines) != 2 {
+		t.Fatalf("audit records must be separated by newline. [data:%v]", string(data))
+	}
+
+	l.Errorf(ctx, "error %v", 1)
+	l.Errorf(ctx, "error %v", 2)
+
+	buf := &bytes.Buffer{}
+	l.recent.dump(buf)
+
+	if lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n"); len(lines) != 2 {
+		t.Fatalf("recent records must be separated by newline. [data:%v]", buf.String())
+	}
+}
@@ -0,0 +1,129 @@
+package log
+
+import (
+	"context"
+	"io/ioutil"
+	"testing"
+)
+
+// newBenchLogger 创建一个编码日志但是丢弃结果的日志实例，用来测试日志本身的开销。
+// 日志和写文件时一样经过 AsyncWriter，只是最终写入 ioutil.Discard，使用之后需要调用 Close。
+func newBenchLogger(format string) *logger {
+	l := newLogger(&Config{
+		Output: OutputStdout,
+		Format: format,
+	})
+
+	for _, w := range l.writers {
+		w.Close()
+	}
+
+	w := NewAsyncWriter(dummyCloser{Writer: ioutil.Discard}, DefaultBufferedLines)
+	l.routes = []route{allRoute(w)}
+	l.writers = []*AsyncWriter{w}
+	return l
+}
+
+func benchmarkLog(b *testing.B, l *logger, ctx context.Context, level Level) {
+	defer l.Close()
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		l.log(ctx, 0, level, "a line of log")
+	}
+}
+
+func benchmarkLogArgs(b *testing.B, l *logger, ctx context.Context) {
+	defer l.Close()
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		l.Infof(ctx, "key1=%v||key2=%v||a line of log", "abcdef", 1234)
+	}
+}
+
+func benchFields() context.Context {
+	return WithMoreInfo(context.Background(),
+		Info{Key: "user", Value: "alice"},
+		Info{Key: "id", Value: 1234},
+		Info{Key: "ok", Value: true},
+	)
+}
+
+// TestAllocations 保证输出日志的热路径满足内存分配的目标：
+// 不输出的日志不分配内存，输出的日志只分配 Entry 和 AsyncWriter 复制的一行日志，有格式化参数时再加上格式化之后的消息。
+func TestAllocations(t *testing.T) {
+	if raceEnabled {
+		t.Skip("allocations are not accurate with race detector.")
+	}
+
+	cases := []struct {
+		name   string
+		format string
+		ctx    context.Context
+		level  Level
+		args   bool
+		target float64
+	}{
+		{"disabled", FormatText, benchFields(), LogDebug, false, 0},
+		{"text", FormatText, benchFields(), LogInfo, false, 2},
+		{"text args", FormatText, benchFields(), LogInfo, true, 3},
+		{"json", FormatJSON, benchFields(), LogInfo, false, 2},
+		{"json args", FormatJSON, benchFields(), LogInfo, true, 3},
+		{"logfmt", FormatLogfmt, benchFields(), LogInfo, false, 2},
+	}
+
+	for _, c := range cases {
+		l := newBenchLogger(c.format)
+		allocs := testing.AllocsPerRun(100, func() {
+			if c.args {
+				l.log(c.ctx, 0, c.level, "key1=%v||key2=%v", "abcdef", 1234)
+			} else {
+				l.log(c.ctx, 0, c.level, "a line of log")
+			}
+		})
+		l.Close()
+
+		if allocs > c.target {
+			t.Fatalf("too many allocations. [case:%v] [target:%v] [allocs:%v]", c.name, c.target, allocs)
+		}
+	}
+}
+
+func BenchmarkDisabled(b *testing.B) {
+	benchmarkLog(b, newBenchLogger(FormatText), context.Background(), LogDebug)
+}
+
+func BenchmarkDisabledWithFields(b *testing.B) {
+	benchmarkLog(b, newBenchLogger(FormatText), benchFields(), LogDebug)
+}
+
+func BenchmarkText(b *testing.B) {
+	benchmarkLog(b, newBenchLogger(FormatText), context.Background(), LogInfo)
+}
+
+func BenchmarkTextArgs(b *testing.B) {
+	benchmarkLogArgs(b, newBenchLogger(FormatText), context.Background())
+}
+
+func BenchmarkTextWithFields(b *testing.B) {
+	benchmarkLog(b, newBenchLogger(FormatText), benchFields(), LogInfo)
+}
+
+func BenchmarkJSON(b *testing.B) {
+	benchmarkLog(b, newBenchLogger(FormatJSON), context.Background(), LogInfo)
+}
+
+func BenchmarkJSONArgs(b *testing.B) {
+	benchmarkLogArgs(b, newBenchLogger(FormatJSON), context.Background())
+}
+
+func BenchmarkJSONWithFields(b *testing.B) {
+	benchmarkLog(b, newBenchLogger(FormatJSON), benchFields(), LogInfo)
+}
+
+func BenchmarkLogfmtWithFields(b *testing.B) {
+	benchmarkLog(b, newBenchLogger(FormatLogfmt), benchFields(), LogInfo)
+}
@@ -0,0 +1,242 @@
+package log
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"time"
+)
+
+// FormatBinary 是紧凑的二进制格式，每条日志是一个以 varint 长度为前缀的 protobuf 消息，
+// 和 protobuf 官方库中 writeDelimitedTo/parseDelimitedFrom 的格式一致。
+// 消息的定义如下：
+//
+//	message Entry {
+//	  int32 level = 1;          // 日志级别，Printf 输出的日志为 0。
+//	  int64 time_unix_nano = 2; // 日志时间。
+//	  string caller = 3;
+//	  string tag = 4;
+//	  repeated Field fields = 5;
+//	  string message = 6;
+//	}
+//
+//	message Field {
+//	  string key = 1;
+//	  string value = 2;
+//	}
+//
+// 使用 BinaryReader 可以解析这种格式，cmd/logdecode 可以将它转换成其他格式。
+const FormatBinary = "binary"
+
+const (
+	binaryFieldLevel   = 1
+	binaryFieldTime    = 2
+	binaryFieldCaller  = 3
+	binaryFieldTag     = 4
+	binaryFieldFields  = 5
+	binaryFieldMessage = 6
+
+	binaryFieldKey   = 1
+	binaryFieldValue = 2
+
+	wireVarint = 0
+	wireBytes  = 2
+
+	maxBinaryEntrySize = 1 << 24
+)
+
+var (
+	errBinaryEntryTooLarge = errors.New("go-log: binary entry is too large")
+	errBinaryMalformed     = errors.New("go-log: malformed binary entry")
+)
+
+// binaryEncoder 将日志编码成 FormatBinary 格式。
+type binaryEncoder struct{}
+
+func (binaryEncoder) Encode(buf *bytes.Buffer, entry *Entry) {
+	var msg []byte
+
+	if entry.Level != 0 {
+		msg = appendVarintField(msg, binaryFieldLevel, uint64(entry.Level))
+	}
+
+	msg = appendVarintField(msg, binaryFieldTime, uint64(entry.Time.UnixNano()))
+	msg = appendStringField(msg, binaryFieldCaller, entry.Caller)
+	msg = appendStringField(msg, binaryFieldTag, entry.Tag)
+
+	for _, info := range entry.Fields {
+		var field []byte
+		field = appendStringField(field, binaryFieldKey, info.Key)
+		field = appendStringField(field, binaryFieldValue, valueString(info.Value))
+		msg = appendBytesField(msg, binaryFieldFields, field)
+	}
+
+	msg = appendStringField(msg, binaryFieldMessage, entry.Message)
+
+	var size [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(size[:], uint64(len(msg)))
+	buf.Write(size[:n])
+	buf.Write(msg)
+}
+
+func appendVarintField(data []byte, field int, v uint64) []byte {
+	data = appendUvarint(data, uint64(field<<3|wireVarint))
+	return appendUvarint(data, v)
+}
+
+func appendStringField(data []byte, field int, s string) []byte {
+	if s == "" {
+		return data
+	}
+
+	data = appendUvarint(data, uint64(field<<3|wireBytes))
+	data = appendUvarint(data, uint64(len(s)))
+	return append(data, s...)
+}
+
+func appendBytesField(data []byte, field int, b []byte) []byte {
+	data = appendUvarint(data, uint64(field<<3|wireBytes))
+	data = appendUvarint(data, uint64(len(b)))
+	return append(data, b...)
+}
+
+func appendUvarint(data []byte, v uint64) []byte {
+	for v >= 0x80 {
+		data = append(data, byte(v)|0x80)
+		v >>= 7
+	}
+
+	return append(data, byte(v))
+}
+
+// BinaryReader 读取 FormatBinary 格式的日志。
+type BinaryReader struct {
+	reader *bufio.Reader
+}
+
+// NewBinaryReader 创建一个 BinaryReader。
+func NewBinaryReader(reader io.Reader) *BinaryReader {
+	return &BinaryReader{
+		reader: bufio.NewReader(reader),
+	}
+}
+
+// Next 读取下一条日志，数据流结束时返回 io.EOF。
+// 解析出的 Entry.Fields 中，所有值都是 string 类型。
+func (r *BinaryReader) Next() (entry *Entry, err error) {
+	size, err := binary.ReadUvarint(r.reader)
+
+	if err != nil {
+		return
+	}
+
+	if size > maxBinaryEntrySize {
+		err = errBinaryEntryTooLarge
+		return
+	}
+
+	msg := make([]byte, size)
+
+	if _, err = io.ReadFull(r.reader, msg); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+
+		return
+	}
+
+	entry = &Entry{}
+	err = decodeBinaryEntry(msg, entry)
+	return
+}
+
+func decodeBinaryEntry(msg []byte, entry *Entry) error {
+	return decodeBinaryMessage(msg, func(field int, v uint64, data []byte) error {
+		switch field {
+		case binaryFieldLevel:
+			entry.Level = Level(v)
+		case binaryFieldTime:
+			entry.Time = time.Unix(0, int64(v))
+		case binaryFieldCaller:
+			entry.Caller = string(data)
+		case binaryFieldTag:
+			entry.Tag = string(data)
+		case binaryFieldMessage:
+			entry.Message = string(data)
+		case binaryFieldFields:
+			var info Info
+			err := decodeBinaryMessage(data, func(field int, v uint64, data []byte) error {
+				switch field {
+				case binaryFieldKey:
+					info.Key = string(data)
+				case binaryFieldValue:
+					info.Value = string(data)
+				}
+
+				return nil
+			})
+
+			if err != nil {
+				return err
+			}
+
+			if info.Value == nil {
+				info.Value = ""
+			}
+
+			entry.Fields = append(entry.Fields, info)
+		}
+
+		return nil
+	})
+}
+
+// decodeBinaryMessage 依次解析 msg 中的每个字段，未知的字段会被忽略。
+func decodeBinaryMessage(msg []byte, handle func(field int, v uint64, data []byte) error) error {
+	for len(msg) > 0 {
+		key, n := binary.Uvarint(msg)
+
+		if n <= 0 {
+			return errBinaryMalformed
+		}
+
+		msg = msg[n:]
+		field := int(key >> 3)
+
+		switch key & 7 {
+		case wireVarint:
+			v, n := binary.Uvarint(msg)
+
+			if n <= 0 {
+				return errBinaryMalformed
+			}
+
+			msg = msg[n:]
+
+			if err := handle(field, v, nil); err != nil {
+				return err
+			}
+
+		case wireBytes:
+			size, n := binary.Uvarint(msg)
+
+			if n <= 0 || uint64(len(msg)-n) < size {
+				return errBinaryMalformed
+			}
+
+			data := msg[n : n+int(size)]
+			msg = msg[n+int(size):]
+
+			if err := handle(field, 0, data); err != nil {
+				return err
+			}
+
+		default:
+			return errBinaryMalformed
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,50 @@
+package log
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestBinaryEncoderAndReader(t *testing.T) {
+	now := time.Unix(1562128496, 789000000)
+	entries := []*Entry{
+		{
+			Level:   LogInfo,
+			Time:    now,
+			Caller:  "a.go:12@pkg.Func",
+			Tag:     "tag",
+			Fields:  []Info{{Key: "key1", Value: "123"}, {Key: "key2", Value: ""}},
+			Message: "hello\nworld",
+		},
+		{
+			Time:    now,
+			Message: "print",
+		},
+	}
+	buf := &bytes.Buffer{}
+
+	for _, e := range entries {
+		binaryEncoder{}.Encode(buf, e)
+	}
+
+	r := NewBinaryReader(buf)
+
+	for i, expected := range entries {
+		actual, err := r.Next()
+
+		if err != nil {
+			t.Fatalf("fail to read entry %v. [err:%v]", i, err)
+		}
+
+		if !reflect.DeepEqual(expected, actual) {
+			t.Fatalf("invalid entry %v.\n  expected:\n%#v\n  actual:\n%#v", i, expected, actual)
+		}
+	}
+
+	if _, err := r.Next(); err != io.EOF {
+		t.Fatalf("reader should reach EOF. [err:%v]", err)
+	}
+}
@@ -0,0 +1,83 @@
+package log
+
+import (
+	"context"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"strings"
+)
+
+// BuildInfoTag 是 LogBuildInfo 输出日志的默认 tag。
+const BuildInfoTag = "build"
+
+// LogBuildInfo 输出一条 Info 日志，包含 module 版本、VCS 版本、Go 版本、主机名和日志配置的摘要，
+// 一般在程序启动并调用 Init 之后立即调用，让每个日志文件都能看出是哪个版本的程序在哪里输出的。
+// 如果 ctx 中没有 tag，日志的 tag 是 BuildInfoTag。
+func LogBuildInfo(ctx context.Context) {
+	if tag(ctx) == "" {
+		ctx = WithTag(ctx, BuildInfoTag)
+	}
+
+	l := defaultLogger()
+	l.log(WithMoreInfo(ctx, l.buildInfo()...), 0, LogInfo, "build info")
+}
+
+func (l *logger) buildInfo() []Info {
+	fields := []Info{
+		String("go_version", runtime.Version()),
+	}
+
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		fields = append(fields,
+			String("module", bi.Main.Path),
+			String("module_version", bi.Main.Version),
+		)
+
+		for _, s := range bi.Settings {
+			switch s.Key {
+			case "vcs.revision", "vcs.time", "vcs.modified":
+				fields = append(fields, String(strings.Replace(s.Key, ".", "_", 1), s.Value))
+			}
+		}
+	}
+
+	// 设置了 Config.Hostname 时每条日志都已经有主机名了。
+	if !hasField(l.stamps, HostnameKey) {
+		hostname, err := os.Hostname()
+
+		if err != nil {
+			hostname = "unknown"
+		}
+
+		fields = append(fields, String(HostnameKey, hostname))
+	}
+
+	return append(fields, String("log_config", l.summary))
+}
+
+// configSummary 返回日志配置中最重要的几项，例如 "output=file format=text level=info path=./log/all.log"。
+func configSummary(config *Config) string {
+	if config == nil {
+		return "output=stdout"
+	}
+
+	c := withDefaults(config)
+	parts := []string{"output=" + c.Output, "format=" + c.Format, "level=" + c.LogLevel}
+
+	if c.LogPath != "" {
+		parts = append(parts, "path="+c.LogPath, "error_path="+c.ErrorLogPath)
+	}
+
+	return strings.Join(parts, " ")
+}
+
+func hasField(fields []Info, key string) bool {
+	for _, info := range fields {
+		if info.Key == key {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,45 @@
+package log
+
+import (
+	"context"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestLogBuildInfo(t *testing.T) {
+	l, lines := newTestLogger(&Config{})
+	setDefaultLogger(l)
+	defer Init(nil)
+
+	LogBuildInfo(context.Background())
+
+	if len(*lines) != 1 {
+		t.Fatalf("build info should be logged. [lines:%v]", *lines)
+	}
+
+	line := (*lines)[0]
+
+	for _, field := range []string{"[INFO]", BuildInfoTag + "||", "go_version=" + runtime.Version(), "||host=", "||log_config=", "||build info"} {
+		if !strings.Contains(line, field) {
+			t.Fatalf("build info should contain %v. [line:%v]", field, line)
+		}
+	}
+}
+
+func TestConfigSummary(t *testing.T) {
+	cases := []struct {
+		config  *Config
+		summary string
+	}{
+		{nil, "output=stdout"},
+		{&Config{}, "output=file format=text level=" + DefaultLogLevel + " path=" + DefaultLogPath + " error_path=" + DefaultErrorLogPath},
+		{&Config{Output: OutputStdout, LogLevel: "warn"}, "output=stdout format=json level=warn"},
+	}
+
+	for i, c := range cases {
+		if summary := configSummary(c.config); summary != c.summary {
+			t.Fatalf("case %v: invalid summary. [expected:%v] [actual:%v]", i, c.summary, summary)
+		}
+	}
+}
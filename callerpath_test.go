@@ -0,0 +1,32 @@
+package log
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestCallerPath(t *testing.T) {
+	ctx := context.Background()
+	cases := map[string]string{
+		CallerBase:    "[callerpath_test.go:",
+		CallerPackage: "[<std>/go-log/callerpath_test.go:",
+		CallerFull:    "/callerpath_test.go:",
+	}
+
+	for callerPath, expected := range cases {
+		l, lines := newTestLogger(&Config{
+			CallerPath: callerPath,
+		})
+		l.Infof(ctx, "caller")
+		l.Flush()
+
+		if len(*lines) != 1 || !strings.Contains((*lines)[0], expected) {
+			t.Fatalf("invalid caller. [caller_path:%v] [lines:%v]", callerPath, *lines)
+		}
+
+		if callerPath == CallerFull && !strings.Contains((*lines)[0], "][/") && !strings.Contains((*lines)[0], ":\\") {
+			t.Fatalf("caller must be an absolute path. [lines:%v]", *lines)
+		}
+	}
+}
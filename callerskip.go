@@ -0,0 +1,105 @@
+package log
+
+import (
+	"context"
+	"io"
+)
+
+// callerSkipLogger 输出日志时额外跳过 skip 层调用栈，l 为 nil 时使用默认日志。
+type callerSkipLogger struct {
+	l    *logger
+	skip int
+}
+
+// WithCallerSkip 返回一个新的 Logger，输出的调用者信息会额外跳过 n 层调用栈，
+// 适合在封装了这个库的框架中使用，让日志中的调用者是框架的使用者而不是框架自己。
+// l 为 nil 时使用默认日志，每次输出日志都会使用当时的默认日志，Init 之后依然有效。
+//
+//	var logger = log.WithCallerSkip(nil, 1)
+//
+//	func Errorf(ctx context.Context, format string, args ...interface{}) {
+//		logger.Errorf(ctx, format, args...)
+//	}
+//
+// 如果 l 不是这个库创建的 Logger，直接返回 l。
+func WithCallerSkip(l Logger, n int) Logger {
+	switch v := l.(type) {
+	case nil:
+		return &callerSkipLogger{skip: n}
+	case *logger:
+		return &callerSkipLogger{l: v, skip: n}
+	case *callerSkipLogger:
+		return &callerSkipLogger{l: v.l, skip: v.skip + n}
+	default:
+		return l
+	}
+}
+
+func (l *callerSkipLogger) target() *logger {
+	if l.l == nil {
+		return defaultLogger()
+	}
+
+	return l.l
+}
+
+func (l *callerSkipLogger) Debugf(ctx context.Context, fmt string, args ...interface{}) {
+	l.target().log(ctx, l.skip, LogDebug, fmt, args...)
+}
+
+func (l *callerSkipLogger) Infof(ctx context.Context, fmt string, args ...interface{}) {
+	l.target().log(ctx, l.skip, LogInfo, fmt, args...)
+}
+
+func (l *callerSkipLogger) Tracef(ctx context.Context, fmt string, args ...interface{}) {
+	l.target().log(ctx, l.skip, LogTrace, fmt, args...)
+}
+
+func (l *callerSkipLogger) Warnf(ctx context.Context, fmt string, args ...interface{}) {
+	l.target().log(ctx, l.skip, LogWarn, fmt, args...)
+}
+
+func (l *callerSkipLogger) Errorf(ctx context.Context, fmt string, args ...interface{}) {
+	l.target().log(ctx, l.skip, LogError, fmt, args...)
+}
+
+func (l *callerSkipLogger) Panicf(ctx context.Context, fmt string, args ...interface{}) {
+	l.target().panicf(ctx, l.skip, fmt, args...)
+}
+
+func (l *callerSkipLogger) Fatalf(ctx context.Context, fmt string, args ...interface{}) {
+	l.target().log(ctx, l.skip, LogFatal, fmt, args...)
+}
+
+func (l *callerSkipLogger) Printf(ctx context.Context, fmt string, args ...interface{}) {
+	l.target().log(ctx, l.skip, logPrint, fmt, args...)
+}
+
+// Flush 刷新 l 对应的 Logger，使用默认日志时刷新默认日志。
+func (l *callerSkipLogger) Flush() error {
+	return l.target().Flush()
+}
+
+// FlushContext 刷新 l 对应的 Logger，最多等到 ctx 结束，使用默认日志时刷新默认日志。
+func (l *callerSkipLogger) FlushContext(ctx context.Context) error {
+	return l.target().FlushContext(ctx)
+}
+
+// Rotate 切割 l 对应的 Logger 的日志文件，使用默认日志时切割默认日志。
+func (l *callerSkipLogger) Rotate() error {
+	return l.target().Rotate()
+}
+
+// SetOutput 让 l 对应的 Logger 写入 w，使用默认日志时设置默认日志。
+func (l *callerSkipLogger) SetOutput(w io.Writer) {
+	l.target().SetOutput(w)
+}
+
+// Close 关闭 l 对应的 Logger，使用默认日志时什么都不做。
+func (l *callerSkipLogger) Close() error {
+	if l.l == nil {
+		return nil
+	}
+
+	return l.l.Close()
+}
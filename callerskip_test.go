@@ -0,0 +1,41 @@
+package log
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func wrappedErrorf(l Logger, ctx context.Context, format string, args ...interface{}) {
+	l.Errorf(ctx, format, args...)
+}
+
+func TestWithCallerSkip(t *testing.T) {
+	l, lines := newTestLogger(&Config{})
+	setDefaultLogger(l)
+	defer Init(nil)
+
+	ctx := context.Background()
+	wrappedErrorf(WithCallerSkip(l, 1), ctx, "custom")
+	wrappedErrorf(WithCallerSkip(nil, 1), ctx, "default")
+	wrappedErrorf(WithCallerSkip(WithCallerSkip(l, 0), 1), ctx, "nested")
+	wrappedErrorf(l, ctx, "wrapper")
+	l.Flush()
+
+	expected := []string{
+		"callerskip_test.go:19@",
+		"callerskip_test.go:20@",
+		"callerskip_test.go:21@",
+		"callerskip_test.go:10@",
+	}
+
+	if len(*lines) != len(expected) {
+		t.Fatalf("unexpected lines. [lines:%v]", *lines)
+	}
+
+	for i, caller := range expected {
+		if line := (*lines)[i]; !strings.Contains(line, caller) {
+			t.Fatalf("invalid caller. [line:%v] [expected:%v]", line, caller)
+		}
+	}
+}
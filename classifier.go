@@ -0,0 +1,111 @@
+package log
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrorClass 是错误分类的结果。
+type ErrorClass struct {
+	Level  Level  // Level 是日志的新级别，为 0 时保持原来的级别不变。
+	Fields []Info // Fields 是需要追加到日志中的信息，例如 err_class=timeout。
+}
+
+// ErrorClassifier 对错误进行分类，如果不认识这个错误，返回的 ok 为 false。
+// 可以在 ErrorClassifier 中使用 errors.Is/errors.As 判断错误类型。
+type ErrorClassifier func(err error) (class ErrorClass, ok bool)
+
+var (
+	classifiersMu sync.Mutex
+	classifiers   atomic.Value // []ErrorClassifier
+)
+
+// RegisterErrorClassifier 注册一个错误分类器。
+//
+// 输出日志时，如果日志参数或者 ctx 中的信息里包含 error，就会依次调用所有分类器，
+// 第一个返回 ok 的分类器决定日志的级别和追加的信息。Fatalf 和 Printf 输出的日志不会被分类。
+//
+// 例如，下面的分类器让 context.Canceled 只输出 Debug 日志：
+//
+//	log.RegisterErrorClassifier(func(err error) (log.ErrorClass, bool) {
+//		if errors.Is(err, context.Canceled) {
+//			return log.ErrorClass{Level: log.LogDebug}, true
+//		}
+//
+//		return log.ErrorClass{}, false
+//	})
+func RegisterErrorClassifier(classifier ErrorClassifier) {
+	if classifier == nil {
+		return
+	}
+
+	classifiersMu.Lock()
+	defer classifiersMu.Unlock()
+
+	old, _ := classifiers.Load().([]ErrorClassifier)
+	list := make([]ErrorClassifier, 0, len(old)+1)
+	list = append(list, old...)
+	list = append(list, classifier)
+	classifiers.Store(list)
+}
+
+// classifyError 查找日志中的第一个 error，并使用注册的分类器处理 entry。
+// 如果日志被降级到不需要输出的级别，返回 false。
+func (l *logger) classifyError(ctx context.Context, pc uintptr, entry *Entry, args []interface{}) bool {
+	if entry.Level == LogFatal || entry.Level == logPrint || entry.Level == logAudit {
+		return true
+	}
+
+	list, _ := classifiers.Load().([]ErrorClassifier)
+
+	if len(list) == 0 {
+		return true
+	}
+
+	err := findError(entry.Fields, args)
+
+	if err == nil {
+		return true
+	}
+
+	for _, classifier := range list {
+		class, ok := classifier(err)
+
+		if !ok {
+			continue
+		}
+
+		if len(class.Fields) != 0 {
+			// entry.Fields 可能和 ctx 共享底层数组，必须复制之后再追加。
+			fields := make([]Info, 0, len(entry.Fields)+len(class.Fields))
+			fields = append(fields, entry.Fields...)
+			entry.Fields = append(fields, class.Fields...)
+		}
+
+		if class.Level != 0 && class.Level != entry.Level {
+			entry.Level = class.Level
+			return l.enabled(ctx, pc, entry.Level)
+		}
+
+		return true
+	}
+
+	return true
+}
+
+func findError(fields []Info, args []interface{}) error {
+	for _, arg := range args {
+		if err, ok := arg.(error); ok && err != nil {
+			return err
+		}
+	}
+
+	for _, info := range fields {
+		if err, ok := info.Value.(error); ok && err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
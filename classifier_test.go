@@ -0,0 +1,41 @@
+package log
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+var errTestTimeout = errors.New("test timeout")
+
+func TestErrorClassifier(t *testing.T) {
+	old, _ := classifiers.Load().([]ErrorClassifier)
+	defer classifiers.Store(old)
+
+	RegisterErrorClassifier(func(err error) (ErrorClass, bool) {
+		switch err {
+		case context.Canceled:
+			return ErrorClass{Level: LogDebug}, true
+		case errTestTimeout:
+			return ErrorClass{Fields: []Info{String("err_class", "timeout")}}, true
+		}
+
+		return ErrorClass{}, false
+	})
+
+	l, lines := newTestLogger(&Config{})
+	defer l.Close()
+
+	ctx := context.Background()
+	l.Errorf(ctx, "request canceled: %v", context.Canceled)
+	l.Errorf(WithError(ctx, errTestTimeout), "query failed")
+
+	if len(*lines) != 1 {
+		t.Fatalf("canceled error should be downgraded to debug. [lines:%v]", *lines)
+	}
+
+	if line := (*lines)[0]; !strings.Contains(line, "||err_class=timeout||query failed") {
+		t.Fatalf("err_class should be added. [line:%v]", line)
+	}
+}
@@ -0,0 +1,36 @@
+package log
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+type clockFunc func() time.Time
+
+var globalClock atomic.Value // clockFunc
+
+// SetClock 设置所有日志获取当前时间的函数，Config.Clock 的优先级更高。
+// 一般用于测试中固定日志时间，clock 为 nil 时恢复使用 time.Now。
+func SetClock(clock func() time.Time) {
+	globalClock.Store(clockFunc(clock))
+}
+
+func (l *logger) now() time.Time {
+	if l.utc {
+		return l.localNow().UTC()
+	}
+
+	return l.localNow()
+}
+
+func (l *logger) localNow() time.Time {
+	if l.clock != nil {
+		return l.clock()
+	}
+
+	if clock, _ := globalClock.Load().(clockFunc); clock != nil {
+		return clock()
+	}
+
+	return time.Now()
+}
@@ -0,0 +1,62 @@
+package log
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClose(t *testing.T) {
+	dir := filepath.Join(os.TempDir(), "go-log-close-test")
+	os.RemoveAll(dir)
+	defer os.RemoveAll(dir)
+	defer Init(nil)
+
+	logPath := filepath.Join(dir, "all.log")
+	Init(&Config{
+		LogPath:      logPath,
+		ErrorLogPath: filepath.Join(dir, "error.log"),
+		AuditLogPath: filepath.Join(dir, "audit.log"),
+	})
+	Infof(context.Background(), "before close")
+
+	if err := Close(); err != nil {
+		t.Fatalf("fail to close. [err:%v]", err)
+	}
+
+	if err := Close(); err != nil {
+		t.Fatalf("close must be safe to call twice. [err:%v]", err)
+	}
+
+	if lines := readLines(t, logPath); len(lines) != 1 {
+		t.Fatalf("log must be flushed on close. [lines:%v]", lines)
+	}
+}
+
+func TestDrain(t *testing.T) {
+	l, _ := newTestLogger(&Config{})
+	atomic.AddInt64(&l.inflight, 1)
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		atomic.AddInt64(&l.inflight, -1)
+	}()
+
+	start := time.Now()
+	l.drain(time.Second)
+
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond || elapsed >= time.Second {
+		t.Fatalf("drain must wait until in-flight logs are done. [elapsed:%v]", elapsed)
+	}
+
+	atomic.AddInt64(&l.inflight, 1)
+	start = time.Now()
+	l.drain(20 * time.Millisecond)
+
+	if elapsed := time.Since(start); elapsed >= time.Second {
+		t.Fatalf("drain must give up after timeout. [elapsed:%v]", elapsed)
+	}
+}
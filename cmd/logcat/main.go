@@ -0,0 +1,331 @@
+// logcat 读取 FormatText 格式的日志文件，按照级别、tag 和字段过滤之后输出成易读的格式或者其他日志格式。
+//
+// 用法：
+//
+//	logcat [-f] [-level warn] [-tag tag] [-field key=value] [-format pretty|text|json|logfmt|tsv] [-escaped] [file ...]
+//
+// 没有指定文件时从 stdin 读取。使用 -f 时会持续读取文件新增的内容，文件被轮转之后会自动打开新的文件。
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	log "github.com/altstory/go-log"
+	"github.com/altstory/go-log/logparse"
+)
+
+const (
+	formatPretty = "pretty"
+
+	pollInterval = 200 * time.Millisecond
+)
+
+type filter struct {
+	level      log.Level
+	tag        string
+	fieldKey   string
+	fieldValue string
+	hasValue   bool
+}
+
+func main() {
+	follow := flag.Bool("f", false, "follow the files and reopen them after rotation")
+	level := flag.String("level", "", "only show logs at or above this level: debug, info, trace, warn, error or fatal")
+	tag := flag.String("tag", "", "only show logs with this tag")
+	field := flag.String("field", "", "only show logs with this field, in the form of key or key=value")
+	format := flag.String("format", formatPretty, "output format: pretty, text, json, logfmt or tsv")
+	escaped := flag.Bool("escaped", false, "parse logs written with Config.Escape")
+	flag.Parse()
+
+	f := &filter{
+		level: log.LogDebug,
+		tag:   *tag,
+	}
+
+	if *level != "" {
+		l, err := log.ParseLevel(*level)
+
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "logcat: %v\n", err)
+			os.Exit(2)
+		}
+
+		f.level = l
+	}
+
+	if *field != "" {
+		if idx := strings.IndexByte(*field, '='); idx >= 0 {
+			f.fieldKey = (*field)[:idx]
+			f.fieldValue = (*field)[idx+1:]
+			f.hasValue = true
+		} else {
+			f.fieldKey = *field
+		}
+	}
+
+	var encoder log.Encoder
+
+	switch *format {
+	case formatPretty:
+		encoder = prettyEncoder{}
+	case log.FormatText, log.FormatJSON, log.FormatLogfmt, log.FormatTSV:
+		encoder = log.NewEncoder(*format)
+	default:
+		fmt.Fprintf(os.Stderr, "logcat: unsupported format %q\n", *format)
+		os.Exit(2)
+	}
+
+	out := bufio.NewWriter(os.Stdout)
+	defer out.Flush()
+
+	c := &cat{
+		out:     out,
+		encoder: encoder,
+		filter:  f,
+		escaped: *escaped,
+	}
+	files := flag.Args()
+
+	if len(files) == 0 {
+		if err := c.read(os.Stdin); err != nil {
+			fmt.Fprintf(os.Stderr, "logcat: fail to read stdin. [err:%v]\n", err)
+			out.Flush()
+			os.Exit(1)
+		}
+
+		return
+	}
+
+	if *follow {
+		if len(files) != 1 {
+			fmt.Fprintln(os.Stderr, "logcat: -f only supports one file")
+			os.Exit(2)
+		}
+
+		if err := c.follow(files[0]); err != nil {
+			fmt.Fprintf(os.Stderr, "logcat: fail to follow file. [file:%v] [err:%v]\n", files[0], err)
+			out.Flush()
+			os.Exit(1)
+		}
+
+		return
+	}
+
+	for _, name := range files {
+		file, err := os.Open(name)
+
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "logcat: fail to open file. [file:%v] [err:%v]\n", name, err)
+			out.Flush()
+			os.Exit(1)
+		}
+
+		err = c.read(file)
+		file.Close()
+
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "logcat: fail to read file. [file:%v] [err:%v]\n", name, err)
+			out.Flush()
+			os.Exit(1)
+		}
+	}
+}
+
+type cat struct {
+	out     *bufio.Writer
+	encoder log.Encoder
+	filter  *filter
+	escaped bool
+	buf     bytes.Buffer
+}
+
+func (c *cat) read(in io.Reader) error {
+	reader := bufio.NewReader(in)
+
+	for {
+		line, err := reader.ReadString('\n')
+
+		if line != "" {
+			if err := c.handle(line); err != nil {
+				return err
+			}
+		}
+
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// follow 持续读取 name 新增的内容，发现文件被轮转（文件被替换或者被截断）之后重新打开文件。
+func (c *cat) follow(name string) error {
+	file, err := os.Open(name)
+
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		file.Close()
+	}()
+
+	reader := bufio.NewReader(file)
+	var partial string
+	var offset int64
+
+	for {
+		line, err := reader.ReadString('\n')
+		offset += int64(len(line))
+
+		if err == nil {
+			if err := c.handle(partial + line); err != nil {
+				return err
+			}
+
+			partial = ""
+			continue
+		}
+
+		if err != io.EOF {
+			return err
+		}
+
+		partial += line
+
+		if err := c.out.Flush(); err != nil {
+			return err
+		}
+
+		time.Sleep(pollInterval)
+		fi, err := os.Stat(name)
+
+		if err != nil {
+			// 轮转过程中文件可能暂时不存在。
+			continue
+		}
+
+		current, err := file.Stat()
+
+		if err != nil {
+			return err
+		}
+
+		if os.SameFile(fi, current) && fi.Size() >= offset {
+			continue
+		}
+
+		newFile, err := os.Open(name)
+
+		if err != nil {
+			continue
+		}
+
+		file.Close()
+		file = newFile
+		reader.Reset(file)
+		partial = ""
+		offset = 0
+	}
+}
+
+func (c *cat) handle(line string) error {
+	if strings.TrimSpace(line) == "" {
+		return nil
+	}
+
+	var entry *log.Entry
+	var err error
+
+	if c.escaped {
+		entry, err = logparse.ParseEscaped(line)
+	} else {
+		entry, err = logparse.Parse(line)
+	}
+
+	// 无法解析的行原样输出。
+	if err != nil {
+		_, err = c.out.WriteString(line)
+		return err
+	}
+
+	if !c.filter.match(entry) {
+		return nil
+	}
+
+	c.buf.Reset()
+	c.encoder.Encode(&c.buf, entry)
+	c.buf.WriteByte('\n')
+	_, err = c.out.Write(c.buf.Bytes())
+	return err
+}
+
+func (f *filter) match(entry *log.Entry) bool {
+	// Printf 和审计日志的级别小于 LogFatal，不受级别过滤影响。
+	if entry.Level.Severity() > f.level.Severity() {
+		return false
+	}
+
+	if f.tag != "" && entry.Tag != f.tag {
+		return false
+	}
+
+	if f.fieldKey == "" {
+		return true
+	}
+
+	for _, info := range entry.Fields {
+		if info.Key == f.fieldKey && (!f.hasValue || info.Value == f.fieldValue) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// prettyEncoder 输出适合人阅读的格式，字段放在消息后面：
+//
+//	12:34:56.789 INFO  tag file.go:12@pkg.Func: this is custom log text  key1=value1
+type prettyEncoder struct{}
+
+func (prettyEncoder) Encode(buf *bytes.Buffer, entry *log.Entry) {
+	if entry.Time.IsZero() {
+		buf.WriteString(entry.Message)
+		return
+	}
+
+	buf.WriteString(entry.Time.Format("15:04:05.000"))
+	fmt.Fprintf(buf, " %-5s", entry.Level.String())
+
+	if entry.Tag != "" {
+		buf.WriteByte(' ')
+		buf.WriteString(entry.Tag)
+	}
+
+	if entry.Caller != "" {
+		buf.WriteByte(' ')
+		buf.WriteString(entry.Caller)
+		buf.WriteByte(':')
+	}
+
+	buf.WriteByte(' ')
+	buf.WriteString(entry.Message)
+
+	if len(entry.Fields) != 0 {
+		buf.WriteByte(' ')
+	}
+
+	for _, info := range entry.Fields {
+		fmt.Fprintf(buf, " %v=%v", info.Key, info.Value)
+	}
+}
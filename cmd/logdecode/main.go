@@ -0,0 +1,89 @@
+// logdecode 将 FormatBinary 格式的日志文件转换成其他可读的格式。
+//
+// 用法：
+//
+//	logdecode [-format text|json|logfmt|tsv] [file ...]
+//
+// 没有指定文件时从 stdin 读取。
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	log "github.com/altstory/go-log"
+)
+
+func main() {
+	format := flag.String("format", log.FormatText, "output format: text, json, logfmt or tsv")
+	flag.Parse()
+
+	if *format == log.FormatBinary {
+		fmt.Fprintln(os.Stderr, "logdecode: output format cannot be binary")
+		os.Exit(2)
+	}
+
+	encoder := log.NewEncoder(*format)
+	out := bufio.NewWriter(os.Stdout)
+	defer out.Flush()
+
+	files := flag.Args()
+
+	if len(files) == 0 {
+		if err := decode(out, encoder, os.Stdin); err != nil {
+			fmt.Fprintf(os.Stderr, "logdecode: fail to decode stdin. [err:%v]\n", err)
+			out.Flush()
+			os.Exit(1)
+		}
+
+		return
+	}
+
+	for _, name := range files {
+		f, err := os.Open(name)
+
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "logdecode: fail to open file. [file:%v] [err:%v]\n", name, err)
+			out.Flush()
+			os.Exit(1)
+		}
+
+		err = decode(out, encoder, f)
+		f.Close()
+
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "logdecode: fail to decode file. [file:%v] [err:%v]\n", name, err)
+			out.Flush()
+			os.Exit(1)
+		}
+	}
+}
+
+func decode(out io.Writer, encoder log.Encoder, in io.Reader) error {
+	reader := log.NewBinaryReader(in)
+	buf := &bytes.Buffer{}
+
+	for {
+		entry, err := reader.Next()
+
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		buf.Reset()
+		encoder.Encode(buf, entry)
+		buf.WriteByte('\n')
+
+		if _, err := out.Write(buf.Bytes()); err != nil {
+			return err
+		}
+	}
+}
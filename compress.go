@@ -0,0 +1,105 @@
+package log
+
+import (
+	"compress/gzip"
+	"io"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// 各种日志压缩方式。
+const (
+	CompressGzip = "gzip" // CompressGzip 使用 gzip 压缩日志文件。
+	CompressZstd = "zstd" // CompressZstd 使用 zstd 压缩日志文件，压缩速度和压缩率都优于 gzip。
+)
+
+// rotator 是可以切割的日志文件。
+type rotator interface {
+	Rotate() error
+}
+
+// logFile 是可以写入和切割的日志文件。
+type logFile interface {
+	io.WriteCloser
+	rotator
+}
+
+// compressor 是一个流式压缩器，gzip.Writer 和 zstd.Encoder 都满足这个接口。
+type compressor interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+// compressFile 在写入文件的同时压缩数据。
+// Flush 会把已经写入的数据全部压缩并写入文件，Rotate 会先结束当前压缩流，保证每个文件都是完整的压缩文件。
+type compressFile struct {
+	mu         sync.Mutex
+	file       logFile
+	compressor compressor
+}
+
+var _ io.WriteCloser = new(compressFile)
+
+// newCompressFile 用 method 压缩写入 file 的数据，不支持的压缩方式返回 nil。
+func newCompressFile(file logFile, method string) *compressFile {
+	var c compressor
+
+	switch method {
+	case CompressGzip:
+		c = gzip.NewWriter(file)
+	case CompressZstd:
+		enc, err := zstd.NewWriter(file)
+
+		if err != nil {
+			return nil
+		}
+
+		c = enc
+	default:
+		return nil
+	}
+
+	return &compressFile{
+		file:       file,
+		compressor: c,
+	}
+}
+
+func (f *compressFile) Write(data []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.compressor.Write(data)
+}
+
+func (f *compressFile) Flush() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.compressor.Flush()
+}
+
+func (f *compressFile) Rotate() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.compressor.Close()
+	err := f.file.Rotate()
+	f.compressor.Reset(f.file)
+	return err
+}
+
+func (f *compressFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	err := f.compressor.Close()
+
+	if e := f.file.Close(); e != nil {
+		err = e
+	}
+
+	return err
+}
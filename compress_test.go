@@ -0,0 +1,49 @@
+package log
+
+import (
+	"compress/gzip"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCompressGzip(t *testing.T) {
+	dir := filepath.Join(os.TempDir(), "go-log-compress-test")
+	os.RemoveAll(dir)
+	defer os.RemoveAll(dir)
+
+	logPath := filepath.Join(dir, "all.log.gz")
+	l := newLogger(&Config{
+		LogPath:      logPath,
+		ErrorLogPath: logPath,
+		Compress:     CompressGzip,
+	})
+	l.Infof(context.Background(), "compressed line")
+	l.Close()
+
+	f, err := os.Open(logPath)
+
+	if err != nil {
+		t.Fatalf("fail to open log file. [err:%v]", err)
+	}
+
+	defer f.Close()
+	r, err := gzip.NewReader(f)
+
+	if err != nil {
+		t.Fatalf("log file should be gzip compressed. [err:%v]", err)
+	}
+
+	data, err := ioutil.ReadAll(r)
+
+	if err != nil {
+		t.Fatalf("fail to decompress log file. [err:%v]", err)
+	}
+
+	if !strings.HasSuffix(string(data), "compressed line\n") {
+		t.Fatalf("invalid log content. [content:%v]", string(data))
+	}
+}
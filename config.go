@@ -1,5 +1,11 @@
 package log
 
+import (
+	"io"
+	"os"
+	"time"
+)
+
 const (
 	// DefaultLogPath 日志文件的默认路径。
 	DefaultLogPath = "./log/all.log"
@@ -13,8 +19,42 @@ const (
 	// DefaultErrorLogLevel 是错误日志的默认级别。
 	DefaultErrorLogLevel = "warn"
 
+	// DefaultAuditLogPath 审计日志文件的默认路径。
+	DefaultAuditLogPath = "./log/audit.log"
+
 	// DefaultBufferedLines 是内存中缓存的日志行数。
 	DefaultBufferedLines = 1 << 18
+
+	// DefaultDiskCheckInterval 是检查磁盘剩余空间的默认间隔。
+	DefaultDiskCheckInterval = 10 * time.Second
+
+	// DefaultDiskDegradeLevel 是磁盘空间不足时的默认日志级别。
+	DefaultDiskDegradeLevel = "warn"
+
+	// DefaultDrainTimeout 是 Close 等待正在输出的日志写完的时间。
+	DefaultDrainTimeout = time.Second
+)
+
+// 各种日志输出方式。
+const (
+	OutputFile    = "file"    // OutputFile 是默认输出方式，日志写入 LogPath 和 ErrorLogPath。
+	OutputStdout  = "stdout"  // OutputStdout 让所有日志只写入 stdout，不创建任何文件，适合在容器中使用。
+	OutputDiscard = "discard" // OutputDiscard 不输出任何日志文件，日志只交给 Config.Sinks 处理。
+	OutputSidecar = "sidecar" // OutputSidecar 让所有日志按 sidecar 协议写入 SidecarPath，由 sidecar 进程负责上报。
+	OutputJournal = "journal" // OutputJournal 让所有日志只写入 systemd journal，适合作为 systemd unit 部署的服务。
+)
+
+// 各种备用输出。
+const (
+	FallbackStdout = "stdout" // FallbackStdout 在写日志失败时将日志写入 stdout。
+	FallbackStderr = "stderr" // FallbackStderr 在写日志失败时将日志写入 stderr。
+)
+
+// 调用者信息中文件路径的格式。
+const (
+	CallerBase    = "base"    // CallerBase 只输出文件名，例如 "handler.go"，这是默认格式。
+	CallerPackage = "package" // CallerPackage 输出 package 路径和文件名，例如 "app/user/handler.go"，package 路径会像函数名一样省略 PackagePrefix。
+	CallerFull    = "full"    // CallerFull 输出编译时的完整文件路径。
 )
 
 // Config 代表日志配置。
@@ -24,6 +64,184 @@ type Config struct {
 	ErrorLogPath  string `config:"error_log_path"`  // ErrorLogPath 是错误日志文件名，默认写到 DefaultErrorLogPath 里面。
 	ErrorLogLevel string `config:"error_log_level"` // ErrorLogLevel 是错误日志级别，当错误级别不大于这个级别时写入错误日志，默认是 DefaultErrorLogLevel。
 
-	PackagePrefix string `config:"package_prefix"` // PackagePrefix 设置最常用的 package 前缀，输出调用栈的时候会用 "." 代替这一长串字符，让日志看起来更简洁。
-	BufferedLines int    `config:"buffered_lines"` // BufferedLines 设置最多在内存中缓存的日志行数，默认是 DefaultBufferedLines。
+	AuditLogPath string `config:"audit_log_path"` // AuditLogPath 是审计日志文件名，默认写到 DefaultAuditLogPath 里面，OutputStdout 默认写到 stdout，第一次调用 Auditf 时才会创建文件。
+
+	PackagePrefix string        `config:"package_prefix"` // PackagePrefix 设置最常用的 package 前缀，输出调用栈的时候会用 "." 代替这一长串字符，让日志看起来更简洁。
+	CallerPath    string        `config:"caller_path"`    // CallerPath 设置调用者信息中文件路径的格式，可以是 CallerBase、CallerPackage 或 CallerFull，默认是 CallerBase。
+	Goroutine     bool          `config:"goroutine"`      // Goroutine 让每条日志带上当前 goroutine 的 ID，字段名是 GoroutineKey，方便追踪并发输出的日志，有一定性能开销，默认不开启。
+	BufferedLines int           `config:"buffered_lines"` // BufferedLines 设置最多在内存中缓存的日志行数，默认是 DefaultBufferedLines。
+	BufferedBytes int           `config:"buffered_bytes"` // BufferedBytes 设置每个日志文件最多在内存中缓存的字节数，超过之后新的日志会被丢弃，没有设置 BufferedLines 时会根据它估算缓存的行数，默认不限制。
+	DrainTimeout  time.Duration `config:"drain_timeout"`  // DrainTimeout 设置重新 Init 时最多等待多久让正在写入之前日志的调用完成，然后再关闭之前的日志，默认不等待。
+
+	Service  string `config:"service"`  // Service 是服务名，设置之后每条日志都会带上 ServiceKey 字段。
+	Version  string `config:"version"`  // Version 是服务版本，设置之后每条日志都会带上 VersionKey 字段。
+	Hostname bool   `config:"hostname"` // Hostname 让每条日志带上 HostnameKey 字段，值是启动时的机器名。
+	PID      bool   `config:"pid"`      // PID 让每条日志带上 PIDKey 字段，值是当前进程 ID。
+	Sequence bool   `config:"sequence"` // Sequence 让每条日志带上 SequenceKey 字段，值是这个日志实例中从 1 开始递增的序号，用于在采集之后发现丢失或者乱序的日志。
+
+	// StableFields 让 ctx 中的字段按照 key 排序，并且统一整数、浮点数和时间在所有格式中的输出，
+	// 方便比较日志和编写解析器。请求 ID 等固定字段依然在最前面。默认按照字段加入 ctx 的顺序输出。
+	StableFields bool `config:"stable_fields"`
+
+	Shards int `config:"shards"` // Shards 设置每个日志文件使用的缓冲区分片数量，每个分片由独立的 goroutine 写入，适合吞吐量非常高的场景。相同 tag 的日志保证有序，但不同分片之间的日志不保证全局有序。默认是 1，即保证全局有序。
+
+	SinglePipeline bool `config:"single_pipeline"` // SinglePipeline 让所有日志文件共享同一个缓冲区和写入 goroutine，保证不同文件之间的日志顺序完全一致，开启后 Shards 不再生效，最多支持 8 个日志文件。
+
+	FlushInterval time.Duration `config:"flush_interval"` // FlushInterval 设置定期刷新缓冲区的间隔，保证日志落盘的延迟不超过这个时间，默认不定期刷新。
+
+	RuntimeStatsInterval time.Duration `config:"runtime_stats_interval"` // RuntimeStatsInterval 设置定期输出 goroutine 数量、内存和 GC 等运行时统计信息的间隔，日志的 tag 是 RuntimeTag，级别是 Info，默认不输出。
+
+	DiskMinFree       int64         `config:"disk_min_free"`       // DiskMinFree 设置日志目录所在磁盘的最小剩余空间，单位是字节，低于这个值时只输出不低于 DiskDegradeLevel 的日志，默认不检查。
+	DiskCheckInterval time.Duration `config:"disk_check_interval"` // DiskCheckInterval 是检查磁盘剩余空间的间隔，默认是 DefaultDiskCheckInterval。
+	DiskDegradeLevel  string        `config:"disk_degrade_level"`  // DiskDegradeLevel 是磁盘空间不足时的日志级别，默认是 DefaultDiskDegradeLevel。
+
+	OnWriteError func(err error, line []byte) `config:"-"`        // OnWriteError 在日志写入失败或者因为缓冲区满被丢弃时调用，line 是没有写入成功的日志，不能阻塞太久。
+	Fallback     string                       `config:"fallback"` // Fallback 设置日志写入失败时的备用输出，可以是 FallbackStdout 或 FallbackStderr，默认不使用备用输出。
+
+	Sinks []Sink `config:"-"` // Sinks 是额外的日志输出目标，每条输出的日志都会同步交给所有 Sink 处理，Close 时会关闭所有 Sink。
+
+	RecentEntries int  `config:"recent_entries"` // RecentEntries 设置在内存中保存最近多少行日志，可以通过 DumpRecent 或 RecentHandler 查看，默认不保存。
+	RecentAll     bool `config:"recent_all"`     // RecentAll 让因为日志级别没有输出的日志也保存到 RecentEntries 的缓冲区中，方便出错时查看之前的调试日志，有一定性能开销，默认不保存。
+
+	// DebugOnError 设置之后，带有请求 ID 的日志如果因为日志级别没有输出，会按照请求 ID 暂存在内存中，
+	// 每个请求最多暂存 DebugOnError 条，只有这个请求之后输出了 error 或更高级别的日志时才会先写出暂存的日志，
+	// 这样平时只输出 info 等级别的日志，出错时依然能看到请求之前的调试日志。默认不暂存。
+	DebugOnError         int `config:"debug_on_error"`
+	DebugOnErrorRequests int `config:"debug_on_error_requests"` // DebugOnErrorRequests 是最多同时暂存日志的请求数，超过之后最早的请求暂存的日志会被丢弃，默认是 DefaultDebugOnErrorRequests。
+
+	TeeWriters []io.Writer `config:"-"` // TeeWriters 额外接收每一行编码好的日志，内容和日志文件中完全一样，不会替代日志文件，Close 时不会关闭，详见 AddWriter。
+
+	Clock func() time.Time `config:"-"` // Clock 设置获取当前时间的函数，用于测试或者回放日志，默认使用 SetClock 设置的时钟。
+
+	OnFatal FatalHandler `config:"-"` // OnFatal 设置 Fatalf 输出日志之后的行为，可以是 FatalPanic、FatalExit 或者自定义函数，默认是 FatalPanic。
+
+	Output      string `config:"output"`       // Output 是日志输出方式，可以是 OutputFile、OutputStdout、OutputSidecar、OutputJournal 或 OutputDiscard，默认是 OutputFile。
+	Format      string `config:"format"`       // Format 是日志格式，可以是 FormatText、FormatJSON、FormatLogfmt、FormatTSV 或 FormatBinary，OutputFile 默认用 FormatText，其他输出方式默认用 FormatJSON。
+	Escape      bool   `config:"escape"`       // Escape 让 FormatText 转义 tag、字段和消息中的分隔符和换行符，保证日志可以被无歧义的解析，默认不转义。
+	QuoteValues bool   `config:"quote_values"` // QuoteValues 让 FormatText 中包含空格、"="、"|"、引号或控制字符的字段值用引号括起来并转义，方便 Splunk 等工具提取字段，开启 Escape 时不生效，默认不开启。
+	Console     string `config:"console"`      // Console 设置日志是否同时回显到终端，错误日志回显到 stderr，其他日志回显到 stdout，可以是 ConsoleAuto、ConsoleAlways 或 ConsoleNever，默认是 ConsoleAuto。
+
+	ConsoleLevel string `config:"console_level"` // ConsoleLevel 是回显到终端的最低日志级别，例如 "warn" 只回显 warn 及以上级别的日志，Printf 输出的日志总是回显，默认回显所有输出的日志。
+
+	LevelNames map[string]string `config:"level_names"` // LevelNames 自定义日志中级别的名字，key 是 ParseLevel 能识别的级别，例如 {"warn": "WARNING", "error": "错误"}，对 FormatText、FormatJSON、FormatLogfmt 和 FormatTSV 生效，logparse 无法解析自定义的名字。
+
+	TimeFormat string `config:"time_format"` // TimeFormat 是日志中时间的格式，使用 time.Format 的 layout，例如 "2006-01-02 15:04:05.000"，也可以是 TimeFormatEpochMillis，默认是 RFC3339 格式。logparse 只能解析默认格式。
+	UTC        bool   `config:"utc"`         // UTC 让日志中的时间使用 UTC 时区，默认使用本地时区。
+
+	ShadowFormat string `config:"shadow_format"` // ShadowFormat 设置之后每条日志会额外用这个格式写入另一组文件，用于迁移日志格式时让下游逐步切换，只在 OutputFile 时生效，默认不开启。
+	ShadowSuffix string `config:"shadow_suffix"` // ShadowSuffix 是 ShadowFormat 文件名的后缀，追加在原日志文件名后面，默认是 "." 加上 ShadowFormat，例如 "./log/all.log.json"。
+
+	ModuleLevels map[string]string `config:"module_levels"` // ModuleLevels 设置每个 package 的日志级别，key 是 package 路径或者路径的最后几段，例如 "dao" 或 "app/dao"，子 package 也会使用这个级别。
+	TagLevels    map[string]string `config:"tag_levels"`    // TagLevels 设置每个 tag 的日志级别，优先于 ModuleLevels 和 LogLevel。
+	Verbosity    int               `config:"verbosity"`     // Verbosity 是 V 的详细程度，V(n) 只有在 n 不大于 Verbosity 时才输出，默认是 0，即只输出 V(0)。
+
+	Filters []Filter `config:"filters"` // Filters 设置日志过滤规则，可以丢弃或者降级匹配的日志。
+
+	Compress    string      `config:"compress"`     // Compress 设置日志文件的压缩方式，可以是 CompressGzip 或 CompressZstd，写入时实时压缩，不支持 FileBackendDated，默认不压缩。日志文件名需要自己加上对应的后缀。
+	FileMode    os.FileMode `config:"file_mode"`    // FileMode 是创建日志文件时使用的权限，例如 0600，设置之后已经存在的日志文件也会改成这个权限，默认是 DefaultFileMode。
+	DirMode     os.FileMode `config:"dir_mode"`     // DirMode 是自动创建日志目录时使用的权限，已经存在的目录不受影响，默认是 DefaultDirMode。
+	FileBackend string      `config:"file_backend"` // FileBackend 设置写日志文件的方式，可以是 FileBackendLumberjack、FileBackendAppend 或 FileBackendDated，默认是 FileBackendLumberjack。
+
+	Routes []Route `config:"routes"` // Routes 设置日志路由规则，设置之后 LogPath、ErrorLogPath 和 ErrorLogLevel 不再决定日志写入哪个文件。
+
+	SidecarPath string `config:"sidecar_path"` // SidecarPath 是 OutputSidecar 写入的命名管道或文件，默认写入 stdout。
+
+	LogConfig bool `config:"log_config"` // LogConfig 让 Init 之后用 Info 级别输出实际生效的日志配置，tag 是 ConfigTag，密码、token 等敏感信息会被隐藏，默认不输出。
+}
+
+// Route 代表一条日志路由规则，严重程度在 MinLevel 和 MaxLevel 之间（包含边界）的日志会写入 Path。
+// 多条规则可以写入同一个文件，一条日志会写入所有匹配的规则。
+// Printf 输出的日志无视级别，总是写入第一条规则对应的文件。
+//
+// 例如，下面的规则让 warn 及以上级别写入 error.log，trace 日志只写入 trace.log，所有日志都写入 all.log：
+//
+//	[]Route{
+//		{Path: "./log/all.log"},
+//		{MinLevel: "warn", Path: "./log/error.log"},
+//		{MinLevel: "trace", MaxLevel: "trace", Path: "./log/trace.log"},
+//	}
+type Route struct {
+	MinLevel string `config:"min_level"` // MinLevel 是最低的严重级别，默认是 debug，即所有级别。
+	MaxLevel string `config:"max_level"` // MaxLevel 是最高的严重级别，默认是 fatal。
+	Path     string `config:"path"`      // Path 是日志文件名。
+}
+
+// withDefaults 返回一份填上了默认值的配置，不修改 config 本身。
+// newLogger、validateConfig 和 LogConfig 都使用这个函数，默认值只在这里决定，输出的配置和实际生效的配置保持一致。
+func withDefaults(config *Config) *Config {
+	c := *config
+
+	if c.Output == "" {
+		c.Output = OutputFile
+	}
+
+	if c.Format == "" && c.Output == OutputFile {
+		c.Format = FormatText
+	} else if c.Format == "" {
+		c.Format = FormatJSON
+	}
+
+	if c.LogLevel == "" {
+		c.LogLevel = DefaultLogLevel
+	}
+
+	if c.Output == OutputFile {
+		if c.ErrorLogLevel == "" {
+			c.ErrorLogLevel = DefaultErrorLogLevel
+		}
+
+		// 设置了路由规则时 LogPath 和 ErrorLogPath 不再生效。
+		if len(c.Routes) == 0 {
+			if c.LogPath == "" {
+				c.LogPath = DefaultLogPath
+			}
+
+			if c.ErrorLogPath == "" {
+				c.ErrorLogPath = DefaultErrorLogPath
+			}
+		}
+	}
+
+	// 只写 stdout 或者丢弃日志时，没有指定审计日志文件就不会创建审计日志文件。
+	if c.AuditLogPath == "" && c.Output != OutputStdout && c.Output != OutputDiscard {
+		c.AuditLogPath = DefaultAuditLogPath
+	}
+
+	if c.Console == "" {
+		c.Console = ConsoleAuto
+	}
+
+	if c.CallerPath == "" {
+		c.CallerPath = CallerBase
+	}
+
+	if c.FileMode == 0 {
+		c.FileMode = DefaultFileMode
+	}
+
+	if c.DirMode == 0 {
+		c.DirMode = DefaultDirMode
+	}
+
+	if c.FileBackend == "" {
+		c.FileBackend = FileBackendLumberjack
+	}
+
+	if c.Shards <= 0 {
+		c.Shards = 1
+	}
+
+	c.BufferedLines = bufferedLinesOf(config)
+
+	if c.DiskMinFree > 0 {
+		if c.DiskCheckInterval <= 0 {
+			c.DiskCheckInterval = DefaultDiskCheckInterval
+		}
+
+		if c.DiskDegradeLevel == "" {
+			c.DiskDegradeLevel = DefaultDiskDegradeLevel
+		}
+	}
+
+	return &c
 }
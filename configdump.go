@@ -0,0 +1,185 @@
+package log
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ConfigTag 是 Config.LogConfig 输出日志的 tag。
+const ConfigTag = "log_config"
+
+// maskedValue 是敏感配置被隐藏之后的值，和 url.URL.Redacted 一样，不会被 URL 转义。
+const maskedValue = "xxxxx"
+
+// sensitiveWords 是敏感配置名中包含的词，匹配时忽略大小写。
+var sensitiveWords = []string{"password", "passwd", "secret", "token", "credential", "auth", "apikey", "api_key"}
+
+// logConfig 用 Info 级别输出 config 加上默认值之后实际生效的配置，每个配置项是一个字段，
+// 字段名是 config tag，嵌套的配置用 "." 连接，例如 "routes.0.path"。
+// 名字像密码、token 的配置和 URL 中的密码会被隐藏，零值的配置项不输出。
+func (l *logger) logConfig(config *Config) {
+	ctx := WithMoreInfo(WithTag(context.Background(), ConfigTag), dumpConfig(withDefaults(config))...)
+	l.output(ctx, callerPC(1), LogInfo, "log config")
+}
+
+func dumpConfig(config *Config) []Info {
+	var fields []Info
+	dumpValue(&fields, "", reflect.ValueOf(config).Elem())
+	return fields
+}
+
+func dumpValue(fields *[]Info, key string, v reflect.Value) {
+	if isZeroValue(v) {
+		return
+	}
+
+	switch value := v.Interface().(type) {
+	case time.Duration:
+		*fields = append(*fields, Duration(key, value))
+		return
+	case os.FileMode:
+		*fields = append(*fields, String(key, "0"+strconv.FormatUint(uint64(value), 8)))
+		return
+	case []string:
+		*fields = append(*fields, String(key, maskConfig(key, strings.Join(value, ","))))
+		return
+	case map[string]string:
+		pairs := make([]string, 0, len(value))
+
+		for k, val := range value {
+			pairs = append(pairs, k+"="+maskConfig(k, val))
+		}
+
+		sort.Strings(pairs)
+		*fields = append(*fields, String(key, maskConfig(key, strings.Join(pairs, ","))))
+		return
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		*fields = append(*fields, String(key, maskConfig(key, v.String())))
+	case reflect.Bool:
+		*fields = append(*fields, Bool(key, v.Bool()))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		*fields = append(*fields, Int64(key, v.Int()))
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			dumpValue(fields, joinConfigKey(key, strconv.Itoa(i)), v.Index(i))
+		}
+	case reflect.Struct:
+		t := v.Type()
+
+		for i := 0; i < t.NumField(); i++ {
+			tag := t.Field(i).Tag.Get("config")
+
+			if tag == "" || tag == "-" {
+				continue
+			}
+
+			dumpValue(fields, joinConfigKey(key, tag), v.Field(i))
+		}
+	}
+}
+
+// isZeroValue 判断 v 是否是零值。
+// reflect.Value.IsZero 需要 Go 1.13，这里自己实现一份。
+func isZeroValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Slice, reflect.Map:
+		return v.Len() == 0
+	case reflect.Ptr, reflect.Interface, reflect.Func, reflect.Chan:
+		return v.IsNil()
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if !isZeroValue(v.Index(i)) {
+				return false
+			}
+		}
+
+		return true
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if !isZeroValue(v.Field(i)) {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	return false
+}
+
+func joinConfigKey(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+
+	return prefix + "." + key
+}
+
+// maskConfig 隐藏敏感的配置值。
+// 如果 key 像是密码或者 token，整个值都会被隐藏，否则只隐藏 URL 中的密码和敏感的 query 参数。
+func maskConfig(key, value string) string {
+	if isSensitive(key) {
+		return maskedValue
+	}
+
+	if !strings.Contains(value, "://") {
+		return value
+	}
+
+	u, err := url.Parse(value)
+
+	if err != nil {
+		return value
+	}
+
+	if u.User != nil {
+		if _, ok := u.User.Password(); ok {
+			u.User = url.UserPassword(u.User.Username(), maskedValue)
+		}
+	}
+
+	if u.RawQuery != "" {
+		query := u.Query()
+
+		for k := range query {
+			if isSensitive(k) {
+				query.Set(k, maskedValue)
+			}
+		}
+
+		u.RawQuery = query.Encode()
+	}
+
+	return u.String()
+}
+
+func isSensitive(key string) bool {
+	key = strings.ToLower(key)
+
+	for _, word := range sensitiveWords {
+		if strings.Contains(key, word) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,35 @@
+package log
+
+import (
+	"io"
+	"os"
+
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+// 日志回显到终端的方式。
+const (
+	ConsoleAuto   = "auto"   // ConsoleAuto 只在 stdout/stderr 是终端时回显日志，这是默认方式。
+	ConsoleAlways = "always" // ConsoleAlways 总是把日志回显到 stdout/stderr，即使它们被重定向到了文件或管道。
+	ConsoleNever  = "never"  // ConsoleNever 不回显日志。
+)
+
+// newConsole 根据 mode 返回普通日志和错误日志回显的位置，不需要回显时返回 nil。
+func newConsole(mode string) (stdout, stderr io.Writer) {
+	switch mode {
+	case ConsoleNever:
+		return nil, nil
+	case ConsoleAlways:
+		return os.Stdout, os.Stderr
+	}
+
+	if terminal.IsTerminal(int(os.Stdout.Fd())) {
+		stdout = os.Stdout
+	}
+
+	if terminal.IsTerminal(int(os.Stderr.Fd())) {
+		stderr = os.Stderr
+	}
+
+	return
+}
@@ -0,0 +1,72 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestConsole(t *testing.T) {
+	if stdout, stderr := newConsole(ConsoleAlways); stdout != os.Stdout || stderr != os.Stderr {
+		t.Fatalf("ConsoleAlways must echo to stdout and stderr.")
+	}
+
+	if stdout, stderr := newConsole(ConsoleNever); stdout != nil || stderr != nil {
+		t.Fatalf("ConsoleNever must not echo.")
+	}
+
+	l, lines := newTestLogger(&Config{})
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	l.noConsole = false
+	l.errorLevel = LogWarn
+	l.consoleOut = stdout
+	l.consoleErr = stderr
+
+	ctx := context.Background()
+	l.Infof(ctx, "info")
+	l.Errorf(ctx, "error")
+
+	if len(*lines) != 2 {
+		t.Fatalf("all logs must be written. [lines:%v]", *lines)
+	}
+
+	if out := stdout.String(); !strings.Contains(out, "||info") || strings.Contains(out, "||error") {
+		t.Fatalf("info must be echoed to stdout. [stdout:%v]", out)
+	}
+
+	if out := stderr.String(); !strings.Contains(out, "||error") || strings.Contains(out, "||info") {
+		t.Fatalf("error must be echoed to stderr. [stderr:%v]", out)
+	}
+}
+
+func TestConsoleLevel(t *testing.T) {
+	l, lines := newTestLogger(&Config{LogLevel: "debug", ConsoleLevel: "warn"})
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	l.noConsole = false
+	l.errorLevel = LogError
+	l.consoleOut = stdout
+	l.consoleErr = stderr
+
+	ctx := context.Background()
+	l.Debugf(ctx, "debug")
+	l.Infof(ctx, "info")
+	l.Warnf(ctx, "warn")
+	l.Errorf(ctx, "error")
+	l.Printf(ctx, "print")
+
+	if len(*lines) != 5 {
+		t.Fatalf("all logs must be written. [lines:%v]", *lines)
+	}
+
+	if out := stdout.String(); strings.Contains(out, "||debug") || strings.Contains(out, "||info") || !strings.Contains(out, "||warn") || !strings.Contains(out, "print") {
+		t.Fatalf("only warn and print must be echoed to stdout. [stdout:%v]", out)
+	}
+
+	if out := stderr.String(); !strings.Contains(out, "||error") {
+		t.Fatalf("error must be echoed to stderr. [stderr:%v]", out)
+	}
+}
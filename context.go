@@ -6,6 +6,8 @@ import (
 
 type logTag struct{}
 type logMoreInfo struct{}
+type logLogger struct{}
+type logGroup struct{}
 
 // Info 代表一个 k=v 键值对。
 type Info struct {
@@ -20,6 +22,8 @@ type moreInfo struct {
 var (
 	keyLogTag      logTag
 	keyLogMoreInfo logMoreInfo
+	keyLogLogger   logLogger
+	keyLogGroup    logGroup
 )
 
 // WithTag 在 ctx 里面存一个日志 tag 信息，用于日志输出。
@@ -38,11 +42,23 @@ func tag(ctx context.Context) string {
 }
 
 // WithMoreInfo 在 ctx 里面保存更多的信息，可以自动在输出 log 时候将这些信息以 k=v 形式输出。
+// 如果 ctx 里面设置过 WithGroup，key 会加上 group 前缀。
 func WithMoreInfo(ctx context.Context, info ...Info) context.Context {
 	if len(info) == 0 {
 		return ctx
 	}
 
+	if prefix := group(ctx); prefix != "" {
+		grouped := make([]Info, 0, len(info))
+
+		for _, i := range info {
+			i.Key = prefix + i.Key
+			grouped = append(grouped, i)
+		}
+
+		info = grouped
+	}
+
 	var infoList []Info
 
 	if more := ctx.Value(keyLogMoreInfo); more != nil {
@@ -66,3 +82,34 @@ func findMoreInfo(ctx context.Context) []Info {
 
 	return more.(moreInfo).infoList
 }
+
+// WithGroup 在 ctx 里面设置一个 group，之后通过 WithMoreInfo 保存的信息 key 都会加上 "name." 前缀，
+// 例如 WithGroup(ctx, "db") 之后保存的 status 会输出成 db.status，避免不同层之间的 key 冲突。
+// 多次调用 WithGroup 会嵌套，例如 "http.db.status"，之前已经保存的信息不受影响。
+func WithGroup(ctx context.Context, name string) context.Context {
+	if name == "" {
+		return ctx
+	}
+
+	return context.WithValue(ctx, keyLogGroup, group(ctx)+name+".")
+}
+
+func group(ctx context.Context) string {
+	prefix, _ := ctx.Value(keyLogGroup).(string)
+	return prefix
+}
+
+// NewContext 在 ctx 里面保存一个 Logger，一般由框架为每个请求设置，
+// 下游代码可以通过 FromContext 取出这个 Logger 输出日志。
+func NewContext(ctx context.Context, l Logger) context.Context {
+	return context.WithValue(ctx, keyLogLogger, l)
+}
+
+// FromContext 返回 NewContext 保存在 ctx 里面的 Logger，如果没有保存过则返回默认日志。
+func FromContext(ctx context.Context) Logger {
+	if l, ok := ctx.Value(keyLogLogger).(Logger); ok && l != nil {
+		return l
+	}
+
+	return defaultLogger()
+}
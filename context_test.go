@@ -0,0 +1,42 @@
+package log
+
+import (
+	"context"
+	"testing"
+)
+
+func TestFromContext(t *testing.T) {
+	ctx := context.Background()
+
+	if l := FromContext(ctx); l != Logger(defaultLogger()) {
+		t.Fatalf("default logger should be returned.")
+	}
+
+	nop := Nop()
+	ctx = NewContext(ctx, nop)
+
+	if l := FromContext(ctx); l != nop {
+		t.Fatalf("logger in context should be returned.")
+	}
+}
+
+func TestWithGroup(t *testing.T) {
+	ctx := WithMoreInfo(context.Background(), Info{Key: "status", Value: 200})
+	ctx = WithGroup(ctx, "db")
+	ctx = WithMoreInfo(ctx, Info{Key: "status", Value: "ok"})
+	ctx = WithGroup(ctx, "rows")
+	ctx = WithMoreInfo(ctx, Info{Key: "count", Value: 3})
+
+	expected := []string{"status", "db.status", "db.rows.count"}
+	infoList := findMoreInfo(ctx)
+
+	if len(infoList) != len(expected) {
+		t.Fatalf("invalid info list. [info:%v]", infoList)
+	}
+
+	for i, info := range infoList {
+		if info.Key != expected[i] {
+			t.Fatalf("invalid key. [expected:%v] [actual:%v]", expected[i], info.Key)
+		}
+	}
+}
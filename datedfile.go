@@ -0,0 +1,104 @@
+package log
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// datedFileLayout 是 FileBackendDated 文件名中的日期格式。
+const datedFileLayout = "2006-01-02"
+
+// datedFile 每天写入一个带日期的文件，例如 "./log/all.log.2024-05-21"，文件在第一次写入时才打开，日期按照本地时区计算。
+// path 本身是指向当前文件的符号链接，让 tail -F 和日志采集工具不需要关心文件名的变化。
+type datedFile struct {
+	mu    sync.Mutex
+	path  string
+	modes fileModes
+	file  *os.File
+	next  time.Time // next 是需要切换到下一个文件的时间。
+	now   func() time.Time
+}
+
+var _ logFile = new(datedFile)
+
+func newDatedFile(path string, modes fileModes) *datedFile {
+	return &datedFile{
+		path:  path,
+		modes: modes,
+		now:   time.Now,
+	}
+}
+
+func (f *datedFile) Write(data []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if now := f.now(); f.file == nil || !now.Before(f.next) {
+		if err := f.open(now); err != nil {
+			return 0, err
+		}
+	}
+
+	return f.file.Write(data)
+}
+
+// open 打开 now 对应的文件，并让 path 指向这个文件。
+func (f *datedFile) open(now time.Time) error {
+	name := f.path + "." + now.Format(datedFileLayout)
+	file, err := f.modes.openFile(name)
+
+	if err != nil {
+		return err
+	}
+
+	if f.file != nil {
+		f.file.Close()
+	}
+
+	year, month, day := now.Date()
+	f.file = file
+	f.next = time.Date(year, month, day+1, 0, 0, 0, 0, now.Location())
+
+	// 符号链接只是为了方便查看，创建失败不影响写日志。
+	f.link(name)
+	return nil
+}
+
+// link 让 path 成为指向 target 的符号链接。
+// 先创建一个临时的符号链接再改名，保证任何时候 path 都是一个完整的链接。
+// 如果 path 是一个普通文件，为了不覆盖之前的日志，不会创建链接。
+func (f *datedFile) link(target string) error {
+	if info, err := os.Lstat(f.path); err == nil && info.Mode()&os.ModeSymlink == 0 {
+		return fmt.Errorf("go-log: %v is not a symlink", f.path)
+	}
+
+	tmp := f.path + ".link"
+	os.Remove(tmp)
+
+	if err := os.Symlink(filepath.Base(target), tmp); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, f.path)
+}
+
+// Rotate 关闭当前文件，下次写入时重新打开当天的文件，方便 logrotate 等工具移走文件。
+func (f *datedFile) Rotate() error {
+	return f.Close()
+}
+
+func (f *datedFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return nil
+	}
+
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
@@ -0,0 +1,74 @@
+package log
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestDatedFile(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlink requires privilege on windows.")
+	}
+
+	dir := filepath.Join(os.TempDir(), "go-log-datedfile-test")
+	os.RemoveAll(dir)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "all.log")
+	now := time.Date(2024, 5, 21, 23, 59, 0, 0, time.Local)
+	f := newDatedFile(path, fileModes{})
+	f.now = func() time.Time {
+		return now
+	}
+	defer f.Close()
+
+	f.Write([]byte("day 1\n"))
+
+	if target, err := os.Readlink(path); err != nil || target != "all.log.2024-05-21" {
+		t.Fatalf("invalid symlink. [target:%v] [err:%v]", target, err)
+	}
+
+	now = now.Add(2 * time.Minute)
+	f.Write([]byte("day 2\n"))
+	f.Rotate()
+	f.Write([]byte("day 2 again\n"))
+
+	if target, err := os.Readlink(path); err != nil || target != "all.log.2024-05-22" {
+		t.Fatalf("symlink must follow the current file. [target:%v] [err:%v]", target, err)
+	}
+
+	for name, expected := range map[string]string{
+		path + ".2024-05-21": "day 1\n",
+		path + ".2024-05-22": "day 2\nday 2 again\n",
+		path:                 "day 2\nday 2 again\n",
+	} {
+		if data, err := ioutil.ReadFile(name); err != nil || string(data) != expected {
+			t.Fatalf("invalid content. [name:%v] [data:%q] [err:%v]", name, data, err)
+		}
+	}
+}
+
+func TestDatedFileKeepsRegularFile(t *testing.T) {
+	dir := filepath.Join(os.TempDir(), "go-log-datedfile-regular-test")
+	os.RemoveAll(dir)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "all.log")
+	os.MkdirAll(dir, 0755)
+	ioutil.WriteFile(path, []byte("old\n"), 0644)
+
+	f := newDatedFile(path, fileModes{})
+	defer f.Close()
+
+	if _, err := f.Write([]byte("new\n")); err != nil {
+		t.Fatalf("fail to write. [err:%v]", err)
+	}
+
+	if data, _ := ioutil.ReadFile(path); string(data) != "old\n" {
+		t.Fatalf("regular file must not be replaced. [data:%q]", data)
+	}
+}
@@ -0,0 +1,122 @@
+package log
+
+import (
+	"context"
+	"sync"
+)
+
+// DefaultDebugOnErrorRequests 是 DebugOnError 默认最多同时保留日志的请求数。
+const DefaultDebugOnErrorRequests = 1024
+
+// heldEntry 是一条暂存的日志。
+type heldEntry struct {
+	pc    uintptr
+	entry *Entry
+}
+
+// debugHold 按照请求 ID 暂存因为日志级别没有输出的日志，请求输出错误日志时再把暂存的日志写出去。
+// 同时暂存的请求数超过 maxRequests 时，最早开始暂存的请求会被丢弃。
+type debugHold struct {
+	mu          sync.Mutex
+	maxLines    int
+	maxRequests int
+	requests    map[string][]heldEntry
+	order       []string // order 是按照开始暂存的顺序排列的请求 ID，可能包含已经被取走的请求。
+}
+
+func newDebugHold(maxLines, maxRequests int) *debugHold {
+	if maxRequests <= 0 {
+		maxRequests = DefaultDebugOnErrorRequests
+	}
+
+	return &debugHold{
+		maxLines:    maxLines,
+		maxRequests: maxRequests,
+		requests:    map[string][]heldEntry{},
+	}
+}
+
+// add 暂存请求 id 的一条日志，每个请求只保留最新的 maxLines 条。
+func (h *debugHold) add(id string, pc uintptr, entry *Entry) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	held, ok := h.requests[id]
+
+	if !ok {
+		h.evict()
+		h.order = append(h.order, id)
+	}
+
+	if len(held) >= h.maxLines {
+		copy(held, held[1:])
+		held = held[:len(held)-1]
+	}
+
+	h.requests[id] = append(held, heldEntry{pc: pc, entry: entry})
+}
+
+// evict 在暂存的请求数达到上限时丢弃最早的请求，并清理 order 中已经被取走的请求。
+func (h *debugHold) evict() {
+	for len(h.requests) >= h.maxRequests && len(h.order) != 0 {
+		delete(h.requests, h.order[0])
+		h.order = h.order[1:]
+	}
+
+	if len(h.order) < 2*h.maxRequests {
+		return
+	}
+
+	order := make([]string, 0, len(h.requests))
+
+	for _, id := range h.order {
+		if _, ok := h.requests[id]; ok {
+			order = append(order, id)
+		}
+	}
+
+	h.order = order
+}
+
+// take 取走请求 id 暂存的所有日志。
+func (h *debugHold) take(id string) []heldEntry {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	held := h.requests[id]
+	delete(h.requests, id)
+	return held
+}
+
+// keepsSuppressed 判断因为日志级别没有输出的日志是否需要保存下来。
+func (l *logger) keepsSuppressed(ctx context.Context) bool {
+	return l.recentAll || l.hold != nil && RequestID(ctx) != ""
+}
+
+// suppress 保存一条因为日志级别没有输出的日志，放入最近日志的缓冲区或者按照请求 ID 暂存。
+func (l *logger) suppress(ctx context.Context, pc uintptr, level Level, format string, args []interface{}) {
+	entry := l.newEntry(ctx, pc, level, format, args...)
+
+	if l.recentAll {
+		l.keepRecent(entry)
+	}
+
+	if l.hold != nil {
+		if id := RequestID(ctx); id != "" {
+			l.hold.add(id, pc, entry)
+		}
+	}
+}
+
+// releaseHeld 输出请求暂存的所有日志，在请求输出错误日志之前调用。
+func (l *logger) releaseHeld(ctx context.Context) {
+	id := RequestID(ctx)
+
+	if id == "" {
+		return
+	}
+
+	for _, held := range l.hold.take(id) {
+		l.emit(ctx, held.pc, held.entry, nil)
+	}
+}
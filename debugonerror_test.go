@@ -0,0 +1,69 @@
+package log
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestDebugOnError(t *testing.T) {
+	l, lines := newTestLogger(&Config{LogLevel: "info", DebugOnError: 2})
+	setDefaultLogger(l)
+	defer Init(nil)
+
+	ok := SetRequestID(context.Background(), "ok")
+	failed := SetRequestID(context.Background(), "failed")
+
+	Debugf(ok, "ok debug")
+	Debugf(failed, "failed debug 1")
+	Debugf(failed, "failed debug 2")
+	Debugf(failed, "failed debug 3")
+	Debugf(context.Background(), "no request")
+	Infof(ok, "ok info")
+
+	if len(*lines) != 1 {
+		t.Fatalf("debug logs must be held. [lines:%v]", *lines)
+	}
+
+	Errorf(failed, "failed error")
+
+	if len(*lines) != 4 {
+		t.Fatalf("held logs must be written before error. [lines:%v]", *lines)
+	}
+
+	for i, msg := range []string{"failed debug 2", "failed debug 3", "failed error"} {
+		if line := (*lines)[i+1]; !strings.Contains(line, "request_id=failed") || !strings.HasSuffix(line, "||"+msg+"\n") {
+			t.Fatalf("invalid held log. [line:%v] [expected:%v]", line, msg)
+		}
+	}
+
+	if !strings.HasPrefix((*lines)[1], "[DEBUG]") || !strings.Contains((*lines)[1], "debugonerror_test.go") {
+		t.Fatalf("held log must keep level and caller. [line:%v]", (*lines)[1])
+	}
+
+	Errorf(failed, "failed again")
+
+	if len(*lines) != 5 {
+		t.Fatalf("held logs must be written once. [lines:%v]", *lines)
+	}
+}
+
+func TestDebugHoldEvict(t *testing.T) {
+	h := newDebugHold(10, 2)
+	h.add("a", 0, &Entry{})
+	h.add("b", 0, &Entry{})
+	h.add("c", 0, &Entry{})
+
+	if len(h.take("a")) != 0 || len(h.take("b")) != 1 || len(h.take("c")) != 1 {
+		t.Fatalf("oldest request must be evicted.")
+	}
+
+	for _, id := range []string{"d", "e", "f", "g", "h"} {
+		h.add(id, 0, &Entry{})
+		h.take(id)
+	}
+
+	if len(h.order) >= 2*h.maxRequests {
+		t.Fatalf("taken requests must be cleaned up. [order:%v]", h.order)
+	}
+}
@@ -0,0 +1,13 @@
+//go:build !linux && !darwin && !freebsd && !windows
+// +build !linux,!darwin,!freebsd,!windows
+
+package log
+
+import "errors"
+
+var errDiskFreeUnsupported = errors.New("go-log: disk free space is not supported on this platform")
+
+// diskFree 在不支持的平台上总是返回错误，checkDisk 会跳过检查。
+func diskFree(dir string) (uint64, error) {
+	return 0, errDiskFreeUnsupported
+}
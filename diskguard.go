@@ -0,0 +1,74 @@
+package log
+
+import (
+	"context"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+)
+
+// startDiskGuard 定期检查日志目录所在磁盘的剩余空间，空间不足时降低日志输出量，避免日志写满磁盘影响服务。
+func (l *logger) startDiskGuard(config *Config) {
+	interval := config.DiskCheckInterval
+	l.degradeLevel = parseLevel(config.DiskDegradeLevel)
+	dirs := make([]string, 0, len(l.paths))
+	seen := map[string]bool{}
+
+	for _, p := range l.paths {
+		dir := filepath.Dir(p)
+
+		if !seen[dir] {
+			seen[dir] = true
+			dirs = append(dirs, dir)
+		}
+	}
+
+	l.checkDisk(dirs, config.DiskMinFree)
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				l.checkDisk(dirs, config.DiskMinFree)
+			case <-l.closing:
+				return
+			}
+		}
+	}()
+}
+
+// checkDisk 检查 dirs 所在磁盘的剩余空间，并在空间不足或者恢复时切换状态。
+func (l *logger) checkDisk(dirs []string, minFree int64) {
+	low := false
+	lowDir := ""
+	var lowFree uint64
+
+	for _, dir := range dirs {
+		free, err := diskFree(dir)
+
+		// 目录还不存在或者系统不支持时不做任何处理。
+		if err != nil {
+			continue
+		}
+
+		if free < uint64(minFree) {
+			low = true
+			lowDir = dir
+			lowFree = free
+			break
+		}
+	}
+
+	ctx := context.Background()
+
+	if low {
+		if atomic.CompareAndSwapInt32(&l.degraded, 0, 1) {
+			l.output(ctx, 0, LogError, "go-log: disk space is running low, only logs at %v level or above are written. [dir:%v] [free:%v] [min_free:%v]",
+				l.degradeLevel.String(), lowDir, lowFree, minFree)
+		}
+	} else if atomic.CompareAndSwapInt32(&l.degraded, 1, 0) {
+		l.output(ctx, 0, LogWarn, "go-log: disk space is back to normal, all logs are written again.")
+	}
+}
@@ -0,0 +1,36 @@
+package log
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestDiskGuard(t *testing.T) {
+	l, lines := newTestLogger(&Config{})
+	defer l.Close()
+
+	l.degradeLevel = LogWarn
+	dirs := []string{os.TempDir()}
+	ctx := context.Background()
+
+	// 不可能满足的剩余空间要求，一定会触发降级。
+	l.checkDisk(dirs, 1<<62)
+	l.Infof(ctx, "dropped")
+	l.Warnf(ctx, "kept")
+	l.checkDisk(dirs, 1)
+	l.Infof(ctx, "recovered")
+
+	if len(*lines) != 4 {
+		t.Fatalf("invalid line count. [lines:%v]", *lines)
+	}
+
+	expected := []string{"disk space is running low", "kept", "disk space is back to normal", "recovered"}
+
+	for i, e := range expected {
+		if !strings.Contains((*lines)[i], e) {
+			t.Fatalf("invalid line. [i:%v] [expected:%v] [actual:%v]", i, e, (*lines)[i])
+		}
+	}
+}
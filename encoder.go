@@ -0,0 +1,555 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// 各种日志格式。
+const (
+	FormatText   = "text"   // FormatText 是默认的文本格式，用 "||" 分隔各个字段。
+	FormatJSON   = "json"   // FormatJSON 是 JSON 格式，每行一个 JSON 对象。
+	FormatLogfmt = "logfmt" // FormatLogfmt 是 logfmt 格式，每行由若干 key=value 组成。
+	FormatTSV    = "tsv"    // FormatTSV 是固定列的 TSV 格式，方便直接导入 Hive 等数据仓库。
+)
+
+// Entry 代表一条日志的全部内容。
+type Entry struct {
+	Level   Level
+	Time    time.Time
+	Caller  string // Caller 是调用者信息，格式为 "file:line@func"。
+	Tag     string
+	Fields  []Info
+	Message string
+
+	pc        uintptr // pc 是调用者的位置，ErrorTrackerSink 用它定位调用栈的起点。
+	event     bool    // event 为 true 时这是一条 TraceEvent 输出的事件，文本格式总是转义输出。
+	noConsole bool    // noConsole 为 true 时这条日志不回显到终端。
+}
+
+// Encoder 将一条日志编码成一行文本写入 buf，编码结果不包含结尾的换行符。
+// FormatBinary 等自带长度前缀的格式除外，这些格式不会追加换行符。
+type Encoder interface {
+	Encode(buf *bytes.Buffer, entry *Entry)
+}
+
+// selfDelimited 判断 encoder 编码的每条日志是否自带长度前缀，例如 FormatBinary，
+// 这样的日志写入普通文件时不需要追加换行符。
+func selfDelimited(encoder Encoder) bool {
+	_, ok := encoder.(binaryEncoder)
+	return ok
+}
+
+// TimeFormatEpochMillis 是 Config.TimeFormat 的特殊值，表示用毫秒时间戳输出时间，FormatJSON 中输出成数字。
+const TimeFormatEpochMillis = "epoch_millis"
+
+// timeLayout 是编码时间的格式，为空时使用默认格式。
+type timeLayout string
+
+func (layout timeLayout) format(t time.Time) string {
+	return string(layout.append(nil, t))
+}
+
+// write 把 t 写入 buf，结果和 format 一样，但是不会分配内存。
+func (layout timeLayout) write(buf *bytes.Buffer, t time.Time) {
+	var scratch [64]byte
+	buf.Write(layout.append(scratch[:0], t))
+}
+
+func (layout timeLayout) append(dst []byte, t time.Time) []byte {
+	switch layout {
+	case "":
+		return t.AppendFormat(dst, logTimeFormat)
+	case TimeFormatEpochMillis:
+		return strconv.AppendInt(dst, t.UnixNano()/int64(time.Millisecond), 10)
+	default:
+		return t.AppendFormat(dst, string(layout))
+	}
+}
+
+// configureEncoder 根据配置调整内置 Encoder 的选项，其他 Encoder 原样返回。
+func configureEncoder(encoder Encoder, config *Config) Encoder {
+	layout := timeLayout(config.TimeFormat)
+	names := newLevelNames(config.LevelNames)
+
+	switch e := encoder.(type) {
+	case textEncoder:
+		e.escape = config.Escape
+		e.quote = config.QuoteValues
+		e.layout = layout
+		e.names = names
+		return e
+	case jsonEncoder:
+		e.layout = layout
+		e.names = names
+		return e
+	case logfmtEncoder:
+		e.layout = layout
+		e.names = names
+		return e
+	case tsvEncoder:
+		e.layout = layout
+		e.names = names
+		return e
+	default:
+		return encoder
+	}
+}
+
+// NewEncoder 返回 format 对应的 Encoder，未知的格式返回 FormatText 的 Encoder。
+func NewEncoder(format string) Encoder {
+	switch format {
+	case FormatJSON:
+		return jsonEncoder{}
+	case FormatLogfmt:
+		return logfmtEncoder{}
+	case FormatTSV:
+		return tsvEncoder{}
+	case FormatBinary:
+		return binaryEncoder{}
+	default:
+		return textEncoder{}
+	}
+}
+
+// textEncoder 输出默认的文本格式：
+//
+//	[INFO][2019-07-03T12:34:56.789Z08:00][file.go:12@pkg.Func] *||key1=value1||this is custom log text
+//
+// 如果 escape 为 true，tag、字段和消息中的 "\"、"|" 和换行符会被转义，字段的 key 和 value 中的 "=" 也会被转义，
+// 保证每一行都能被无歧义的解析回来，详见 writeEscaped。
+// 如果 quote 为 true 且没有开启 escape，包含空格、"="、"|"、引号或控制字符的字段值会写成 JSON 字符串，详见 writeQuotedValue。
+type textEncoder struct {
+	escape bool
+	quote  bool
+	layout timeLayout
+	names  levelNames
+}
+
+func (e textEncoder) Encode(buf *bytes.Buffer, entry *Entry) {
+	if entry.Level == logPrint {
+		buf.WriteString(entry.Message)
+		return
+	}
+
+	if e.escape || entry.event {
+		e.encodeEscaped(buf, entry)
+		return
+	}
+
+	e.encodeHeader(buf, entry)
+	tag := entry.Tag
+
+	if tag == "" {
+		tag = "*"
+	}
+
+	buf.WriteString(tag)
+	buf.Write(logSeparator)
+
+	for _, info := range entry.Fields {
+		buf.WriteString(info.Key)
+		buf.WriteByte('=')
+
+		if e.quote {
+			writeQuotedValue(buf, info.Value)
+		} else {
+			writeValue(buf, info.Value)
+		}
+
+		buf.Write(logSeparator)
+	}
+
+	buf.WriteString(entry.Message)
+}
+
+func (e textEncoder) encodeHeader(buf *bytes.Buffer, entry *Entry) {
+	buf.WriteByte('[')
+	buf.WriteString(e.names.name(entry.Level))
+	buf.WriteByte(']')
+
+	buf.WriteByte('[')
+	e.layout.write(buf, entry.Time)
+	buf.WriteByte(']')
+
+	if entry.Caller != "" {
+		buf.WriteByte('[')
+		buf.WriteString(entry.Caller)
+		buf.WriteByte(']')
+	}
+
+	buf.WriteByte(' ')
+}
+
+func (e textEncoder) encodeEscaped(buf *bytes.Buffer, entry *Entry) {
+	e.encodeHeader(buf, entry)
+
+	if entry.Tag == "" {
+		buf.WriteByte('*')
+	} else {
+		writeEscaped(buf, entry.Tag, false)
+	}
+
+	buf.Write(logSeparator)
+
+	for _, info := range entry.Fields {
+		writeEscaped(buf, info.Key, true)
+		buf.WriteByte('=')
+		writeEscaped(buf, valueString(info.Value), true)
+		buf.Write(logSeparator)
+	}
+
+	writeEscaped(buf, entry.Message, false)
+}
+
+// writeQuotedValue 将字段值写入 buf，如果值为空或者包含空格、"="、"|"、引号或控制字符，就写成带引号的 JSON 字符串，
+// 让 Splunk 等按照空格和 "=" 提取字段的工具也能正确解析。
+func writeQuotedValue(buf *bytes.Buffer, value interface{}) {
+	switch value.(type) {
+	case int, int64, bool:
+		writeValue(buf, value)
+		return
+	}
+
+	s := valueString(value)
+
+	if s == "" {
+		buf.WriteString(`""`)
+		return
+	}
+
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; c <= ' ' || c == '=' || c == '|' || c == '"' || c == 0x7f {
+			writeJSONString(buf, s)
+			return
+		}
+	}
+
+	buf.WriteString(s)
+}
+
+// writeEscaped 转义 s 之后写入 buf："\" 转义成 "\\"，"|" 转义成 "\|"，换行符和回车符转义成 "\n" 和 "\r"，
+// 如果 escapeEqual 为 true，"=" 转义成 "\="。
+func writeEscaped(buf *bytes.Buffer, s string, escapeEqual bool) {
+	start := 0
+
+	for i := 0; i < len(s); i++ {
+		var escaped byte
+
+		switch c := s[i]; c {
+		case '\\', '|':
+			escaped = c
+		case '=':
+			if !escapeEqual {
+				continue
+			}
+
+			escaped = c
+		case '\n':
+			escaped = 'n'
+		case '\r':
+			escaped = 'r'
+		default:
+			continue
+		}
+
+		buf.WriteString(s[start:i])
+		buf.WriteByte('\\')
+		buf.WriteByte(escaped)
+		start = i + 1
+	}
+
+	buf.WriteString(s[start:])
+}
+
+// jsonEncoder 将日志输出成一行 JSON，ctx 中的各种信息会作为 JSON 的字段输出：
+//
+//	{"level":"INFO","time":"2019-07-03T12:34:56.789+08:00","caller":"file.go:12@pkg.Func","key1":"value1","msg":"this is custom log text"}
+type jsonEncoder struct {
+	layout timeLayout
+	names  levelNames
+}
+
+func (e jsonEncoder) Encode(buf *bytes.Buffer, entry *Entry) {
+	buf.WriteByte('{')
+
+	if entry.Level != logPrint {
+		writeJSONKey(buf, "level")
+		writeJSONString(buf, e.names.name(entry.Level))
+		buf.WriteByte(',')
+	}
+
+	writeJSONKey(buf, "time")
+
+	// 毫秒时间戳直接输出成数字，默认格式不包含需要转义的字符。
+	if e.layout == TimeFormatEpochMillis {
+		e.layout.write(buf, entry.Time)
+	} else if e.layout == "" {
+		buf.WriteByte('"')
+		e.layout.write(buf, entry.Time)
+		buf.WriteByte('"')
+	} else {
+		writeJSONString(buf, e.layout.format(entry.Time))
+	}
+
+	buf.WriteByte(',')
+
+	if entry.Caller != "" {
+		writeJSONKey(buf, "caller")
+		writeJSONString(buf, entry.Caller)
+		buf.WriteByte(',')
+	}
+
+	if entry.Tag != "" {
+		writeJSONKey(buf, "tag")
+		writeJSONString(buf, entry.Tag)
+		buf.WriteByte(',')
+	}
+
+	for _, info := range entry.Fields {
+		writeJSONKey(buf, info.Key)
+		writeJSONValue(buf, info.Value)
+		buf.WriteByte(',')
+	}
+
+	writeJSONKey(buf, "msg")
+	writeJSONString(buf, entry.Message)
+	buf.WriteByte('}')
+}
+
+func writeJSONKey(buf *bytes.Buffer, key string) {
+	writeJSONString(buf, key)
+	buf.WriteByte(':')
+}
+
+const hexDigits = "0123456789abcdef"
+
+func writeJSONString(buf *bytes.Buffer, s string) {
+	buf.WriteByte('"')
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+
+		switch {
+		case c == '"' || c == '\\':
+			buf.WriteByte('\\')
+			buf.WriteByte(c)
+		case c == '\n':
+			buf.WriteString(`\n`)
+		case c == '\r':
+			buf.WriteString(`\r`)
+		case c == '\t':
+			buf.WriteString(`\t`)
+		case c < 0x20:
+			buf.WriteString(`\u00`)
+			buf.WriteByte(hexDigits[c>>4])
+			buf.WriteByte(hexDigits[c&0xf])
+		default:
+			buf.WriteByte(c)
+		}
+	}
+
+	buf.WriteByte('"')
+}
+
+func writeJSONValue(buf *bytes.Buffer, value interface{}) {
+	switch v := value.(type) {
+	case string:
+		writeJSONString(buf, v)
+		return
+	case stableNumber:
+		buf.WriteString(string(v))
+		return
+	case byteSize:
+		buf.WriteString(strconv.FormatInt(int64(v), 10))
+		return
+	case durationValue:
+		buf.WriteString(strconv.FormatInt(int64(v), 10))
+		return
+	case error:
+		writeJSONString(buf, safeString(v, "Error", v.Error))
+		return
+	case fmt.Stringer:
+		writeJSONString(buf, safeString(v, "String", v.String))
+		return
+	}
+
+	if writeJSONNumber(buf, value) {
+		return
+	}
+
+	data, err := marshalJSON(value)
+
+	if err != nil {
+		writeJSONString(buf, fmt.Sprint(value))
+		return
+	}
+
+	buf.Write(data)
+}
+
+// marshalJSON 调用 json.Marshal，如果 value 的 MarshalJSON 发生 panic 就返回占位符。
+func marshalJSON(value interface{}) (data []byte, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			data, err = json.Marshal(panicPlaceholder(value, "MarshalJSON", r))
+		}
+	}()
+
+	return json.Marshal(value)
+}
+
+// logfmtEncoder 将日志输出成 logfmt 格式，ctx 中的各种信息会放在最后：
+//
+//	time=2019-07-03T12:34:56.789+08:00 level=info caller=file.go:12@pkg.Func msg="this is custom log text" key1=value1
+type logfmtEncoder struct {
+	layout timeLayout
+	names  levelNames
+}
+
+func (e logfmtEncoder) Encode(buf *bytes.Buffer, entry *Entry) {
+	buf.WriteString("time=")
+
+	// 默认格式和毫秒时间戳不包含需要转义的字符。
+	if e.layout == "" || e.layout == TimeFormatEpochMillis {
+		e.layout.write(buf, entry.Time)
+	} else {
+		writeLogfmtValue(buf, e.layout.format(entry.Time))
+	}
+
+	if entry.Level != logPrint {
+		buf.WriteString(" level=")
+
+		if name, ok := e.names[entry.Level]; ok {
+			writeLogfmtValue(buf, name)
+		} else {
+			for _, c := range []byte(entry.Level.String()) {
+				if c >= 'A' && c <= 'Z' {
+					c += 'a' - 'A'
+				}
+
+				buf.WriteByte(c)
+			}
+		}
+	}
+
+	if entry.Caller != "" {
+		buf.WriteString(" caller=")
+		writeLogfmtValue(buf, entry.Caller)
+	}
+
+	if entry.Tag != "" {
+		buf.WriteString(" tag=")
+		writeLogfmtValue(buf, entry.Tag)
+	}
+
+	buf.WriteString(" msg=")
+	writeLogfmtValue(buf, entry.Message)
+
+	for _, info := range entry.Fields {
+		buf.WriteByte(' ')
+		buf.WriteString(info.Key)
+		buf.WriteByte('=')
+		writeLogfmtField(buf, info.Value)
+	}
+}
+
+// writeLogfmtField 将字段值写成 logfmt 格式，结果和 valueString 一样，常见类型不会分配内存。
+func writeLogfmtField(buf *bytes.Buffer, value interface{}) {
+	var scratch [32]byte
+
+	switch v := value.(type) {
+	case string:
+		writeLogfmtValue(buf, v)
+	case int:
+		buf.Write(strconv.AppendInt(scratch[:0], int64(v), 10))
+	case int64:
+		buf.Write(strconv.AppendInt(scratch[:0], v, 10))
+	case bool:
+		buf.Write(strconv.AppendBool(scratch[:0], v))
+	default:
+		writeLogfmtValue(buf, valueString(value))
+	}
+}
+
+// writeLogfmtValue 输出 logfmt 的值，如果值中包含空格、等号、引号或控制字符，就用引号括起来。
+func writeLogfmtValue(buf *bytes.Buffer, value string) {
+	if value == "" {
+		buf.WriteString(`""`)
+		return
+	}
+
+	for i := 0; i < len(value); i++ {
+		if c := value[i]; c <= ' ' || c == '=' || c == '"' || c == 0x7f {
+			writeJSONString(buf, value)
+			return
+		}
+	}
+
+	buf.WriteString(value)
+}
+
+// tsvEncoder 将日志输出成固定列的 TSV 格式，列的顺序是：
+//
+//	time	level	caller	tag	fields	message
+//
+// 其中 fields 是 ctx 中的各种信息编码成的 JSON 对象，没有信息时为 "{}"。
+// 每列中的反斜杠、制表符和换行符会被转义成 "\\"、"\t" 和 "\n"。
+type tsvEncoder struct {
+	layout timeLayout
+	names  levelNames
+}
+
+func (e tsvEncoder) Encode(buf *bytes.Buffer, entry *Entry) {
+	level := ""
+
+	if entry.Level != logPrint {
+		level = e.names.name(entry.Level)
+	}
+
+	writeTSVColumn(buf, e.layout.format(entry.Time))
+	buf.WriteByte('\t')
+	writeTSVColumn(buf, level)
+	buf.WriteByte('\t')
+	writeTSVColumn(buf, entry.Caller)
+	buf.WriteByte('\t')
+	writeTSVColumn(buf, entry.Tag)
+	buf.WriteByte('\t')
+
+	fields := &bytes.Buffer{}
+	fields.WriteByte('{')
+
+	for i, info := range entry.Fields {
+		if i != 0 {
+			fields.WriteByte(',')
+		}
+
+		writeJSONKey(fields, info.Key)
+		writeJSONValue(fields, info.Value)
+	}
+
+	fields.WriteByte('}')
+	writeTSVColumn(buf, fields.String())
+	buf.WriteByte('\t')
+	writeTSVColumn(buf, entry.Message)
+}
+
+func writeTSVColumn(buf *bytes.Buffer, value string) {
+	for i := 0; i < len(value); i++ {
+		switch c := value[i]; c {
+		case '\\':
+			buf.WriteString(`\\`)
+		case '\t':
+			buf.WriteString(`\t`)
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\r':
+			buf.WriteString(`\r`)
+		default:
+			buf.WriteByte(c)
+		}
+	}
+}
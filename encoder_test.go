@@ -0,0 +1,198 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJSONEncoder(t *testing.T) {
+	now, _ := time.Parse(logTimeFormat, "2019-07-03T12:34:56.789+08:00")
+	cases := []struct {
+		entry    Entry
+		expected string
+	}{
+		{
+			entry: Entry{
+				Level:   LogInfo,
+				Time:    now,
+				Caller:  "a.go:12@pkg.Func",
+				Tag:     "tag",
+				Fields:  []Info{{Key: "key1", Value: 123}, {Key: "key2", Value: "v\"2"}, {Key: "key3", Value: []int{1, 2}}},
+				Message: "line1\nline2",
+			},
+			expected: `{"level":"INFO","time":"2019-07-03T12:34:56.789+08:00","caller":"a.go:12@pkg.Func","tag":"tag","key1":123,"key2":"v\"2","key3":[1,2],"msg":"line1\nline2"}`,
+		},
+		{
+			entry: Entry{
+				Level:   logPrint,
+				Time:    now,
+				Message: "print\x01",
+			},
+			expected: `{"time":"2019-07-03T12:34:56.789+08:00","msg":"print\u0001"}`,
+		},
+	}
+
+	for i, c := range cases {
+		buf := &bytes.Buffer{}
+		jsonEncoder{}.Encode(buf, &c.entry)
+
+		if actual := buf.String(); actual != c.expected {
+			t.Fatalf("case %v: invalid json.\n  expected:\n%v\n  actual:\n%v", i, c.expected, actual)
+		}
+	}
+}
+
+func TestLogfmtEncoder(t *testing.T) {
+	now, _ := time.Parse(logTimeFormat, "2019-07-03T12:34:56.789+08:00")
+	entry := &Entry{
+		Level:   LogWarn,
+		Time:    now,
+		Caller:  "a.go:12@pkg.Func",
+		Fields:  []Info{{Key: "key1", Value: 123}, {Key: "key2", Value: "a=b"}, {Key: "key3", Value: ""}},
+		Message: "hello world",
+	}
+	expected := `time=2019-07-03T12:34:56.789+08:00 level=warn caller=a.go:12@pkg.Func msg="hello world" key1=123 key2="a=b" key3=""`
+	buf := &bytes.Buffer{}
+	logfmtEncoder{}.Encode(buf, entry)
+
+	if actual := buf.String(); actual != expected {
+		t.Fatalf("invalid logfmt.\n  expected:\n%v\n  actual:\n%v", expected, actual)
+	}
+}
+
+func TestTSVEncoder(t *testing.T) {
+	now, _ := time.Parse(logTimeFormat, "2019-07-03T12:34:56.789+08:00")
+	entry := &Entry{
+		Level:   LogTrace,
+		Time:    now,
+		Caller:  "a.go:12@pkg.Func",
+		Tag:     "tag",
+		Fields:  []Info{{Key: "key1", Value: 123}, {Key: "key2", Value: "a\tb"}},
+		Message: "line1\nline2\\",
+	}
+	expected := "2019-07-03T12:34:56.789+08:00\tTRACE\ta.go:12@pkg.Func\ttag\t" + `{"key1":123,"key2":"a\\tb"}` + "\t" + `line1\nline2\\`
+	buf := &bytes.Buffer{}
+	tsvEncoder{}.Encode(buf, entry)
+
+	if actual := buf.String(); actual != expected {
+		t.Fatalf("invalid tsv.\n  expected:\n%v\n  actual:\n%v", expected, actual)
+	}
+}
+
+func TestTextEncoderTypedFields(t *testing.T) {
+	now, _ := time.Parse(logTimeFormat, "2019-07-03T12:34:56.789+08:00")
+	entry := &Entry{
+		Level:   LogInfo,
+		Time:    now,
+		Fields:  []Info{String("s", "v"), Int("i", -1), Uint64("u", 2), Float64("f", 1.5), Bool("b", true), Any("a", []int{1})},
+		Message: "msg",
+	}
+	expected := `[INFO][2019-07-03T12:34:56.789+08:00] *||s=v||i=-1||u=2||f=1.5||b=true||a=[1]||msg`
+	buf := &bytes.Buffer{}
+	textEncoder{}.Encode(buf, entry)
+
+	if actual := buf.String(); actual != expected {
+		t.Fatalf("invalid text.\n  expected:\n%v\n  actual:\n%v", expected, actual)
+	}
+}
+
+func TestTextEncoderEscape(t *testing.T) {
+	now, _ := time.Parse(logTimeFormat, "2019-07-03T12:34:56.789+08:00")
+	entry := &Entry{
+		Level:   LogInfo,
+		Time:    now,
+		Caller:  "a.go:12@pkg.Func",
+		Tag:     "t|g",
+		Fields:  []Info{String("k=1", "a||b=c"), String("path", `C:\tmp`)},
+		Message: "x=1||line1\nline2",
+	}
+	expected := `[INFO][2019-07-03T12:34:56.789+08:00][a.go:12@pkg.Func] t\|g||k\=1=a\|\|b\=c||path=C:\\tmp||x=1\|\|line1\nline2`
+	buf := &bytes.Buffer{}
+	textEncoder{escape: true}.Encode(buf, entry)
+
+	if actual := buf.String(); actual != expected {
+		t.Fatalf("invalid text.\n  expected:\n%v\n  actual:\n%v", expected, actual)
+	}
+}
+
+func TestTextEncoderQuoteValues(t *testing.T) {
+	now, _ := time.Parse(logTimeFormat, "2019-07-03T12:34:56.789+08:00")
+	entry := &Entry{
+		Level:   LogInfo,
+		Time:    now,
+		Fields:  []Info{String("s", "v"), String("space", "a b"), String("eq", "a=b"), String("sep", "a||b"), String("empty", ""), Int("i", 1), Any("a", []string{"x"})},
+		Message: "msg with space",
+	}
+	expected := `[INFO][2019-07-03T12:34:56.789+08:00] *||s=v||space="a b"||eq="a=b"||sep="a||b"||empty=""||i=1||a="[\"x\"]"||msg with space`
+	buf := &bytes.Buffer{}
+	textEncoder{quote: true}.Encode(buf, entry)
+
+	if actual := buf.String(); actual != expected {
+		t.Fatalf("invalid text.\n  expected:\n%v\n  actual:\n%v", expected, actual)
+	}
+}
+
+func TestLevelNames(t *testing.T) {
+	names := map[string]string{"warn": "WARNING", "error": "错误"}
+	cases := []struct {
+		format   string
+		expected []string
+	}{
+		{FormatText, []string{"[WARNING]", "[错误]", "[INFO]"}},
+		{FormatJSON, []string{`"level":"WARNING"`, `"level":"错误"`, `"level":"INFO"`}},
+		{FormatLogfmt, []string{" level=WARNING ", " level=错误 ", " level=info "}},
+		{FormatTSV, []string{"\tWARNING\t", "\t错误\t", "\tINFO\t"}},
+	}
+
+	for _, c := range cases {
+		l, lines := newTestLogger(&Config{Format: c.format, LevelNames: names})
+		ctx := context.Background()
+		l.Warnf(ctx, "warn")
+		l.Errorf(ctx, "error")
+		l.Infof(ctx, "info")
+
+		for i, expected := range c.expected {
+			if !strings.Contains((*lines)[i], expected) {
+				t.Fatalf("invalid level name. [format:%v] [expected:%v] [line:%v]", c.format, expected, (*lines)[i])
+			}
+		}
+	}
+
+	if err := validateConfig(&Config{LevelNames: map[string]string{"unknown": "U"}}); err == nil {
+		t.Fatalf("unknown level must be rejected.")
+	}
+}
+
+func TestTimeFormat(t *testing.T) {
+	now, _ := time.Parse(logTimeFormat, "2019-07-03T12:34:56.789+08:00")
+	l, lines := newTestLogger(&Config{
+		Format:     FormatJSON,
+		TimeFormat: TimeFormatEpochMillis,
+		Clock: func() time.Time {
+			return now
+		},
+	})
+	l.Infof(context.Background(), "millis")
+	l.Flush()
+
+	if expected := `{"level":"INFO","time":1562128496789,`; len(*lines) != 1 || !strings.HasPrefix((*lines)[0], expected) {
+		t.Fatalf("invalid epoch millis. [lines:%v]", *lines)
+	}
+
+	l, lines = newTestLogger(&Config{
+		TimeFormat: "2006-01-02 15:04:05.000",
+		UTC:        true,
+		Clock: func() time.Time {
+			return now
+		},
+	})
+	l.Infof(context.Background(), "utc")
+	l.Flush()
+
+	if expected := "[INFO][2019-07-03 04:34:56.789]"; len(*lines) != 1 || !strings.HasPrefix((*lines)[0], expected) {
+		t.Fatalf("invalid time format. [lines:%v]", *lines)
+	}
+}
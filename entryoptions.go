@@ -0,0 +1,54 @@
+package log
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// Options 是单条日志的选项，用于在不修改 ctx 的情况下调整一条日志的输出方式。
+type Options struct {
+	Tag       string // Tag 不为空时覆盖 ctx 中的 tag，tag 相关的日志级别、过滤规则和告警规则都会使用这个 tag。
+	NoCaller  bool   // NoCaller 让这条日志不输出调用者信息。
+	NoConsole bool   // NoConsole 让这条日志不回显到终端。
+	Skip      int    // Skip 是调用者信息需要额外跳过的调用栈层数，效果和 WithCallerSkip 相同。
+}
+
+// Logf 使用 opts 输出一条 level 级别的日志，例如：
+//
+//	log.Logf(ctx, log.LogWarn, log.Options{Tag: "slowquery"}, "slow query: %v", query)
+func Logf(ctx context.Context, level Level, opts Options, format string, args ...interface{}) {
+	defaultLogger().logOptions(ctx, &opts, level, format, args...)
+}
+
+func (l *logger) logOptions(ctx context.Context, opts *Options, level Level, format string, args ...interface{}) {
+	if level <= logPrint || !level.within(LogDebug) {
+		return
+	}
+
+	if l.disabled(ctx, level) {
+		return
+	}
+
+	if !level.within(l.degradeLevel) && atomic.LoadInt32(&l.degraded) != 0 {
+		return
+	}
+
+	if opts.Tag != "" {
+		ctx = WithTag(ctx, opts.Tag)
+	}
+
+	pc := callerPC(loggerSkipLevel + opts.Skip)
+
+	if !l.enabled(ctx, pc, level) {
+		return
+	}
+
+	entry := l.newEntry(ctx, pc, level, format, args...)
+	entry.noConsole = opts.NoConsole
+
+	if opts.NoCaller {
+		entry.Caller = ""
+	}
+
+	l.emit(ctx, pc, entry, args)
+}
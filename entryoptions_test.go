@@ -0,0 +1,33 @@
+package log
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestLogf(t *testing.T) {
+	l, lines := newTestLogger(&Config{
+		TagLevels: map[string]string{"noisy": "error"},
+	})
+	setDefaultLogger(l)
+	defer Init(nil)
+
+	ctx := WithTag(context.Background(), "origin")
+	Logf(ctx, LogWarn, Options{Tag: "slowquery"}, "slow %v", 1)
+	Logf(ctx, LogWarn, Options{Tag: "noisy"}, "dropped")
+	Logf(ctx, LogInfo, Options{NoCaller: true, NoConsole: true}, "no caller")
+	l.Flush()
+
+	if len(*lines) != 2 {
+		t.Fatalf("there should be 2 lines. [lines:%v]", *lines)
+	}
+
+	if line := (*lines)[0]; !strings.Contains(line, "[entryoptions_test.go:17@") || !strings.HasSuffix(line, "] slowquery||slow 1\n") {
+		t.Fatalf("invalid tagged line. [line:%v]", line)
+	}
+
+	if line := (*lines)[1]; !strings.HasPrefix(line, "[INFO][") || strings.Contains(line, "@") || !strings.HasSuffix(line, "] origin||no caller\n") {
+		t.Fatalf("invalid line without caller. [line:%v]", line)
+	}
+}
@@ -0,0 +1,123 @@
+package log
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EnvPrefix 是日志配置环境变量的前缀。
+//
+// 环境变量名由 Config 字段的 config tag 转成大写并加上前缀得到，tag 本身以 "log_" 开头时不重复添加，
+// 例如 LOG_LEVEL、LOG_PATH、LOG_FORMAT、LOG_ERROR_LOG_PATH、LOG_BUFFERED_LINES。
+// map 类型的配置用逗号分隔多个 key=value，例如 LOG_MODULE_LEVELS="app/dao=debug,rpc=warn"。
+const EnvPrefix = "LOG_"
+
+// applyEnv 用环境变量覆盖 config 中对应的配置，返回一份新的配置，不修改 config 本身。
+func applyEnv(config *Config) (*Config, error) {
+	if config == nil {
+		return nil, nil
+	}
+
+	c := *config
+	v := reflect.ValueOf(&c).Elem()
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		tag := t.Field(i).Tag.Get("config")
+
+		if tag == "" || tag == "-" {
+			continue
+		}
+
+		name := envName(tag)
+		value, ok := os.LookupEnv(name)
+
+		if !ok {
+			continue
+		}
+
+		if err := setEnvValue(v.Field(i), value); err != nil {
+			return nil, fmt.Errorf("go-log: invalid env %v=%q: %v", name, value, err)
+		}
+	}
+
+	return &c, nil
+}
+
+func envName(tag string) string {
+	tag = strings.ToUpper(tag)
+
+	if strings.HasPrefix(tag, EnvPrefix) {
+		return tag
+	}
+
+	return EnvPrefix + tag
+}
+
+func setEnvValue(field reflect.Value, value string) error {
+	switch field.Interface().(type) {
+	case string:
+		field.SetString(value)
+	case bool:
+		b, err := strconv.ParseBool(value)
+
+		if err != nil {
+			return err
+		}
+
+		field.SetBool(b)
+	case int, int64:
+		n, err := strconv.ParseInt(value, 10, 64)
+
+		if err != nil {
+			return err
+		}
+
+		field.SetInt(n)
+	case os.FileMode:
+		// 文件权限习惯用八进制表示，例如 0600。
+		n, err := strconv.ParseUint(value, 8, 32)
+
+		if err != nil {
+			return err
+		}
+
+		field.SetUint(n)
+	case time.Duration:
+		d, err := time.ParseDuration(value)
+
+		if err != nil {
+			return err
+		}
+
+		field.SetInt(int64(d))
+	case map[string]string:
+		m := map[string]string{}
+
+		for _, kv := range strings.Split(value, ",") {
+			kv = strings.TrimSpace(kv)
+
+			if kv == "" {
+				continue
+			}
+
+			idx := strings.IndexByte(kv, '=')
+
+			if idx <= 0 {
+				return fmt.Errorf("expect key=value but got %q", kv)
+			}
+
+			m[strings.TrimSpace(kv[:idx])] = strings.TrimSpace(kv[idx+1:])
+		}
+
+		field.Set(reflect.ValueOf(m))
+	default:
+		return fmt.Errorf("unsupported config type %v", field.Type())
+	}
+
+	return nil
+}
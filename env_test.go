@@ -0,0 +1,55 @@
+package log
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestApplyEnv(t *testing.T) {
+	env := map[string]string{
+		"LOG_LEVEL":          "debug",
+		"LOG_PATH":           "/tmp/go-log-env/all.log",
+		"LOG_FORMAT":         "json",
+		"LOG_BUFFERED_LINES": "100",
+		"LOG_FLUSH_INTERVAL": "2s",
+		"LOG_MODULE_LEVELS":  "app/dao=warn, rpc=error",
+		"LOG_FILE_MODE":      "0600",
+	}
+
+	for k, v := range env {
+		os.Setenv(k, v)
+		defer os.Unsetenv(k)
+	}
+
+	config := &Config{
+		LogLevel:     "info",
+		ErrorLogPath: "/tmp/go-log-env/error.log",
+	}
+	c, err := applyEnv(config)
+
+	if err != nil {
+		t.Fatalf("fail to apply env. [err:%v]", err)
+	}
+
+	if config.LogLevel != "info" {
+		t.Fatalf("original config should not be modified.")
+	}
+
+	if c.LogLevel != "debug" || c.LogPath != env["LOG_PATH"] || c.Format != FormatJSON ||
+		c.ErrorLogPath != config.ErrorLogPath || c.BufferedLines != 100 || c.FlushInterval != 2*time.Second || c.FileMode != 0600 {
+		t.Fatalf("invalid config. [config:%+v]", c)
+	}
+
+	if len(c.ModuleLevels) != 2 || c.ModuleLevels["app/dao"] != "warn" || c.ModuleLevels["rpc"] != "error" {
+		t.Fatalf("invalid module levels. [levels:%v]", c.ModuleLevels)
+	}
+
+	os.Setenv("LOG_SHARDS", "many")
+
+	if _, err := applyEnv(config); err == nil {
+		t.Fatalf("invalid env should be rejected.")
+	}
+
+	os.Unsetenv("LOG_SHARDS")
+}
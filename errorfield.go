@@ -0,0 +1,91 @@
+package log
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// 错误信息中各个字段的 key。
+const (
+	ErrorKey      = "error"       // ErrorKey 是错误信息的 key。
+	ErrorTypeKey  = "error_type"  // ErrorTypeKey 是错误类型的 key。
+	ErrorChainKey = "error_chain" // ErrorChainKey 是错误链的 key，仅在错误包装了其他错误时输出。
+	ErrorStackKey = "error_stack" // ErrorStackKey 是错误调用栈的 key，仅在错误自带调用栈时输出。
+)
+
+const maxErrorChainDepth = 16
+
+// ErrorFields 将 err 转换成一组标准的 Info，包括错误信息、错误类型、错误链和调用栈。
+// 错误信息的值就是 err 本身，方便 ErrorClassifier 识别。
+// 错误链通过 Unwrap() 方法获取，调用栈来自于 github.com/pkg/errors 等库提供的 StackTrace() 方法。
+// 如果 err 为 nil，返回 nil。
+func ErrorFields(err error) []Info {
+	if err == nil {
+		return nil
+	}
+
+	fields := []Info{
+		{Key: ErrorKey, Value: err},
+		{Key: ErrorTypeKey, Value: fmt.Sprintf("%T", err)},
+	}
+
+	if chain := errorChain(err); len(chain) > 1 {
+		fields = append(fields, Info{Key: ErrorChainKey, Value: strings.Join(chain, " <- ")})
+	}
+
+	if stack := errorStack(err); stack != "" {
+		fields = append(fields, Info{Key: ErrorStackKey, Value: stack})
+	}
+
+	return fields
+}
+
+// WithError 将 err 的信息保存在 ctx 中，输出日志时会自动带上这些信息。
+func WithError(ctx context.Context, err error) context.Context {
+	return WithMoreInfo(ctx, ErrorFields(err)...)
+}
+
+type unwrapper interface {
+	Unwrap() error
+}
+
+// errorChain 返回 err 及其包装的所有错误的类型。
+func errorChain(err error) []string {
+	var chain []string
+
+	for i := 0; err != nil && i < maxErrorChainDepth; i++ {
+		chain = append(chain, fmt.Sprintf("%T", err))
+		u, ok := err.(unwrapper)
+
+		if !ok {
+			break
+		}
+
+		err = u.Unwrap()
+	}
+
+	return chain
+}
+
+// errorStack 在错误链中查找第一个提供 StackTrace() 方法的错误，返回它的调用栈。
+func errorStack(err error) string {
+	for i := 0; err != nil && i < maxErrorChainDepth; i++ {
+		m := reflect.ValueOf(err).MethodByName("StackTrace")
+
+		if m.IsValid() && m.Type().NumIn() == 0 && m.Type().NumOut() == 1 {
+			return fmt.Sprintf("%v", m.Call(nil)[0].Interface())
+		}
+
+		u, ok := err.(unwrapper)
+
+		if !ok {
+			break
+		}
+
+		err = u.Unwrap()
+	}
+
+	return ""
+}
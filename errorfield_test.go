@@ -0,0 +1,37 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"testing"
+)
+
+type stackError struct {
+	error
+}
+
+func (stackError) StackTrace() []string {
+	return []string{"a.go:1", "b.go:2"}
+}
+
+func TestErrorFields(t *testing.T) {
+	if fields := ErrorFields(nil); fields != nil {
+		t.Fatalf("nil error should have no fields. [fields:%v]", fields)
+	}
+
+	inner := &os.PathError{Op: "open", Path: "/x", Err: errors.New("denied")}
+	err := fmt.Errorf("load config: %w", stackError{inner})
+	fields := ErrorFields(err)
+	expected := []Info{
+		{Key: ErrorKey, Value: err},
+		{Key: ErrorTypeKey, Value: "*fmt.wrapError"},
+		{Key: ErrorChainKey, Value: "*fmt.wrapError <- log.stackError"},
+		{Key: ErrorStackKey, Value: "[a.go:1 b.go:2]"},
+	}
+
+	if !reflect.DeepEqual(expected, fields) {
+		t.Fatalf("invalid error fields.\n  expected:\n%v\n  actual:\n%v", expected, fields)
+	}
+}
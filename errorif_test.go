@@ -0,0 +1,40 @@
+package log
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestErrorIf(t *testing.T) {
+	l, lines := newTestLogger(&Config{})
+	setDefaultLogger(l)
+	defer Init(nil)
+
+	ctx := context.Background()
+	err := errors.New("timeout")
+
+	if e := ErrorIf(ctx, nil, "nothing"); e != nil {
+		t.Fatalf("nil error must be returned. [err:%v]", e)
+	}
+
+	if e := ErrorIf(ctx, err, "fail to save"); e != err {
+		t.Fatalf("err must be returned. [err:%v]", e)
+	}
+
+	WarnIf(ctx, err, "fail to cache")
+	l.Flush()
+
+	if len(*lines) != 2 {
+		t.Fatalf("there should be 2 lines. [lines:%v]", *lines)
+	}
+
+	if line := (*lines)[0]; !strings.HasPrefix(line, "[ERROR]") || !strings.Contains(line, "errorif_test.go:22@") || !strings.HasSuffix(line, "fail to save: timeout\n") {
+		t.Fatalf("invalid error line. [line:%v]", line)
+	}
+
+	if line := (*lines)[1]; !strings.HasPrefix(line, "[WARN]") || !strings.HasSuffix(line, "fail to cache: timeout\n") {
+		t.Fatalf("invalid warn line. [line:%v]", line)
+	}
+}
@@ -0,0 +1,239 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultErrorTrackerRate 是 ErrorTrackerSink 默认每秒最多上报的错误数。
+const DefaultErrorTrackerRate = 10
+
+// logPackage 是这个库的 package 路径，用于在调用栈中跳过日志库内部的调用。
+var logPackage = reflect.TypeOf(logger{}).PkgPath()
+
+// ErrorEvent 是上报给 ErrorTracker 的一条错误日志。
+type ErrorEvent struct {
+	Entry
+
+	// Stack 是输出日志时的调用栈，从调用日志函数的位置开始，
+	// 格式为 "file.go:12@pkg.Func <- file.go:34@pkg.Caller"。
+	Stack string
+}
+
+// ErrorTracker 代表 Sentry 之类的错误追踪服务。
+// 如果实现了 Flush() error 方法，刷新日志和 Fatalf 终止程序之前会调用。
+type ErrorTracker interface {
+	Capture(event *ErrorEvent) error
+}
+
+// ErrorTrackerFunc 将一个函数转换成 ErrorTracker，例如对接 Sentry：
+//
+//	log.ErrorTrackerFunc(func(event *log.ErrorEvent) error {
+//		e := sentry.NewEvent()
+//		e.Message = event.Message
+//		// 填充 level、tags、extra 等信息。
+//		sentry.CaptureEvent(e)
+//		return nil
+//	})
+type ErrorTrackerFunc func(event *ErrorEvent) error
+
+// Capture 调用 f。
+func (f ErrorTrackerFunc) Capture(event *ErrorEvent) error {
+	return f(event)
+}
+
+// ErrorTrackerConfig 是 ErrorTrackerSink 的配置。
+type ErrorTrackerConfig struct {
+	Level Level   // Level 是上报的日志级别，只上报不低于这个级别的日志，默认是 LogError。
+	Rate  float64 // Rate 是每秒最多上报的错误数，超过的错误会被丢弃，默认是 DefaultErrorTrackerRate。
+	Burst int     // Burst 是短时间内最多连续上报的错误数，默认和 Rate 相同。
+}
+
+// ErrorTrackerSink 将 Error 和 Fatal 级别的日志连同调用栈一起上报给 ErrorTracker，
+// 不需要在每个出错的地方单独调用错误追踪服务的 API。上报会被限流，避免错误风暴时压垮错误追踪服务。
+// Capture 会在输出日志的 goroutine 中同步调用，如果上报比较慢，可以放到 FanOut 中使用。
+type ErrorTrackerSink struct {
+	tracker ErrorTracker
+	level   Level
+	rate    float64
+	burst   float64
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+
+	health sinkHealth
+}
+
+var _ Sink = new(ErrorTrackerSink)
+
+var errErrorTrackerLimited = errors.New("go-log: error tracker is rate limited")
+
+// NewErrorTrackerSink 创建一个 ErrorTrackerSink，config 可以是 nil。
+func NewErrorTrackerSink(tracker ErrorTracker, config *ErrorTrackerConfig) *ErrorTrackerSink {
+	if config == nil {
+		config = &ErrorTrackerConfig{}
+	}
+
+	s := &ErrorTrackerSink{
+		tracker: tracker,
+		level:   config.Level,
+		rate:    config.Rate,
+		burst:   float64(config.Burst),
+	}
+
+	if s.level <= 0 {
+		s.level = LogError
+	}
+
+	if s.rate <= 0 {
+		s.rate = DefaultErrorTrackerRate
+	}
+
+	if s.burst <= 0 {
+		s.burst = s.rate
+	}
+
+	s.tokens = s.burst
+	return s
+}
+
+// Write 上报一条日志，超过频率限制时丢弃这条日志并返回错误。
+func (s *ErrorTrackerSink) Write(entry *Entry) error {
+	if entry.Level <= logPrint || !entry.Level.within(s.level) {
+		return nil
+	}
+
+	if !s.allow() {
+		s.health.drop()
+		return errErrorTrackerLimited
+	}
+
+	event := &ErrorEvent{
+		Entry: *entry,
+		Stack: callerStack(entry.pc),
+	}
+	event.Fields = append([]Info(nil), entry.Fields...)
+
+	err := s.tracker.Capture(event)
+	s.health.record(err)
+	return err
+}
+
+// allow 使用令牌桶判断是否可以上报。
+func (s *ErrorTrackerSink) allow() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+
+	if !s.last.IsZero() {
+		s.tokens += now.Sub(s.last).Seconds() * s.rate
+
+		if s.tokens > s.burst {
+			s.tokens = s.burst
+		}
+	}
+
+	s.last = now
+
+	if s.tokens < 1 {
+		return false
+	}
+
+	s.tokens--
+	return true
+}
+
+// Flush 刷新 ErrorTracker 中尚未发送的错误。
+func (s *ErrorTrackerSink) Flush() error {
+	if f, ok := s.tracker.(flusher); ok {
+		return f.Flush()
+	}
+
+	return nil
+}
+
+// Close 刷新 ErrorTracker，如果 ErrorTracker 实现了 io.Closer 也会关闭它。
+func (s *ErrorTrackerSink) Close() error {
+	err := s.Flush()
+
+	if c, ok := s.tracker.(interface{ Close() error }); ok {
+		if e := c.Close(); e != nil {
+			err = e
+		}
+	}
+
+	return err
+}
+
+// SinkStats 返回上报的健康状况，被限流丢弃的错误计入 Dropped。
+func (s *ErrorTrackerSink) SinkStats() []SinkStats {
+	stats := SinkStats{
+		Name:      fmt.Sprintf("%T", s),
+		Connected: true,
+	}
+	s.health.fill(&stats)
+	return []SinkStats{stats}
+}
+
+// callerStack 返回当前 goroutine 中输出日志的调用栈，从调用者 pc 对应的位置开始记录。
+// pc 为 0 或者不在当前调用栈中时，跳过日志库内部的调用。
+func callerStack(pc uintptr) string {
+	pcs := make([]uintptr, 64)
+	n := runtime.Callers(3, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	all := make([]runtime.Frame, 0, n)
+
+	for {
+		frame, more := frames.Next()
+		all = append(all, frame)
+
+		if !more {
+			break
+		}
+	}
+
+	start := -1
+
+	// 用完整的文件名和行号定位调用者，不受 CallerPath 等输出格式的影响。
+	if fn := runtime.FuncForPC(pc); pc != 0 && fn != nil {
+		file, line := fn.FileLine(pc)
+
+		for i, frame := range all {
+			if frame.File == file && frame.Line == line {
+				start = i
+				break
+			}
+		}
+	}
+
+	if start < 0 {
+		start = 0
+
+		for start < len(all)-1 && strings.HasPrefix(all[start].Function, logPackage+".") {
+			start++
+		}
+	}
+
+	all = all[start:]
+
+	if len(all) > maxPanicStackDepth {
+		all = all[:maxPanicStackDepth]
+	}
+
+	lines := make([]string, 0, len(all))
+
+	for _, frame := range all {
+		lines = append(lines, path.Base(frame.File)+":"+strconv.Itoa(frame.Line)+"@"+frame.Function)
+	}
+
+	return strings.Join(lines, " <- ")
+}
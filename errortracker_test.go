@@ -0,0 +1,73 @@
+package log
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestErrorTrackerSink(t *testing.T) {
+	var events []*ErrorEvent
+	tracker := NewErrorTrackerSink(ErrorTrackerFunc(func(event *ErrorEvent) error {
+		events = append(events, event)
+		return nil
+	}), &ErrorTrackerConfig{
+		Rate:  0.001,
+		Burst: 2,
+	})
+	l, _ := newTestLogger(&Config{
+		LogLevel: "debug",
+		Sinks:    []Sink{tracker},
+	})
+
+	ctx := WithMoreInfo(context.Background(), Info{Key: "user", Value: "alice"})
+	l.Warnf(ctx, "warn")
+	l.Errorf(ctx, "error 1")
+	l.Errorf(ctx, "error 2")
+	l.Errorf(ctx, "error 3")
+
+	if len(events) != 2 {
+		t.Fatalf("only 2 errors should be captured. [count:%v]", len(events))
+	}
+
+	event := events[0]
+
+	if event.Message != "error 1" || event.Level != LogError {
+		t.Fatalf("unexpected event. [event:%+v]", event)
+	}
+
+	if len(event.Fields) != 1 || event.Fields[0].Value != "alice" {
+		t.Fatalf("unexpected fields. [fields:%v]", event.Fields)
+	}
+
+	if !strings.HasPrefix(event.Stack, "errortracker_test.go:") || !strings.Contains(event.Stack, "TestErrorTrackerSink") {
+		t.Fatalf("stack must start at caller. [stack:%v]", event.Stack)
+	}
+
+	stats := tracker.SinkStats()[0]
+
+	if stats.Dropped != 1 || !stats.Healthy {
+		t.Fatalf("unexpected stats. [stats:%+v]", stats)
+	}
+}
+
+func TestErrorTrackerSinkCallerPath(t *testing.T) {
+	for _, callerPath := range []string{CallerPackage, CallerFull} {
+		var event *ErrorEvent
+		tracker := NewErrorTrackerSink(ErrorTrackerFunc(func(e *ErrorEvent) error {
+			event = e
+			return nil
+		}), nil)
+		l, _ := newTestLogger(&Config{
+			CallerPath: callerPath,
+			Sinks:      []Sink{tracker},
+		})
+
+		l.Errorf(context.Background(), "error")
+
+		// 调用者信息的格式不能影响调用栈的起点。
+		if event == nil || !strings.HasPrefix(event.Stack, "errortracker_test.go:") || strings.Contains(event.Stack, "(*logger)") {
+			t.Fatalf("stack must start at caller. [caller_path:%v] [event:%+v]", callerPath, event)
+		}
+	}
+}
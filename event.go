@@ -0,0 +1,40 @@
+package log
+
+import (
+	"context"
+)
+
+// TraceEvent 输出一条 Trace 级别的结构化事件，日志内容是 name，fields 按照传入的顺序放在 ctx 中的信息后面。
+// 和 Tracef 不同，事件不需要格式化，name 中的 "%" 也会原样输出。
+// 文本格式中事件总是按照 Config.Escape 的规则转义，可以用 logparse.ParseEscaped 无歧义的解析，
+// 下游不需要再用正则表达式解析 Tracef 输出的内容。
+//
+//	log.TraceEvent(ctx, "order_paid", log.String("order_id", id), log.Int64("amount", amount))
+func TraceEvent(ctx context.Context, name string, fields ...Info) {
+	pc := callerPC(1)
+	defaultLogger().traceEvent(ctx, pc, name, fields)
+}
+
+func (l *logger) traceEvent(ctx context.Context, pc uintptr, name string, fields []Info) {
+	if l.disabled(ctx, LogTrace) || !l.enabled(ctx, pc, LogTrace) {
+		return
+	}
+
+	entry := l.newEntry(ctx, pc, LogTrace, "")
+	entry.Message = name
+	entry.event = true
+
+	if len(fields) != 0 {
+		fields = resolveFields(fields)
+
+		if l.stable {
+			fields = l.stableFields(fields)
+		}
+
+		all := make([]Info, 0, len(entry.Fields)+len(fields))
+		all = append(all, entry.Fields...)
+		entry.Fields = append(all, fields...)
+	}
+
+	l.emit(ctx, pc, entry, nil)
+}
@@ -0,0 +1,28 @@
+package log
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestTraceEvent(t *testing.T) {
+	l, lines := newTestLogger(&Config{})
+	setDefaultLogger(l)
+	defer Init(nil)
+
+	ctx := WithMoreInfo(context.Background(), Info{Key: "user", Value: "a|b"})
+	TraceEvent(ctx, "paid 100%", String("order", "x=1"), Int("amount", 3))
+	l.Flush()
+
+	if len(*lines) != 1 {
+		t.Fatalf("there should be 1 line. [lines:%v]", *lines)
+	}
+
+	line := (*lines)[0]
+	expected := `*||user=a\|b||order=x\=1||amount=3||paid 100%` + "\n"
+
+	if !strings.HasPrefix(line, "[TRACE]") || !strings.Contains(line, "event_test.go:15@") || !strings.HasSuffix(line, expected) {
+		t.Fatalf("invalid event. [line:%v] [expected:%v]", line, expected)
+	}
+}
@@ -0,0 +1,28 @@
+//go:build !windows
+// +build !windows
+
+package log
+
+import "errors"
+
+var errEventLogUnsupported = errors.New("go-log: Windows event log is only supported on Windows")
+
+// EventLogSink 将不低于 Warn 级别的日志写入 Windows 事件日志，只能在 Windows 上使用。
+type EventLogSink struct{}
+
+var _ Sink = new(EventLogSink)
+
+// NewEventLogSink 在非 Windows 系统上总是返回错误。
+func NewEventLogSink(source string) (*EventLogSink, error) {
+	return nil, errEventLogUnsupported
+}
+
+// Write 在非 Windows 系统上总是返回错误。
+func (s *EventLogSink) Write(entry *Entry) error {
+	return errEventLogUnsupported
+}
+
+// Close 在非 Windows 系统上什么都不做。
+func (s *EventLogSink) Close() error {
+	return nil
+}
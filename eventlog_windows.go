@@ -0,0 +1,116 @@
+package log
+
+import (
+	"errors"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	advapi32                  = syscall.NewLazyDLL("advapi32.dll")
+	procRegisterEventSource   = advapi32.NewProc("RegisterEventSourceW")
+	procDeregisterEventSource = advapi32.NewProc("DeregisterEventSource")
+	procReportEvent           = advapi32.NewProc("ReportEventW")
+
+	errEventLogClosed = errors.New("go-log: event log sink is closed")
+)
+
+// Windows 事件日志的事件类型。
+const (
+	eventLogErrorType       = 0x0001
+	eventLogWarningType     = 0x0002
+	eventLogInformationType = 0x0004
+
+	eventLogEventID = 1
+)
+
+// EventLogSink 将不低于 Warn 级别的日志写入 Windows 事件日志，可以在事件查看器中查看。
+// 日志使用 FormatText 格式编码，Fatal 和 Error 写成错误事件，Warn 写成警告事件。
+type EventLogSink struct {
+	mu     sync.Mutex
+	handle uintptr
+}
+
+var _ Sink = new(EventLogSink)
+
+// NewEventLogSink 使用 source 作为事件来源创建一个 EventLogSink。
+// source 最好事先在注册表中注册，否则事件查看器会提示找不到事件描述，但日志内容依然可以正常查看。
+func NewEventLogSink(source string) (*EventLogSink, error) {
+	p, err := syscall.UTF16PtrFromString(source)
+
+	if err != nil {
+		return nil, err
+	}
+
+	h, _, err := procRegisterEventSource.Call(0, uintptr(unsafe.Pointer(p)))
+
+	if h == 0 {
+		return nil, err
+	}
+
+	return &EventLogSink{
+		handle: h,
+	}, nil
+}
+
+// Write 将 entry 写入事件日志，低于 Warn 级别的日志会被忽略。
+func (s *EventLogSink) Write(entry *Entry) error {
+	var eventType uintptr
+
+	switch entry.Level {
+	case LogFatal, LogPanic, LogError:
+		eventType = eventLogErrorType
+	case LogWarn:
+		eventType = eventLogWarningType
+	case logAudit:
+		eventType = eventLogInformationType
+	default:
+		return nil
+	}
+
+	buf := getBuffer()
+	defer putBuffer(buf)
+	textEncoder{}.Encode(buf, entry)
+
+	msg, err := syscall.UTF16PtrFromString(buf.String())
+
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.handle == 0 {
+		return errEventLogClosed
+	}
+
+	strs := []*uint16{msg}
+	r, _, err := procReportEvent.Call(s.handle, eventType, 0, eventLogEventID, 0, 1, 0, uintptr(unsafe.Pointer(&strs[0])), 0)
+
+	if r == 0 {
+		return err
+	}
+
+	return nil
+}
+
+// Close 注销事件来源。
+func (s *EventLogSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.handle == 0 {
+		return nil
+	}
+
+	r, _, err := procDeregisterEventSource.Call(s.handle)
+	s.handle = 0
+
+	if r == 0 {
+		return err
+	}
+
+	return nil
+}
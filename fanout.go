@@ -0,0 +1,284 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// SinkConfig 描述 FanOut 中的一个输出目标。
+type SinkConfig struct {
+	Sink          Sink  // Sink 是输出目标。
+	Level         Level // Level 是这个输出目标的日志级别，只输出不低于这个级别的日志，默认输出所有级别。
+	BufferedLines int   // BufferedLines 是这个输出目标的缓冲区大小，默认是 DefaultBufferedLines，缓冲区满时新的日志会被丢弃。
+
+	Fields FieldFilter // Fields 设置这个输出目标可以收到哪些字段，默认收到所有字段。
+}
+
+// FanOut 将日志同时分发给多个 Sink，每个 Sink 有自己的日志级别、缓冲区和写入 goroutine，
+// 一个 Sink 写入缓慢或者阻塞时只会让它自己的缓冲区被写满，不会影响其他 Sink 和输出日志的 goroutine。
+// FanOut 本身也是一个 Sink，可以放到 Config.Sinks 中使用：
+//
+//	log.Init(&log.Config{
+//		Sinks: []log.Sink{log.NewFanOut(
+//			log.SinkConfig{Sink: log.NewWriterSink(os.Stdout, log.NewEncoder(log.FormatJSON)), Level: log.LogInfo},
+//			log.SinkConfig{Sink: log.NewNetworkSink(networkConfig), Level: log.LogWarn},
+//		)},
+//	})
+type FanOut struct {
+	members []*fanOutMember
+}
+
+var _ Sink = new(FanOut)
+
+type fanOutMember struct {
+	sink   Sink
+	level  Level
+	fields FieldFilter
+	ch     chan fanOutItem
+	done   chan bool
+
+	mu     sync.RWMutex // mu 保证 ch 关闭之后不会再写入。
+	closed bool
+
+	health sinkHealth
+}
+
+// fanOutItem 是缓冲区中的一条日志，如果 flushed 不为 nil，表示这是一个刷新请求。
+type fanOutItem struct {
+	entry   *Entry
+	flushed chan bool
+}
+
+var errFanOutFull = errors.New("go-log: sink buffer is full")
+
+// NewFanOut 创建一个 FanOut，每个输出目标都会启动一个 goroutine。
+func NewFanOut(configs ...SinkConfig) *FanOut {
+	f := &FanOut{}
+
+	for _, c := range configs {
+		size := c.BufferedLines
+
+		if size <= 0 {
+			size = DefaultBufferedLines
+		}
+
+		m := &fanOutMember{
+			sink:   c.Sink,
+			level:  c.Level,
+			fields: c.Fields,
+			ch:     make(chan fanOutItem, size),
+			done:   make(chan bool),
+		}
+		go m.run()
+		f.members = append(f.members, m)
+	}
+
+	return f
+}
+
+// Write 将 entry 复制一份放入每个匹配的输出目标的缓冲区，任何情况下都不会阻塞。
+// 如果有输出目标的缓冲区满了，这条日志对这个输出目标会被丢弃，并且返回错误。
+func (f *FanOut) Write(entry *Entry) (err error) {
+	var cp *Entry
+
+	for _, m := range f.members {
+		if !m.match(entry.Level) {
+			continue
+		}
+
+		// 所有输出目标共享同一份拷贝，它们都不会修改 entry。
+		if cp == nil {
+			e := *entry
+			e.Fields = append([]Info(nil), entry.Fields...)
+			cp = &e
+		}
+
+		if !m.send(fanOutItem{entry: cp}, false) {
+			m.health.drop()
+			err = errFanOutFull
+		}
+	}
+
+	return
+}
+
+// Flush 等待所有输出目标写完缓冲区中的日志，如果输出目标自带缓冲区也会一起刷新。
+func (f *FanOut) Flush() error {
+	var waits []chan bool
+
+	for _, m := range f.members {
+		flushed := make(chan bool, 1)
+
+		if m.send(fanOutItem{flushed: flushed}, true) {
+			waits = append(waits, flushed)
+		}
+	}
+
+	for _, w := range waits {
+		<-w
+	}
+
+	return nil
+}
+
+// SinkStats 返回每个输出目标的健康状况，如果输出目标自己也实现了 SinkStatser，
+// 它报告的连接状态、缓冲区和失败次数会合并进来。
+func (f *FanOut) SinkStats() []SinkStats {
+	stats := make([]SinkStats, 0, len(f.members))
+
+	for _, m := range f.members {
+		s := SinkStats{
+			Name:      fmt.Sprintf("%T", m.sink),
+			Len:       len(m.ch),
+			Cap:       cap(m.ch),
+			Connected: true,
+		}
+		m.health.fill(&s)
+
+		if statser, ok := m.sink.(SinkStatser); ok {
+			for _, inner := range statser.SinkStats() {
+				s.merge(&inner)
+			}
+		}
+
+		stats = append(stats, s)
+	}
+
+	return stats
+}
+
+// Close 写完所有缓冲区中的日志，然后关闭所有输出目标。
+func (f *FanOut) Close() (err error) {
+	for _, m := range f.members {
+		if e := m.close(); e != nil {
+			err = e
+		}
+	}
+
+	return
+}
+
+func (m *fanOutMember) match(level Level) bool {
+	return m.level == 0 || level.within(m.level)
+}
+
+// send 将 item 放入缓冲区，如果 wait 为 false，缓冲区满时直接返回 false。
+func (m *fanOutMember) send(item fanOutItem, wait bool) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.closed {
+		return false
+	}
+
+	if wait {
+		m.ch <- item
+		return true
+	}
+
+	select {
+	case m.ch <- item:
+		return true
+	default:
+		return false
+	}
+}
+
+func (m *fanOutMember) run() {
+	defer close(m.done)
+
+	for item := range m.ch {
+		if item.flushed != nil {
+			if f, ok := m.sink.(flusher); ok {
+				f.Flush()
+			}
+
+			item.flushed <- true
+			continue
+		}
+
+		entry := item.entry
+
+		// 在写入 goroutine 中过滤字段，不影响输出日志的 goroutine。
+		if m.fields.enabled() {
+			entry = m.fields.apply(entry)
+		}
+
+		m.health.record(m.sink.Write(entry))
+	}
+}
+
+func (m *fanOutMember) close() error {
+	m.mu.Lock()
+
+	if m.closed {
+		m.mu.Unlock()
+		return nil
+	}
+
+	m.closed = true
+	close(m.ch)
+	m.mu.Unlock()
+
+	<-m.done
+	return m.sink.Close()
+}
+
+// WriterSink 将日志用指定的 Encoder 编码之后同步写入一个 io.Writer，一般和 FanOut 一起使用。
+type WriterSink struct {
+	mu      sync.Mutex
+	writer  io.Writer
+	encoder Encoder
+	framed  bool
+}
+
+var _ Sink = new(WriterSink)
+
+// NewWriterSink 创建一个 WriterSink，encoder 为 nil 时使用 FormatText。
+// 如果 w 实现了 io.Closer，关闭 WriterSink 时会关闭 w，os.Stdout 和 os.Stderr 除外。
+func NewWriterSink(w io.Writer, encoder Encoder) *WriterSink {
+	if encoder == nil {
+		encoder = textEncoder{}
+	}
+
+	framed := selfDelimited(encoder)
+	return &WriterSink{
+		writer:  w,
+		encoder: encoder,
+		framed:  framed,
+	}
+}
+
+// Write 编码 entry 并写入 writer。
+func (s *WriterSink) Write(entry *Entry) error {
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	s.encoder.Encode(buf, entry)
+
+	if !s.framed {
+		buf.WriteByte('\n')
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := s.writer.Write(buf.Bytes())
+	return err
+}
+
+// Close 关闭 writer。
+func (s *WriterSink) Close() error {
+	if s.writer == os.Stdout || s.writer == os.Stderr {
+		return nil
+	}
+
+	if c, ok := s.writer.(io.Closer); ok {
+		return c.Close()
+	}
+
+	return nil
+}
@@ -0,0 +1,129 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type blockingSink struct {
+	release chan bool
+	mu      sync.Mutex
+	entries []*Entry
+}
+
+func (s *blockingSink) Write(entry *Entry) error {
+	<-s.release
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = append(s.entries, entry)
+	return nil
+}
+
+func (s *blockingSink) Close() error {
+	return nil
+}
+
+func TestFanOut(t *testing.T) {
+	buf := &bytes.Buffer{}
+	slow := &blockingSink{release: make(chan bool)}
+	f := NewFanOut(
+		SinkConfig{Sink: NewWriterSink(buf, NewEncoder(FormatLogfmt)), Level: LogInfo},
+		SinkConfig{Sink: slow, Level: LogWarn, BufferedLines: 1},
+	)
+
+	now := time.Now()
+	start := time.Now()
+	f.Write(&Entry{Level: LogDebug, Time: now, Message: "debug"})
+	f.Write(&Entry{Level: LogInfo, Time: now, Message: "info"})
+	f.Write(&Entry{Level: LogWarn, Time: now, Message: "warn1"})
+
+	// 等待 warn1 被取出，slow 阻塞在 Write 中。
+	for len(f.members[1].ch) != 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	f.Write(&Entry{Level: LogWarn, Time: now, Message: "warn2"})
+	err := f.Write(&Entry{Level: LogError, Time: now, Message: "error"})
+
+	if time.Since(start) > time.Second {
+		t.Fatalf("write should not be blocked by slow sink.")
+	}
+
+	if err != errFanOutFull {
+		t.Fatalf("slow sink should be full. [err:%v]", err)
+	}
+
+	close(slow.release)
+	f.Flush()
+
+	if s := buf.String(); strings.Contains(s, "debug") || !strings.Contains(s, "msg=info") || !strings.Contains(s, "msg=error") {
+		t.Fatalf("invalid writer sink output. [output:%v]", s)
+	}
+
+	if len(slow.entries) != 2 || slow.entries[0].Message != "warn1" || slow.entries[1].Message != "warn2" {
+		t.Fatalf("invalid slow sink entries. [entries:%v]", slow.entries)
+	}
+
+	f.Close()
+
+	if err := f.Write(&Entry{Level: LogError, Time: now, Message: "closed"}); err == nil {
+		t.Fatalf("write after close should fail.")
+	}
+}
+
+type failingSink struct{}
+
+func (failingSink) Write(entry *Entry) error {
+	return errors.New("broken")
+}
+
+func (failingSink) Close() error {
+	return nil
+}
+
+func TestFanOutStats(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+
+	if err != nil {
+		t.Fatalf("fail to listen. [err:%v]", err)
+	}
+
+	addr := ln.Addr().String()
+	ln.Close()
+
+	f := NewFanOut(
+		SinkConfig{Sink: failingSink{}},
+		SinkConfig{Sink: NewNetworkSink(&NetworkConfig{Address: addr})},
+		SinkConfig{Sink: NewWriterSink(ioutil.Discard, nil)},
+	)
+	defer f.Close()
+
+	l, _ := newTestLogger(&Config{Sinks: []Sink{f}})
+	l.Infof(context.Background(), "hello")
+	l.Flush()
+
+	stats := l.Stats().Sinks
+
+	if len(stats) != 3 {
+		t.Fatalf("there should be 3 sinks. [stats:%v]", stats)
+	}
+
+	if s := stats[0]; s.Healthy || s.ConsecutiveFailures != 1 || s.LastError == nil || s.Name != "log.failingSink" {
+		t.Fatalf("failing sink should be unhealthy. [stats:%+v]", s)
+	}
+
+	if s := stats[1]; s.Healthy || s.Connected || s.Failures != 1 {
+		t.Fatalf("network sink should be disconnected. [stats:%+v]", s)
+	}
+
+	if s := stats[2]; !s.Healthy || !s.Connected || s.Failures != 0 {
+		t.Fatalf("writer sink should be healthy. [stats:%+v]", s)
+	}
+}
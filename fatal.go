@@ -0,0 +1,34 @@
+package log
+
+import "os"
+
+// FatalHandler 决定 Fatalf 输出日志之后的行为，调用时日志已经刷新到磁盘。
+// 如果 FatalHandler 正常返回，Fatalf 也会正常返回。
+type FatalHandler func(entry *Entry)
+
+// FatalError 是 FatalPanic 触发 panic 时的参数，recover 之后可以拿到导致程序退出的原因。
+type FatalError struct {
+	Message string // Message 是 Fatalf 格式化之后的日志内容。
+	Caller  string // Caller 是调用 Fatalf 的位置，格式为 "file:line@func"。
+	Tag     string // Tag 是日志的 tag。
+	Fields  []Info // Fields 是日志中的字段。
+}
+
+func (e *FatalError) Error() string {
+	return "go-log: log.Fatalf at " + e.Caller + ": " + e.Message
+}
+
+// FatalPanic 是默认的 FatalHandler，用 *FatalError 作为参数触发 panic。
+func FatalPanic(entry *Entry) {
+	panic(&FatalError{
+		Message: entry.Message,
+		Caller:  entry.Caller,
+		Tag:     entry.Tag,
+		Fields:  append([]Info(nil), entry.Fields...),
+	})
+}
+
+// FatalExit 直接调用 os.Exit(1) 退出进程，无法被 recover 拦截。
+func FatalExit(entry *Entry) {
+	os.Exit(1)
+}
@@ -0,0 +1,30 @@
+package log
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestFatalPanic(t *testing.T) {
+	l, _ := newTestLogger(&Config{})
+	ctx := WithMoreInfo(WithTag(context.Background(), "boot"), Info{Key: "port", Value: 80})
+
+	defer func() {
+		fe, ok := recover().(*FatalError)
+
+		if !ok {
+			t.Fatalf("panic value must be *FatalError.")
+		}
+
+		if fe.Message != "fail to listen 80" || fe.Tag != "boot" || len(fe.Fields) != 1 || !strings.Contains(fe.Caller, "fatal_test.go:") {
+			t.Fatalf("invalid fatal error. [err:%+v]", fe)
+		}
+
+		if !strings.HasSuffix(fe.Error(), ": fail to listen 80") {
+			t.Fatalf("invalid error message. [err:%v]", fe.Error())
+		}
+	}()
+
+	l.Fatalf(ctx, "fail to listen %v", 80)
+}
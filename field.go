@@ -0,0 +1,329 @@
+package log
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// String 创建一个值为字符串的 Info。
+func String(key string, value string) Info {
+	return Info{Key: key, Value: value}
+}
+
+// Int 创建一个值为 int 的 Info。
+func Int(key string, value int) Info {
+	return Info{Key: key, Value: value}
+}
+
+// Int64 创建一个值为 int64 的 Info。
+func Int64(key string, value int64) Info {
+	return Info{Key: key, Value: value}
+}
+
+// Uint64 创建一个值为 uint64 的 Info。
+func Uint64(key string, value uint64) Info {
+	return Info{Key: key, Value: value}
+}
+
+// Float64 创建一个值为 float64 的 Info。
+func Float64(key string, value float64) Info {
+	return Info{Key: key, Value: value}
+}
+
+// Bool 创建一个值为 bool 的 Info。
+func Bool(key string, value bool) Info {
+	return Info{Key: key, Value: value}
+}
+
+// Bytes 创建一个表示字节数的 Info，文本格式中输出成 "1.2MB" 这样容易阅读的形式（以 1024 为进制），
+// JSON 格式中输出原始的字节数。
+func Bytes(key string, n int64) Info {
+	return Info{Key: key, Value: byteSize(n)}
+}
+
+// Duration 创建一个表示耗时的 Info，文本格式中输出成 "35ms" 这样容易阅读的形式，
+// JSON 格式中输出以纳秒为单位的整数。
+func Duration(key string, d time.Duration) Info {
+	return Info{Key: key, Value: durationValue(d)}
+}
+
+// Any 创建一个任意类型值的 Info，值会用 "%v" 格式输出，map、slice、数组和 struct 在文本格式中输出成 JSON。
+func Any(key string, value interface{}) Info {
+	return Info{Key: key, Value: value}
+}
+
+// LazyString 是一个延迟求值的字符串，只有日志真正输出时才会调用这个函数。
+// 可以把它作为 Debugf 等函数的参数，避免在日志级别关闭时构造代价很高的参数：
+//
+//	log.Debugf(ctx, "state: %v", log.LazyString(func() string {
+//		return dumpState()
+//	}))
+type LazyString func() string
+
+// String 调用 f 返回字符串。
+func (f LazyString) String() string {
+	return f()
+}
+
+// LazyValue 是一个延迟求值的字段值，只有日志真正输出时才会调用这个函数。
+// 通过 WithMoreInfo 保存在 ctx 中的字段如果代价很高，可以用 Lazy 包装，
+// 日志级别关闭或者日志被过滤时不会产生任何开销。
+type LazyValue func() interface{}
+
+// Lazy 创建一个延迟求值的 Info，f 只会在日志输出时调用，每条日志调用一次。
+// 值为 func() interface{} 类型的 Info 也会被当做延迟求值的字段。
+func Lazy(key string, f func() interface{}) Info {
+	return Info{Key: key, Value: LazyValue(f)}
+}
+
+// resolveFields 对 fields 中所有延迟求值的字段求值，如果有需要求值的字段就返回一个新的 slice，不修改 fields。
+func resolveFields(fields []Info) []Info {
+	var resolved []Info
+
+	for i, info := range fields {
+		var f func() interface{}
+
+		switch v := info.Value.(type) {
+		case LazyValue:
+			f = v
+		case func() interface{}:
+			f = v
+		default:
+			if resolved != nil {
+				resolved = append(resolved, info)
+			}
+
+			continue
+		}
+
+		if resolved == nil {
+			resolved = make([]Info, i, len(fields))
+			copy(resolved, fields[:i])
+		}
+
+		resolved = append(resolved, Info{Key: info.Key, Value: callLazyValue(f)})
+	}
+
+	if resolved == nil {
+		return fields
+	}
+
+	return resolved
+}
+
+// writeValue 将 value 按照 "%v" 的格式写入 buf，常见类型不经过 fmt，避免反射带来的开销。
+// map、slice、数组和 struct 会写成 JSON，避免输出 "map[a:1 b:2]" 这样无法解析的内容。
+func writeValue(buf *bytes.Buffer, value interface{}) {
+	var scratch [64]byte
+
+	switch v := value.(type) {
+	case string:
+		buf.WriteString(v)
+	case int:
+		buf.Write(strconv.AppendInt(scratch[:0], int64(v), 10))
+	case int64:
+		buf.Write(strconv.AppendInt(scratch[:0], v, 10))
+	case int32:
+		buf.Write(strconv.AppendInt(scratch[:0], int64(v), 10))
+	case uint:
+		buf.Write(strconv.AppendUint(scratch[:0], uint64(v), 10))
+	case uint64:
+		buf.Write(strconv.AppendUint(scratch[:0], v, 10))
+	case uint32:
+		buf.Write(strconv.AppendUint(scratch[:0], uint64(v), 10))
+	case bool:
+		buf.Write(strconv.AppendBool(scratch[:0], v))
+	case float64:
+		buf.Write(strconv.AppendFloat(scratch[:0], v, 'g', -1, 64))
+	case error:
+		buf.WriteString(safeString(v, "Error", v.Error))
+	case fmt.Stringer:
+		buf.WriteString(safeString(v, "String", v.String))
+	case []byte:
+		// 合法的 UTF-8 直接当作字符串输出，否则输出成十六进制，不使用 JSON 的 base64。
+		if utf8.Valid(v) {
+			buf.Write(v)
+		} else {
+			buf.WriteString(hex.EncodeToString(v))
+		}
+	default:
+		if isComposite(value) {
+			if data, err := marshalJSON(value); err == nil {
+				buf.Write(data)
+				return
+			}
+		}
+
+		fmt.Fprintf(buf, "%+v", value)
+	}
+}
+
+// isComposite 判断 value 是否是 map、slice、数组、struct 或者指向它们的非 nil 指针。
+// 没有导出字段的 struct 会被 JSON 序列化成 {}，这种 struct 不算，交给 fmt 输出。
+func isComposite(value interface{}) bool {
+	switch value.(type) {
+	case json.Marshaler, encoding.TextMarshaler:
+		return true
+	}
+
+	v := reflect.ValueOf(value)
+
+	if v.Kind() == reflect.Ptr && !v.IsNil() {
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Map, reflect.Slice, reflect.Array:
+		return true
+	case reflect.Struct:
+		return hasExportedField(v.Type())
+	}
+
+	return false
+}
+
+// hasExportedField 判断 struct 类型 t 是否有会被 JSON 序列化的导出字段。
+func hasExportedField(t reflect.Type) bool {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+
+		if f.PkgPath == "" && f.Tag.Get("json") != "-" {
+			return true
+		}
+
+		// 嵌入的 struct 即使没有导出，它的导出字段也会被序列化。
+		if f.Anonymous && f.Type.Kind() == reflect.Struct && hasExportedField(f.Type) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// safeString 调用 value 的 Error 或 String 方法，如果发生 panic 就返回和 fmt 一样的占位符，
+// 保证有问题的 Stringer 不会通过日志让程序崩溃。
+func safeString(value interface{}, method string, f func() string) (s string) {
+	defer func() {
+		if r := recover(); r != nil {
+			s = panicPlaceholder(value, method, r)
+		}
+	}()
+
+	return f()
+}
+
+// panicPlaceholder 返回 method 发生 panic 时的占位符，格式和 fmt 相同，nil 指针输出 "<nil>"。
+func panicPlaceholder(value interface{}, method string, r interface{}) string {
+	if v := reflect.ValueOf(value); v.Kind() == reflect.Ptr && v.IsNil() {
+		return "<nil>"
+	}
+
+	return fmt.Sprintf("%%!v(PANIC=%v method: %v)", method, r)
+}
+
+// callLazyValue 调用延迟求值的函数，如果发生 panic 就返回占位符。
+func callLazyValue(f func() interface{}) (v interface{}) {
+	defer func() {
+		if r := recover(); r != nil {
+			v = panicPlaceholder(nil, "LazyValue", r)
+		}
+	}()
+
+	return f()
+}
+
+// valueString 返回 value 按照 "%v" 格式化后的字符串。
+func valueString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case int:
+		return strconv.Itoa(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+
+	buf := &bytes.Buffer{}
+	writeValue(buf, value)
+	return buf.String()
+}
+
+// writeJSONNumber 尝试将数字类型的 value 直接写成 JSON，如果 value 不是数字就返回 false。
+func writeJSONNumber(buf *bytes.Buffer, value interface{}) bool {
+	var scratch [64]byte
+
+	switch v := value.(type) {
+	case int:
+		buf.Write(strconv.AppendInt(scratch[:0], int64(v), 10))
+	case int64:
+		buf.Write(strconv.AppendInt(scratch[:0], v, 10))
+	case int32:
+		buf.Write(strconv.AppendInt(scratch[:0], int64(v), 10))
+	case uint:
+		buf.Write(strconv.AppendUint(scratch[:0], uint64(v), 10))
+	case uint64:
+		buf.Write(strconv.AppendUint(scratch[:0], v, 10))
+	case uint32:
+		buf.Write(strconv.AppendUint(scratch[:0], uint64(v), 10))
+	case bool:
+		buf.Write(strconv.AppendBool(scratch[:0], v))
+	case float64:
+		// JSON 不支持 NaN 和 Inf，交给通用逻辑处理。
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return false
+		}
+
+		buf.Write(strconv.AppendFloat(scratch[:0], v, 'f', -1, 64))
+	default:
+		return false
+	}
+
+	return true
+}
+
+// byteSize 是 Bytes 创建的字段值。
+type byteSize int64
+
+var byteUnits = []string{"KB", "MB", "GB", "TB", "PB", "EB"}
+
+func (b byteSize) String() string {
+	n := int64(b)
+	abs := n
+
+	if abs < 0 {
+		abs = -abs
+	}
+
+	if abs < 1024 {
+		return strconv.FormatInt(n, 10) + "B"
+	}
+
+	f := float64(n) / 1024
+	unit := 0
+
+	for (f >= 1024 || f <= -1024) && unit < len(byteUnits)-1 {
+		f /= 1024
+		unit++
+	}
+
+	// 保留一位小数，整数不输出小数部分。
+	return strings.TrimSuffix(strconv.FormatFloat(f, 'f', 1, 64), ".0") + byteUnits[unit]
+}
+
+// durationValue 是 Duration 创建的字段值。
+type durationValue time.Duration
+
+func (d durationValue) String() string {
+	return time.Duration(d).String()
+}
@@ -0,0 +1,147 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLazyValue(t *testing.T) {
+	l, lines := newTestLogger(&Config{LogLevel: "info"})
+	calls := 0
+	ctx := WithMoreInfo(context.Background(), String("a", "1"), Lazy("dump", func() interface{} {
+		calls++
+		return "expensive"
+	}), Info{Key: "raw", Value: func() interface{} {
+		return 42
+	}})
+
+	l.Debugf(ctx, "filtered")
+
+	if calls != 0 {
+		t.Fatalf("lazy value should not be evaluated when level is disabled.")
+	}
+
+	l.Infof(ctx, "emitted")
+	l.Flush()
+
+	if calls != 1 {
+		t.Fatalf("lazy value should be evaluated once. [calls:%v]", calls)
+	}
+
+	if len(*lines) != 1 || !strings.Contains((*lines)[0], "a=1||dump=expensive||raw=42||emitted") {
+		t.Fatalf("invalid lines. [lines:%v]", *lines)
+	}
+}
+
+type panicStringer struct{}
+
+func (*panicStringer) String() string {
+	panic("broken")
+}
+
+type panicError struct{}
+
+func (panicError) Error() string {
+	panic("broken")
+}
+
+func TestPanicSafeRendering(t *testing.T) {
+	var nilStringer *panicStringer
+	ctx := WithMoreInfo(context.Background(),
+		Any("s", &panicStringer{}),
+		Any("nil", nilStringer),
+		Any("err", panicError{}),
+		Lazy("lazy", func() interface{} { panic("broken") }),
+	)
+
+	for _, format := range []string{FormatText, FormatJSON, FormatLogfmt} {
+		l, lines := newTestLogger(&Config{})
+		l.encoder = NewEncoder(format)
+		l.Infof(ctx, "hello")
+		l.Flush()
+
+		if len(*lines) != 1 {
+			t.Fatalf("there should be one line. [format:%v] [lines:%v]", format, *lines)
+		}
+
+		line := (*lines)[0]
+
+		if !strings.Contains(line, "PANIC=String method: broken") || !strings.Contains(line, "PANIC=Error method: broken") ||
+			!strings.Contains(line, "PANIC=LazyValue method: broken") || !strings.Contains(line, "<nil>") {
+			t.Fatalf("panics should be rendered as placeholders. [format:%v] [line:%v]", format, line)
+		}
+	}
+}
+
+func TestBytesAndDuration(t *testing.T) {
+	sizes := map[int64]string{
+		0:                "0B",
+		1023:             "1023B",
+		1024:             "1KB",
+		1258291:          "1.2MB",
+		-2048:            "-2KB",
+		5 * (1 << 40):    "5TB",
+		1<<62 + 1<<61:    "6EB",
+		1536 * (1 << 30): "1.5TB",
+	}
+
+	for n, expected := range sizes {
+		if actual := byteSize(n).String(); actual != expected {
+			t.Fatalf("invalid byte size. [n:%v] [expected:%v] [actual:%v]", n, expected, actual)
+		}
+	}
+
+	entry := &Entry{
+		Level:   LogInfo,
+		Fields:  []Info{Bytes("size", 1258291), Duration("took", 35*time.Millisecond)},
+		Message: "msg",
+	}
+	buf := &bytes.Buffer{}
+	textEncoder{}.Encode(buf, entry)
+
+	if expected := "size=1.2MB||took=35ms||msg"; !strings.HasSuffix(buf.String(), expected) {
+		t.Fatalf("invalid text. [text:%v] [expected:%v]", buf.String(), expected)
+	}
+
+	buf.Reset()
+	jsonEncoder{}.Encode(buf, entry)
+
+	if expected := `"size":1258291,"took":35000000,"msg":"msg"}`; !strings.HasSuffix(buf.String(), expected) {
+		t.Fatalf("invalid json. [json:%v] [expected:%v]", buf.String(), expected)
+	}
+}
+
+func TestWriteCompositeValue(t *testing.T) {
+	type point struct {
+		X, Y int
+	}
+
+	cases := []struct {
+		value    interface{}
+		expected string
+	}{
+		{map[string]int{"b": 2, "a": 1}, `{"a":1,"b":2}`},
+		{[]string{"a b", "c"}, `["a b","c"]`},
+		{[2]int{1, 2}, `[1,2]`},
+		{point{1, 2}, `{"X":1,"Y":2}`},
+		{&point{1, 2}, `{"X":1,"Y":2}`},
+		{(*point)(nil), `<nil>`},
+		{map[string]interface{}{"f": func() {}}, `map[f:`},
+		{[]byte("hello"), `hello`},
+		{[]byte{0xff, 0x01}, `ff01`},
+		{struct{ x, y int }{1, 2}, `{x:1 y:2}`},
+		{&struct{ x int }{1}, `&{x:1}`},
+	}
+
+	for i, c := range cases {
+		buf := &bytes.Buffer{}
+		writeValue(buf, c.value)
+
+		if !strings.HasPrefix(buf.String(), c.expected) {
+			t.Fatalf("case %v: invalid value. [expected:%v] [actual:%v]", i, c.expected, buf.String())
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package log
+
+import (
+	"fmt"
+	"path"
+)
+
+// FieldFilter 设置一个 Sink 可以收到哪些字段，例如发往远端的日志只保留不含隐私信息的字段。
+// 字段名支持 path.Match 的通配符，例如 "user.*" 匹配 WithGroup(ctx, "user") 之后保存的所有字段。
+type FieldFilter struct {
+	Allow []string `config:"allow"` // Allow 不为空时只保留匹配的字段，RequestIDKey 等自动添加的字段也需要列出来才会保留。
+	Deny  []string `config:"deny"`  // Deny 设置需要删除的字段，优先于 Allow。
+}
+
+func (f *FieldFilter) enabled() bool {
+	return len(f.Allow) != 0 || len(f.Deny) != 0
+}
+
+func (f *FieldFilter) keep(key string) bool {
+	if matchFieldPattern(f.Deny, key) {
+		return false
+	}
+
+	return len(f.Allow) == 0 || matchFieldPattern(f.Allow, key)
+}
+
+// apply 返回字段过滤之后的 entry，没有字段被删除时直接返回 entry，否则返回一份拷贝，不会修改 entry。
+func (f *FieldFilter) apply(entry *Entry) *Entry {
+	for i, info := range entry.Fields {
+		if f.keep(info.Key) {
+			continue
+		}
+
+		fields := make([]Info, i, len(entry.Fields)-1)
+		copy(fields, entry.Fields[:i])
+
+		for _, info := range entry.Fields[i+1:] {
+			if f.keep(info.Key) {
+				fields = append(fields, info)
+			}
+		}
+
+		cp := *entry
+		cp.Fields = fields
+		return &cp
+	}
+
+	return entry
+}
+
+func matchFieldPattern(patterns []string, key string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, key); matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+// FieldFilterSink 按照 FieldFilter 过滤字段之后再把日志交给另一个 Sink。
+type FieldFilterSink struct {
+	sink   Sink
+	filter FieldFilter
+}
+
+var _ Sink = new(FieldFilterSink)
+
+// NewFieldFilterSink 创建一个 FieldFilterSink，sink 只会收到 filter 允许的字段。
+func NewFieldFilterSink(sink Sink, filter FieldFilter) *FieldFilterSink {
+	return &FieldFilterSink{
+		sink:   sink,
+		filter: filter,
+	}
+}
+
+// Write 过滤 entry 的字段之后写入内部的 Sink，不会修改 entry。
+func (s *FieldFilterSink) Write(entry *Entry) error {
+	return s.sink.Write(s.filter.apply(entry))
+}
+
+// Flush 刷新内部的 Sink。
+func (s *FieldFilterSink) Flush() error {
+	if f, ok := s.sink.(flusher); ok {
+		return f.Flush()
+	}
+
+	return nil
+}
+
+// SinkStats 返回内部 Sink 的健康状况。
+func (s *FieldFilterSink) SinkStats() []SinkStats {
+	if statser, ok := s.sink.(SinkStatser); ok {
+		return statser.SinkStats()
+	}
+
+	return []SinkStats{{
+		Name:      fmt.Sprintf("%T", s.sink),
+		Connected: true,
+	}}
+}
+
+// Close 关闭内部的 Sink。
+func (s *FieldFilterSink) Close() error {
+	return s.sink.Close()
+}
@@ -0,0 +1,55 @@
+package log
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFieldFilter(t *testing.T) {
+	all := &bytes.Buffer{}
+	remote := &bytes.Buffer{}
+	f := NewFanOut(
+		SinkConfig{Sink: NewWriterSink(all, NewEncoder(FormatLogfmt))},
+		SinkConfig{Sink: NewWriterSink(remote, NewEncoder(FormatLogfmt)), Fields: FieldFilter{
+			Allow: []string{RequestIDKey, "user.*"},
+			Deny:  []string{"user.phone"},
+		}},
+	)
+	defer f.Close()
+
+	entry := &Entry{
+		Level:   LogInfo,
+		Time:    time.Now(),
+		Message: "login",
+		Fields: []Info{
+			String(RequestIDKey, "req-1"),
+			String("user.id", "42"),
+			String("user.phone", "123456"),
+			String("password", "secret"),
+		},
+	}
+	f.Write(entry)
+	f.Flush()
+
+	if s := all.String(); !strings.HasSuffix(s, "msg=login request_id=req-1 user.id=42 user.phone=123456 password=secret\n") {
+		t.Fatalf("unfiltered sink must receive all fields. [output:%v]", s)
+	}
+
+	if s := remote.String(); !strings.HasSuffix(s, "msg=login request_id=req-1 user.id=42\n") {
+		t.Fatalf("filtered sink must only receive allowed fields. [output:%v]", s)
+	}
+
+	if len(entry.Fields) != 4 {
+		t.Fatalf("entry must not be modified. [fields:%v]", entry.Fields)
+	}
+
+	buf := &bytes.Buffer{}
+	sink := NewFieldFilterSink(NewWriterSink(buf, NewEncoder(FormatLogfmt)), FieldFilter{Deny: []string{"pass*"}})
+	sink.Write(entry)
+
+	if s := buf.String(); strings.Contains(s, "password") || !strings.Contains(s, "user.phone=123456") {
+		t.Fatalf("denied fields must be removed. [output:%v]", s)
+	}
+}
@@ -0,0 +1,75 @@
+package log
+
+import (
+	"os"
+	"path/filepath"
+)
+
+const (
+	// DefaultFileMode 是日志文件的默认权限。
+	DefaultFileMode os.FileMode = 0644
+
+	// DefaultDirMode 是自动创建的日志目录的默认权限。
+	DefaultDirMode os.FileMode = 0755
+)
+
+// fileModes 是创建日志文件和目录时使用的权限，为 0 时使用默认权限。
+type fileModes struct {
+	file os.FileMode
+	dir  os.FileMode
+}
+
+func newFileModes(config *Config) fileModes {
+	return fileModes{
+		file: config.FileMode,
+		dir:  config.DirMode,
+	}
+}
+
+func (m fileModes) fileMode() os.FileMode {
+	if m.file == 0 {
+		return DefaultFileMode
+	}
+
+	return m.file
+}
+
+func (m fileModes) dirMode() os.FileMode {
+	if m.dir == 0 {
+		return DefaultDirMode
+	}
+
+	return m.dir
+}
+
+// openFile 创建 path 所在的目录，并用 O_APPEND 方式打开 path，文件不存在时按照 m 的权限创建。
+func (m fileModes) openFile(path string) (*os.File, error) {
+	if err := os.MkdirAll(filepath.Dir(path), m.dirMode()); err != nil {
+		return nil, err
+	}
+
+	return os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, m.fileMode())
+}
+
+// prepare 提前按照 m 的权限创建 lumberjack 写入的目录和文件，lumberjack 切割日志时会沿用已有文件的权限。
+// 设置了文件权限时，已经存在的文件也会被修改成这个权限。没有设置任何权限时什么都不做，保持 lumberjack 原本的行为。
+func (m fileModes) prepare(path string) error {
+	if m.file == 0 && m.dir == 0 {
+		return nil
+	}
+
+	if m.file == 0 {
+		return os.MkdirAll(filepath.Dir(path), m.dirMode())
+	}
+
+	file, err := m.openFile(path)
+
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+
+	// OpenFile 创建的文件权限受 umask 影响，已有文件的权限也可能不一样。
+	return file.Chmod(m.file)
+}
@@ -0,0 +1,52 @@
+package log
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestFileMode(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file mode is not supported on windows.")
+	}
+
+	for _, backend := range []string{FileBackendLumberjack, FileBackendAppend} {
+		dir := filepath.Join(os.TempDir(), "go-log-filemode-test")
+		os.RemoveAll(dir)
+		defer os.RemoveAll(dir)
+
+		logDir := filepath.Join(dir, "log")
+		logPath := filepath.Join(logDir, "all.log")
+		auditPath := filepath.Join(logDir, "audit.log")
+		err := InitE(&Config{
+			LogPath:      logPath,
+			ErrorLogPath: filepath.Join(logDir, "error.log"),
+			AuditLogPath: auditPath,
+			FileMode:     0600,
+			DirMode:      0700,
+			FileBackend:  backend,
+		})
+
+		if err != nil {
+			t.Fatalf("fail to init. [backend:%v] [err:%v]", backend, err)
+		}
+
+		ctx := context.Background()
+		Infof(ctx, "file mode")
+		Auditf(ctx, "audit")
+		Init(nil)
+
+		if info, err := os.Stat(logDir); err != nil || info.Mode().Perm() != 0700 {
+			t.Fatalf("invalid dir mode. [backend:%v] [info:%v] [err:%v]", backend, info, err)
+		}
+
+		for _, p := range []string{logPath, auditPath} {
+			if info, err := os.Stat(p); err != nil || info.Mode().Perm() != 0600 {
+				t.Fatalf("invalid file mode. [backend:%v] [path:%v] [info:%v] [err:%v]", backend, p, info, err)
+			}
+		}
+	}
+}
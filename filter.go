@@ -0,0 +1,166 @@
+package log
+
+import (
+	"context"
+	"regexp"
+	"strings"
+)
+
+// FilterDrop 是 Filter 的默认动作，直接丢弃匹配的日志。
+const FilterDrop = "drop"
+
+// Filter 代表一条日志过滤规则，所有非空的条件都满足时，规则才会匹配。
+// 匹配的日志会被丢弃，或者被降级成 Action 指定的级别，Action 比日志本身的级别更严重时日志保持不变。
+// Fatalf、Panicf 和 Printf 输出的日志不会被过滤。
+//
+// 例如，下面的规则丢弃所有健康检查的访问日志：
+//
+//	Filter{Tag: "access", Field: "path=/health"}
+type Filter struct {
+	Message string `config:"message"` // Message 匹配包含这个子串的日志内容。
+	Pattern string `config:"pattern"` // Pattern 匹配满足这个正则表达式的日志内容。
+	Module  string `config:"module"`  // Module 匹配调用者所在的 package，规则和 Config.ModuleLevels 一样。
+	Tag     string `config:"tag"`     // Tag 匹配日志的 tag。
+	Field   string `config:"field"`   // Field 匹配 ctx 中的信息，格式是 "key=value"，只写 "key" 时只要存在这个 key 即可。
+	Action  string `config:"action"`  // Action 是匹配之后的动作，可以是 FilterDrop 或者 error 及以下的日志级别，默认是 FilterDrop。
+}
+
+type filter struct {
+	message    string
+	pattern    *regexp.Regexp
+	module     string
+	tag        string
+	fieldKey   string
+	fieldValue string
+	hasValue   bool
+
+	drop  bool
+	level Level
+}
+
+func newFilters(configs []Filter) []filter {
+	filters := make([]filter, 0, len(configs))
+
+	for _, fc := range configs {
+		f := filter{
+			message: fc.Message,
+			module:  strings.Trim(fc.Module, "/"),
+			tag:     fc.Tag,
+		}
+
+		if fc.Pattern != "" {
+			re, err := regexp.Compile(fc.Pattern)
+
+			// 无效的规则直接忽略。
+			if err != nil {
+				continue
+			}
+
+			f.pattern = re
+		}
+
+		if fc.Field != "" {
+			if idx := strings.IndexByte(fc.Field, '='); idx >= 0 {
+				f.fieldKey = fc.Field[:idx]
+				f.fieldValue = fc.Field[idx+1:]
+				f.hasValue = true
+			} else {
+				f.fieldKey = fc.Field
+			}
+		}
+
+		if fc.Action == "" || fc.Action == FilterDrop {
+			f.drop = true
+		} else {
+			f.level = parseLevel(fc.Action)
+
+			// 过滤规则只能降级，不能把日志升级成 fatal 或 panic。
+			if !validFilterLevel(f.level) {
+				continue
+			}
+		}
+
+		filters = append(filters, f)
+	}
+
+	return filters
+}
+
+// validFilterLevel 判断 level 是否可以作为 Filter 的 Action。
+func validFilterLevel(level Level) bool {
+	return !level.within(LogPanic)
+}
+
+func (f *filter) match(pkg string, entry *Entry) bool {
+	if f.tag != "" && f.tag != entry.Tag {
+		return false
+	}
+
+	if f.module != "" && !matchModule(pkg, f.module) {
+		return false
+	}
+
+	if f.message != "" && !strings.Contains(entry.Message, f.message) {
+		return false
+	}
+
+	if f.pattern != nil && !f.pattern.MatchString(entry.Message) {
+		return false
+	}
+
+	if f.fieldKey != "" && !f.matchField(entry.Fields) {
+		return false
+	}
+
+	return true
+}
+
+func (f *filter) matchField(fields []Info) bool {
+	for _, info := range fields {
+		if info.Key != f.fieldKey {
+			continue
+		}
+
+		if !f.hasValue || valueString(info.Value) == f.fieldValue {
+			return true
+		}
+	}
+
+	return false
+}
+
+// filter 使用过滤规则处理 entry，第一条匹配的规则生效。
+// 如果日志需要丢弃就返回 false，日志被降级时会修改 entry.Level。
+func (l *logger) filter(ctx context.Context, pc uintptr, entry *Entry) bool {
+	if entry.Level == LogFatal || entry.Level == LogPanic || entry.Level == logPrint {
+		return true
+	}
+
+	pkg := ""
+
+	if pc != 0 {
+		pkg = l.lookupStack(pc).pkg
+	}
+
+	for i := range l.filters {
+		f := &l.filters[i]
+
+		if !f.match(pkg, entry) {
+			continue
+		}
+
+		if f.drop {
+			return false
+		}
+
+		// 只降级，不升级。
+		if f.level.Severity() > entry.Level.Severity() {
+			entry.Level = f.level
+			return l.enabled(ctx, pc, entry.Level)
+		}
+
+		return true
+	}
+
+	return true
+}
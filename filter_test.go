@@ -0,0 +1,43 @@
+package log
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestFilters(t *testing.T) {
+	l, lines := newTestLogger(&Config{
+		Filters: []Filter{
+			{Tag: "access", Field: "path=/health"},
+			{Pattern: "^retry #[0-9]+$", Action: "debug"},
+			{Message: "noisy", Action: "warn"},
+		},
+	})
+	defer l.Close()
+
+	ctx := context.Background()
+	access := WithTag(ctx, "access")
+	l.Infof(WithMoreInfo(access, Info{Key: "path", Value: "/health"}), "health check")
+	l.Infof(WithMoreInfo(access, Info{Key: "path", Value: "/api"}), "api")
+	l.Infof(ctx, "retry #%v", 3)
+	l.Errorf(ctx, "a noisy line")
+	l.Infof(ctx, "another noisy line")
+
+	if len(*lines) != 3 {
+		t.Fatalf("invalid line count. [lines:%v]", *lines)
+	}
+
+	if !strings.HasSuffix((*lines)[0], "api\n") {
+		t.Fatalf("api log should be kept. [line:%v]", (*lines)[0])
+	}
+
+	if !strings.HasPrefix((*lines)[1], "[WARN]") {
+		t.Fatalf("noisy log should be changed to warn. [line:%v]", (*lines)[1])
+	}
+
+	// 过滤规则只降级，不升级。
+	if !strings.HasPrefix((*lines)[2], "[INFO]") {
+		t.Fatalf("info log must not be raised. [line:%v]", (*lines)[2])
+	}
+}
@@ -0,0 +1,206 @@
+package log
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+)
+
+// FluentConfig 是 fluent forward 协议的配置。
+type FluentConfig struct {
+	NetworkConfig
+
+	Tag string `config:"tag"` // Tag 是 fluent 的 tag，用于 Fluentd/Fluent Bit 的路由，默认是 "go-log"。
+}
+
+// NewFluentSink 创建一个使用 fluent forward 协议发送日志的 NetworkSink，
+// 可以直接发送给 Fluentd 或 Fluent Bit 的 forward input，Network 一般是 "tcp" 或 "unix"。
+// 每条日志编码成一个 msgpack 格式的 [tag, time, record] 消息，record 包含 level、caller、tag、msg 和所有字段。
+func NewFluentSink(config *FluentConfig) *NetworkSink {
+	tag := config.Tag
+
+	if tag == "" {
+		tag = "go-log"
+	}
+
+	return newNetworkSink(&config.NetworkConfig, fluentEncoder{tag: tag}, true)
+}
+
+// fluentEncoder 将日志编码成 fluent forward 协议的 Message Mode 消息，时间使用 EventTime 扩展类型。
+type fluentEncoder struct {
+	tag string
+}
+
+func (e fluentEncoder) Encode(buf *bytes.Buffer, entry *Entry) {
+	writeMsgpackArrayHeader(buf, 3)
+	writeMsgpackString(buf, e.tag)
+
+	// EventTime 是 ext 类型 0，内容是大端序的秒和纳秒。
+	var eventTime [10]byte
+	eventTime[0] = 0xd7
+	eventTime[1] = 0
+	binary.BigEndian.PutUint32(eventTime[2:], uint32(entry.Time.Unix()))
+	binary.BigEndian.PutUint32(eventTime[6:], uint32(entry.Time.Nanosecond()))
+	buf.Write(eventTime[:])
+
+	size := 1 + len(entry.Fields)
+
+	if entry.Level != logPrint {
+		size++
+	}
+
+	if entry.Caller != "" {
+		size++
+	}
+
+	if entry.Tag != "" {
+		size++
+	}
+
+	writeMsgpackMapHeader(buf, size)
+
+	if entry.Level != logPrint {
+		writeMsgpackString(buf, "level")
+		writeMsgpackString(buf, entry.Level.String())
+	}
+
+	if entry.Caller != "" {
+		writeMsgpackString(buf, "caller")
+		writeMsgpackString(buf, entry.Caller)
+	}
+
+	if entry.Tag != "" {
+		writeMsgpackString(buf, "tag")
+		writeMsgpackString(buf, entry.Tag)
+	}
+
+	for _, info := range entry.Fields {
+		writeMsgpackString(buf, info.Key)
+		writeMsgpackValue(buf, info.Value)
+	}
+
+	writeMsgpackString(buf, "msg")
+	writeMsgpackString(buf, entry.Message)
+}
+
+func writeMsgpackArrayHeader(buf *bytes.Buffer, n int) {
+	if n < 16 {
+		buf.WriteByte(0x90 | byte(n))
+		return
+	}
+
+	writeMsgpackLength(buf, 0xdc, 0xdd, n)
+}
+
+func writeMsgpackMapHeader(buf *bytes.Buffer, n int) {
+	if n < 16 {
+		buf.WriteByte(0x80 | byte(n))
+		return
+	}
+
+	writeMsgpackLength(buf, 0xde, 0xdf, n)
+}
+
+// writeMsgpackLength 用 16 位或 32 位长度写入 array 或者 map 的头部。
+func writeMsgpackLength(buf *bytes.Buffer, code16, code32 byte, n int) {
+	var scratch [5]byte
+
+	if n <= math.MaxUint16 {
+		scratch[0] = code16
+		binary.BigEndian.PutUint16(scratch[1:], uint16(n))
+		buf.Write(scratch[:3])
+		return
+	}
+
+	scratch[0] = code32
+	binary.BigEndian.PutUint32(scratch[1:], uint32(n))
+	buf.Write(scratch[:5])
+}
+
+func writeMsgpackString(buf *bytes.Buffer, s string) {
+	var scratch [5]byte
+	n := len(s)
+
+	switch {
+	case n < 32:
+		buf.WriteByte(0xa0 | byte(n))
+	case n <= math.MaxUint8:
+		scratch[0] = 0xd9
+		scratch[1] = byte(n)
+		buf.Write(scratch[:2])
+	case n <= math.MaxUint16:
+		scratch[0] = 0xda
+		binary.BigEndian.PutUint16(scratch[1:], uint16(n))
+		buf.Write(scratch[:3])
+	default:
+		scratch[0] = 0xdb
+		binary.BigEndian.PutUint32(scratch[1:], uint32(n))
+		buf.Write(scratch[:5])
+	}
+
+	buf.WriteString(s)
+}
+
+func writeMsgpackInt(buf *bytes.Buffer, v int64) {
+	var scratch [9]byte
+
+	if v >= 0 && v < 128 {
+		buf.WriteByte(byte(v))
+		return
+	}
+
+	if v < 0 && v >= -32 {
+		buf.WriteByte(byte(v))
+		return
+	}
+
+	scratch[0] = 0xd3
+	binary.BigEndian.PutUint64(scratch[1:], uint64(v))
+	buf.Write(scratch[:])
+}
+
+func writeMsgpackValue(buf *bytes.Buffer, value interface{}) {
+	var scratch [9]byte
+
+	switch v := value.(type) {
+	case nil:
+		buf.WriteByte(0xc0)
+	case bool:
+		if v {
+			buf.WriteByte(0xc3)
+		} else {
+			buf.WriteByte(0xc2)
+		}
+	case int:
+		writeMsgpackInt(buf, int64(v))
+	case int64:
+		writeMsgpackInt(buf, v)
+	case int32:
+		writeMsgpackInt(buf, int64(v))
+	case uint:
+		writeMsgpackUint(buf, uint64(v))
+	case uint64:
+		writeMsgpackUint(buf, v)
+	case uint32:
+		writeMsgpackUint(buf, uint64(v))
+	case float64:
+		scratch[0] = 0xcb
+		binary.BigEndian.PutUint64(scratch[1:], math.Float64bits(v))
+		buf.Write(scratch[:])
+	default:
+		writeMsgpackString(buf, valueString(value))
+	}
+}
+
+func writeMsgpackUint(buf *bytes.Buffer, v uint64) {
+	var scratch [9]byte
+
+	if v < 128 {
+		buf.WriteByte(byte(v))
+		return
+	}
+
+	scratch[0] = 0xcf
+	binary.BigEndian.PutUint64(scratch[1:], v)
+	buf.Write(scratch[:])
+}
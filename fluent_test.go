@@ -0,0 +1,31 @@
+package log
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestFluentEncoder(t *testing.T) {
+	entry := &Entry{
+		Level:   LogInfo,
+		Time:    time.Unix(1, 2),
+		Fields:  []Info{Int("n", 1)},
+		Message: "hi",
+	}
+	expected := []byte{
+		0x93,
+		0xa3, 'a', 'p', 'p',
+		0xd7, 0x00, 0, 0, 0, 1, 0, 0, 0, 2,
+		0x83,
+		0xa5, 'l', 'e', 'v', 'e', 'l', 0xa4, 'I', 'N', 'F', 'O',
+		0xa1, 'n', 0x01,
+		0xa3, 'm', 's', 'g', 0xa2, 'h', 'i',
+	}
+	buf := &bytes.Buffer{}
+	fluentEncoder{tag: "app"}.Encode(buf, entry)
+
+	if actual := buf.Bytes(); !bytes.Equal(actual, expected) {
+		t.Fatalf("invalid msgpack.\n  expected: %x\n  actual:   %x", expected, actual)
+	}
+}
@@ -0,0 +1,101 @@
+package log
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+type slowWriter struct {
+	delay time.Duration
+}
+
+func (w slowWriter) Write(data []byte) (int, error) {
+	time.Sleep(w.delay)
+	return len(data), nil
+}
+
+func (slowWriter) Close() error {
+	return nil
+}
+
+func TestFlushContext(t *testing.T) {
+	w := NewAsyncWriter(slowWriter{delay: 50 * time.Millisecond}, 16)
+	defer w.Close()
+
+	for i := 0; i < 10; i++ {
+		w.Write([]byte("line\n"))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+
+	if err := w.FlushContext(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("flush must time out. [err:%v]", err)
+	}
+
+	if elapsed := time.Since(start); elapsed > 200*time.Millisecond {
+		t.Fatalf("flush must return once ctx is done. [elapsed:%v]", elapsed)
+	}
+
+	// 超时的 Flush 不能影响之后的 Flush。
+	if err := w.FlushContext(context.Background()); err != nil {
+		t.Fatalf("fail to flush. [err:%v]", err)
+	}
+
+	if n := w.Bytes(); n != 0 {
+		t.Fatalf("all lines must be written after flush. [bytes:%v]", n)
+	}
+}
+
+func TestFlushAfterTimeout(t *testing.T) {
+	w := NewAsyncWriter(slowWriter{delay: 50 * time.Millisecond}, 16)
+	defer w.Close()
+
+	w.Write([]byte("first\n"))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if err := w.FlushContext(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("flush must time out. [err:%v]", err)
+	}
+
+	// 等待超时的刷新请求被处理，它的信号不能被之后的 Flush 收到。
+	time.Sleep(100 * time.Millisecond)
+	w.Write([]byte("second\n"))
+
+	if err := w.Flush(); err != nil {
+		t.Fatalf("fail to flush. [err:%v]", err)
+	}
+
+	if n := w.Bytes(); n != 0 {
+		t.Fatalf("flush must wait for its own request. [bytes:%v]", n)
+	}
+}
+
+func TestConcurrentFlush(t *testing.T) {
+	w := NewAsyncWriter(slowWriter{delay: time.Millisecond}, 1024)
+	defer w.Close()
+
+	done := make(chan bool)
+
+	for i := 0; i < 8; i++ {
+		go func() {
+			for j := 0; j < 10; j++ {
+				w.Write([]byte("line\n"))
+				w.Flush()
+			}
+
+			done <- true
+		}()
+	}
+
+	for i := 0; i < 8; i++ {
+		select {
+		case <-done:
+		case <-time.After(10 * time.Second):
+			t.Fatalf("concurrent flush must not lose wake-ups.")
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package log
+
+import "context"
+
+// ForceDebugHeader 是标记请求强制输出调试日志的 HTTP header，值为 "1" 时生效。
+// 这个 header 由 InjectHTTP 和 ExtractHTTP 传递，ExtractHTTP 只有在使用 AllowForceDebug 时才会接受它，
+// 对外的网关也应该删除外部请求中的这个 header。
+const ForceDebugHeader = "X-Log-Debug"
+
+type logForceDebug struct{}
+
+var keyLogForceDebug logForceDebug
+
+// WithForceDebug 标记 ctx 强制输出调试日志，用这个 ctx 输出的所有日志都会忽略日志级别的配置，
+// 一般用于在线上复现单个请求的问题，例如只给带有特定 header 的请求开启调试日志。
+func WithForceDebug(ctx context.Context) context.Context {
+	return context.WithValue(ctx, keyLogForceDebug, true)
+}
+
+// IsForceDebug 判断 ctx 是否被 WithForceDebug 标记过。
+func IsForceDebug(ctx context.Context) bool {
+	forced, _ := ctx.Value(keyLogForceDebug).(bool)
+	return forced
+}
+
+// disabled 快速判断 level 级别的日志是否一定不会输出，被 WithForceDebug 标记过的 ctx 总是返回 false，
+// 通过 SetTagLevel 设置过的 tag 按照设置的级别判断。
+func (l *logger) disabled(ctx context.Context, level Level) bool {
+	return !level.within(l.verboseLevel) && !IsForceDebug(ctx) && !tagOverrideEnabled(ctx, level)
+}
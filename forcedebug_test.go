@@ -0,0 +1,43 @@
+package log
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestForceDebug(t *testing.T) {
+	l, lines := newTestLogger(&Config{
+		LogLevel:  "warn",
+		TagLevels: map[string]string{"quiet": "error"},
+	})
+	setDefaultLogger(l)
+	defer Init(nil)
+
+	ctx := context.Background()
+	Debugf(ctx, "normal debug")
+
+	if len(*lines) != 0 || DebugEnabled(ctx) {
+		t.Fatalf("debug log must be disabled by default. [lines:%v]", *lines)
+	}
+
+	header := http.Header{}
+	InjectHTTP(WithForceDebug(WithTag(ctx, "quiet")), header)
+
+	if IsForceDebug(ExtractHTTP(ctx, header)) {
+		t.Fatalf("force debug must be ignored without AllowForceDebug.")
+	}
+
+	forced := ExtractHTTP(ctx, header, AllowForceDebug())
+
+	if !IsForceDebug(forced) || !DebugEnabled(forced) {
+		t.Fatalf("force debug must be propagated. [header:%v]", header)
+	}
+
+	Debugf(forced, "forced debug")
+	Infof(forced, "forced info")
+
+	if len(*lines) != 2 {
+		t.Fatalf("all logs must be written for forced ctx. [lines:%v]", *lines)
+	}
+}
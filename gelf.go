@@ -0,0 +1,184 @@
+package log
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/rand"
+	"errors"
+	"io"
+	"os"
+	"strconv"
+)
+
+// GELF 的默认配置。
+const (
+	DefaultGELFChunkSize = 1420 // DefaultGELFChunkSize 是 UDP 分片的默认大小，适合大多数网络的 MTU。
+
+	gelfChunkHeaderSize = 12
+	gelfMaxChunks       = 128
+)
+
+// GELFConfig 是 GELF 输出的配置。
+type GELFConfig struct {
+	NetworkConfig
+
+	Host      string `config:"host"`       // Host 是日志中的 host 字段，默认是 os.Hostname()。
+	ChunkSize int    `config:"chunk_size"` // ChunkSize 是 UDP 分片的最大长度，包含 12 字节的分片头，默认是 DefaultGELFChunkSize。
+	Compress  bool   `config:"compress"`   // Compress 让 UDP 消息使用 gzip 压缩，TCP 不支持压缩。
+}
+
+// NewGELFSink 创建一个使用 GELF 格式发送日志的 NetworkSink，可以直接发送给 Graylog 的 GELF input。
+// UDP 消息超过 ChunkSize 时会按照 GELF 协议分片，TCP 消息以 "\x00" 结尾。
+// 日志的所有字段都作为 GELF 的附加字段输出，字段名会加上 "_" 前缀，不合法的字符会被替换成 "_"。
+func NewGELFSink(config *GELFConfig) *NetworkSink {
+	host := config.Host
+
+	if host == "" {
+		host, _ = os.Hostname()
+	}
+
+	if config.Network == "udp" {
+		chunkSize := config.ChunkSize
+
+		if chunkSize <= gelfChunkHeaderSize {
+			chunkSize = DefaultGELFChunkSize
+		}
+
+		conn := newNetConn(&config.NetworkConfig)
+		w := &gelfChunkWriter{
+			conn:      conn,
+			chunkSize: chunkSize,
+			compress:  config.Compress,
+		}
+		return newNetworkSinkWriter(&config.NetworkConfig, gelfEncoder{host: host}, true, conn, w)
+	}
+
+	return newNetworkSink(&config.NetworkConfig, gelfEncoder{host: host, null: true}, true)
+}
+
+// gelfEncoder 将日志编码成 GELF 1.1 格式的 JSON，如果 null 为 true 则在结尾追加 "\x00"。
+type gelfEncoder struct {
+	host string
+	null bool
+}
+
+func (e gelfEncoder) Encode(buf *bytes.Buffer, entry *Entry) {
+	var scratch [64]byte
+
+	buf.WriteString(`{"version":"1.1","host":`)
+	writeJSONString(buf, e.host)
+	buf.WriteString(`,"short_message":`)
+	writeJSONString(buf, entry.Message)
+	buf.WriteString(`,"timestamp":`)
+	buf.Write(strconv.AppendFloat(scratch[:0], float64(entry.Time.UnixNano()/1e6)/1e3, 'f', 3, 64))
+	buf.WriteString(`,"level":`)
+	buf.Write(strconv.AppendInt(scratch[:0], int64(syslogPriority(entry.Level)), 10))
+
+	if entry.Caller != "" {
+		buf.WriteString(`,"_caller":`)
+		writeJSONString(buf, entry.Caller)
+	}
+
+	if entry.Tag != "" {
+		buf.WriteString(`,"_tag":`)
+		writeJSONString(buf, entry.Tag)
+	}
+
+	for _, info := range entry.Fields {
+		buf.WriteByte(',')
+		writeJSONString(buf, gelfFieldName(info.Key))
+		buf.WriteByte(':')
+		writeJSONValue(buf, info.Value)
+	}
+
+	buf.WriteByte('}')
+
+	if e.null {
+		buf.WriteByte(0)
+	}
+}
+
+// gelfFieldName 返回附加字段的名字，GELF 只允许字母、数字、"_"、"." 和 "-"，并且不能使用 "_id"。
+func gelfFieldName(key string) string {
+	name := make([]byte, 0, len(key)+1)
+	name = append(name, '_')
+
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+
+		if c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '.' || c == '-' {
+			name = append(name, c)
+		} else {
+			name = append(name, '_')
+		}
+	}
+
+	if string(name) == "_id" {
+		return "_id_"
+	}
+
+	return string(name)
+}
+
+var errGELFMessageTooLarge = errors.New("go-log: GELF message is too large")
+
+// gelfChunkWriter 将一条 GELF 消息压缩并分片之后通过 UDP 发送。
+type gelfChunkWriter struct {
+	conn      io.WriteCloser
+	chunkSize int
+	compress  bool
+}
+
+func (w *gelfChunkWriter) Write(data []byte) (int, error) {
+	msg := data
+
+	if w.compress {
+		buf := &bytes.Buffer{}
+		gw := gzip.NewWriter(buf)
+		gw.Write(data)
+		gw.Close()
+		msg = buf.Bytes()
+	}
+
+	if len(msg) <= w.chunkSize {
+		if _, err := w.conn.Write(msg); err != nil {
+			return 0, err
+		}
+
+		return len(data), nil
+	}
+
+	size := w.chunkSize - gelfChunkHeaderSize
+	count := (len(msg) + size - 1) / size
+
+	if count > gelfMaxChunks {
+		return 0, errGELFMessageTooLarge
+	}
+
+	chunk := make([]byte, gelfChunkHeaderSize, w.chunkSize)
+	chunk[0] = 0x1e
+	chunk[1] = 0x0f
+	rand.Read(chunk[2:10])
+	chunk[11] = byte(count)
+
+	for i := 0; i < count; i++ {
+		end := (i + 1) * size
+
+		if end > len(msg) {
+			end = len(msg)
+		}
+
+		chunk[10] = byte(i)
+		chunk = append(chunk[:gelfChunkHeaderSize], msg[i*size:end]...)
+
+		if _, err := w.conn.Write(chunk); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(data), nil
+}
+
+func (w *gelfChunkWriter) Close() error {
+	return w.conn.Close()
+}
@@ -0,0 +1,78 @@
+package log
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+type chunkRecorder struct {
+	chunks [][]byte
+}
+
+func (r *chunkRecorder) Write(data []byte) (int, error) {
+	r.chunks = append(r.chunks, append([]byte(nil), data...))
+	return len(data), nil
+}
+
+func (r *chunkRecorder) Close() error {
+	return nil
+}
+
+func TestGELFEncoder(t *testing.T) {
+	entry := &Entry{
+		Level:   LogWarn,
+		Time:    time.Unix(1562128496, 789000000),
+		Caller:  "a.go:12@pkg.Func",
+		Fields:  []Info{Int("id", 1), String("user name", "u")},
+		Message: "hello",
+	}
+	expected := `{"version":"1.1","host":"h","short_message":"hello","timestamp":1562128496.789,"level":4,"_caller":"a.go:12@pkg.Func","_id_":1,"_user_name":"u"}` + "\x00"
+	buf := &bytes.Buffer{}
+	gelfEncoder{host: "h", null: true}.Encode(buf, entry)
+
+	if actual := buf.String(); actual != expected {
+		t.Fatalf("invalid GELF.\n  expected:\n%v\n  actual:\n%v", expected, actual)
+	}
+}
+
+func TestGELFChunkWriter(t *testing.T) {
+	rec := &chunkRecorder{}
+	w := &gelfChunkWriter{conn: rec, chunkSize: 20}
+	data := bytes.Repeat([]byte("x"), 20)
+
+	if _, err := w.Write(data); err != nil {
+		t.Fatalf("fail to write. [err:%v]", err)
+	}
+
+	if len(rec.chunks) != 1 || !bytes.Equal(rec.chunks[0], data) {
+		t.Fatalf("small message should not be chunked. [chunks:%q]", rec.chunks)
+	}
+
+	rec.chunks = nil
+	data = bytes.Repeat([]byte("y"), 24)
+	w.Write(data)
+
+	if len(rec.chunks) != 3 {
+		t.Fatalf("message should be split into 3 chunks. [chunks:%q]", rec.chunks)
+	}
+
+	var joined []byte
+
+	for i, chunk := range rec.chunks {
+		if chunk[0] != 0x1e || chunk[1] != 0x0f || chunk[10] != byte(i) || chunk[11] != 3 ||
+			!bytes.Equal(chunk[2:10], rec.chunks[0][2:10]) {
+			t.Fatalf("invalid chunk header. [i:%v] [chunk:%x]", i, chunk)
+		}
+
+		joined = append(joined, chunk[gelfChunkHeaderSize:]...)
+	}
+
+	if !bytes.Equal(joined, data) {
+		t.Fatalf("invalid chunk data. [data:%q]", joined)
+	}
+
+	if _, err := w.Write(bytes.Repeat([]byte("z"), 8*gelfMaxChunks+1)); err != errGELFMessageTooLarge {
+		t.Fatalf("too large message should be rejected. [err:%v]", err)
+	}
+}
@@ -0,0 +1,160 @@
+package log
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultJournalSocket 是 systemd journald 原生协议的 socket 路径。
+const DefaultJournalSocket = "/run/systemd/journal/socket"
+
+// JournalSink 通过 journald 的原生协议将日志写入 systemd journal。
+// 日志级别会转换成 PRIORITY，调用栈转换成 CODE_FILE、CODE_LINE 和 CODE_FUNC，
+// tag 写入 TAG，其他字段的名字会转换成大写，不合法的字符替换成 "_"。
+//
+// 每条日志是一个 datagram，超过 socket 缓冲区大小的日志会写入失败。
+type JournalSink struct {
+	mu         sync.Mutex
+	socket     string
+	identifier string
+	conn       net.Conn
+}
+
+var _ Sink = new(JournalSink)
+
+// NewJournalSink 创建一个 JournalSink，identifier 是 SYSLOG_IDENTIFIER，默认是程序名。
+// 第一次写入时才会连接 journald，只能在使用 systemd 的 Linux 上使用。
+func NewJournalSink(identifier string) *JournalSink {
+	if identifier == "" {
+		identifier = filepath.Base(os.Args[0])
+	}
+
+	return &JournalSink{
+		socket:     DefaultJournalSocket,
+		identifier: identifier,
+	}
+}
+
+// Write 将 entry 写入 journal。
+func (s *JournalSink) Write(entry *Entry) error {
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	s.encode(buf, entry)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn == nil {
+		conn, err := net.Dial("unixgram", s.socket)
+
+		if err != nil {
+			return err
+		}
+
+		s.conn = conn
+	}
+
+	_, err := s.conn.Write(buf.Bytes())
+	return err
+}
+
+// Close 关闭和 journald 的连接。
+func (s *JournalSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn == nil {
+		return nil
+	}
+
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+func (s *JournalSink) encode(buf *bytes.Buffer, entry *Entry) {
+	writeJournalField(buf, "MESSAGE", entry.Message)
+	writeJournalField(buf, "PRIORITY", strconv.Itoa(syslogPriority(entry.Level)))
+	writeJournalField(buf, "SYSLOG_IDENTIFIER", s.identifier)
+
+	if entry.Caller != "" {
+		caller := entry.Caller
+		function := ""
+
+		if idx := strings.IndexByte(caller, '@'); idx >= 0 {
+			function = caller[idx+1:]
+			caller = caller[:idx]
+		}
+
+		if idx := strings.LastIndexByte(caller, ':'); idx >= 0 {
+			writeJournalField(buf, "CODE_FILE", caller[:idx])
+			writeJournalField(buf, "CODE_LINE", caller[idx+1:])
+		}
+
+		if function != "" {
+			writeJournalField(buf, "CODE_FUNC", function)
+		}
+	}
+
+	if entry.Tag != "" {
+		writeJournalField(buf, "TAG", entry.Tag)
+	}
+
+	for _, info := range entry.Fields {
+		writeJournalField(buf, journalFieldName(info.Key), valueString(info.Value))
+	}
+}
+
+// writeJournalField 按照 journald 原生协议写入一个字段，值中包含换行符时使用二进制格式。
+func writeJournalField(buf *bytes.Buffer, name, value string) {
+	buf.WriteString(name)
+
+	if strings.IndexByte(value, '\n') < 0 {
+		buf.WriteByte('=')
+		buf.WriteString(value)
+		buf.WriteByte('\n')
+		return
+	}
+
+	var size [8]byte
+	binary.LittleEndian.PutUint64(size[:], uint64(len(value)))
+	buf.WriteByte('\n')
+	buf.Write(size[:])
+	buf.WriteString(value)
+	buf.WriteByte('\n')
+}
+
+// journalFieldName 返回合法的 journal 字段名，只能包含大写字母、数字和 "_"，不能以 "_" 或数字开头，最长 64 个字符。
+func journalFieldName(key string) string {
+	name := make([]byte, 0, len(key)+1)
+
+	for i := 0; i < len(key) && len(name) < 64; i++ {
+		c := key[i]
+
+		switch {
+		case c >= 'a' && c <= 'z':
+			name = append(name, c-'a'+'A')
+		case c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+			name = append(name, c)
+		default:
+			name = append(name, '_')
+		}
+	}
+
+	if len(name) == 0 || name[0] == '_' || name[0] >= '0' && name[0] <= '9' {
+		name = append([]byte("F_"), name...)
+
+		if len(name) > 64 {
+			name = name[:64]
+		}
+	}
+
+	return string(name)
+}
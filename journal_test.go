@@ -0,0 +1,64 @@
+package log
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJournalSink(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-log-journal")
+
+	if err != nil {
+		t.Fatalf("fail to create temp dir. [err:%v]", err)
+	}
+
+	defer os.RemoveAll(dir)
+	socket := filepath.Join(dir, "socket")
+	conn, err := net.ListenPacket("unixgram", socket)
+
+	if err != nil {
+		t.Skipf("unixgram is not supported. [err:%v]", err)
+	}
+
+	defer conn.Close()
+
+	sink := NewJournalSink("app")
+	sink.socket = socket
+	defer sink.Close()
+
+	err = sink.Write(&Entry{
+		Level:   LogWarn,
+		Time:    time.Now(),
+		Caller:  "a.go:12@pkg.Func",
+		Tag:     "t",
+		Fields:  []Info{String("user.id", "1"), String("_secret", "2")},
+		Message: "line1\nline2",
+	})
+
+	if err != nil {
+		t.Fatalf("fail to write journal. [err:%v]", err)
+	}
+
+	data := make([]byte, 4096)
+	n, _, err := conn.ReadFrom(data)
+
+	if err != nil {
+		t.Fatalf("fail to read journal. [err:%v]", err)
+	}
+
+	expected := "MESSAGE\n\x0b\x00\x00\x00\x00\x00\x00\x00line1\nline2\n" +
+		"PRIORITY=4\nSYSLOG_IDENTIFIER=app\nCODE_FILE=a.go\nCODE_LINE=12\nCODE_FUNC=pkg.Func\nTAG=t\nUSER_ID=1\nF__SECRET=2\n"
+
+	if actual := string(data[:n]); actual != expected {
+		t.Fatalf("invalid journal message.\n  expected:\n%q\n  actual:\n%q", expected, actual)
+	}
+
+	if !strings.HasPrefix(journalFieldName(strings.Repeat("a", 100)), "AAAA") || len(journalFieldName(strings.Repeat("a", 100))) != 64 {
+		t.Fatalf("field name should be truncated.")
+	}
+}
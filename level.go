@@ -1,8 +1,11 @@
 package log
 
-import "strings"
+import (
+	"fmt"
+	"strings"
+)
 
-// Level 代表日志级别，值越小日志级别越高。
+// Level 代表日志级别，除了 LogPanic 以外值越小日志级别越高，比较日志级别时应该使用 Severity。
 type Level int
 
 // 各种日志级别。
@@ -14,25 +17,134 @@ const (
 	LogInfo
 	LogDebug
 
+	// LogPanic 是 Panicf 使用的日志级别，严重程度介于 LogFatal 和 LogError 之间，
+	// 为了不改变已有日志级别的值，它的值排在 LogDebug 之后。
+	LogPanic
+
 	logMax   = LogDebug + 1
 	logPrint = 0
+	logAudit = -1
 )
 
-func parseLevel(level string) Level {
+// Severity 返回日志级别的严重程度，值越小越严重。
+// LogPanic 的值比 LogDebug 大，所以比较日志级别时应该比较 Severity 而不是直接比较 Level。
+func (level Level) Severity() int {
+	switch {
+	case level == LogPanic:
+		return int(LogFatal)*2 + 1
+	case level > 0:
+		return int(level) * 2
+	default:
+		return int(level)
+	}
+}
+
+// within 判断 level 是否不低于 max，也就是日志级别设置为 max 时 level 级别的日志是否需要输出。
+func (level Level) within(max Level) bool {
+	return level.Severity() <= max.Severity()
+}
+
+// ParseLevel 解析日志级别，不区分大小写，可以是 debug、info、trace、warn（或 warning）、error、panic 和 fatal。
+// 无法识别的级别返回错误。
+func ParseLevel(level string) (Level, error) {
 	switch strings.ToLower(level) {
 	case "debug":
-		return LogDebug
+		return LogDebug, nil
 	case "info":
-		return LogInfo
+		return LogInfo, nil
 	case "trace":
-		return LogTrace
+		return LogTrace, nil
 	case "warn", "warning":
-		return LogWarn
+		return LogWarn, nil
 	case "error":
-		return LogError
+		return LogError, nil
+	case "panic":
+		return LogPanic, nil
 	case "fatal":
-		return LogFatal
+		return LogFatal, nil
 	default:
+		return 0, fmt.Errorf("go-log: unknown log level %q", level)
+	}
+}
+
+// parseLevel 解析日志级别，无法识别的级别当做 LogDebug。
+func parseLevel(level string) Level {
+	l, err := ParseLevel(level)
+
+	if err != nil {
 		return LogDebug
 	}
+
+	return l
+}
+
+// String 返回日志级别的名字，例如 "INFO"，和文本日志中输出的一致。
+func (level Level) String() string {
+	switch level {
+	case LogDebug:
+		return "DEBUG"
+	case LogInfo:
+		return "INFO"
+	case LogTrace:
+		return "TRACE"
+	case LogWarn:
+		return "WARN"
+	case LogError:
+		return "ERROR"
+	case LogPanic:
+		return "PANIC"
+	case LogFatal:
+		return "FATAL"
+	case logAudit:
+		return "AUDIT"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// levelNames 是自定义的日志级别名字，没有自定义的级别使用 Level.String。
+type levelNames map[Level]string
+
+// newLevelNames 解析 Config.LevelNames，无法识别的级别会被忽略。
+func newLevelNames(names map[string]string) levelNames {
+	if len(names) == 0 {
+		return nil
+	}
+
+	parsed := levelNames{}
+
+	for level, name := range names {
+		if l, err := ParseLevel(level); err == nil && name != "" {
+			parsed[l] = name
+		}
+	}
+
+	return parsed
+}
+
+// name 返回 level 在日志中的名字。
+func (names levelNames) name(level Level) string {
+	if name, ok := names[level]; ok {
+		return name
+	}
+
+	return level.String()
+}
+
+// syslogPriority 将日志级别转换成 syslog 的级别。
+func syslogPriority(level Level) int {
+	switch level {
+	case LogFatal, LogPanic:
+		return 2
+	case LogError:
+		return 3
+	case LogWarn:
+		return 4
+	case LogDebug:
+		return 7
+	case logAudit:
+		return 5
+	default:
+		return 6
+	}
 }
@@ -0,0 +1,131 @@
+package log
+
+import (
+	"sort"
+	"strings"
+)
+
+// levelOverrides 记录按 package 和 tag 设置的日志级别。
+// 所有方法都可以在 nil 上调用，代表没有设置任何覆盖规则。
+type levelOverrides struct {
+	modules []moduleLevel // modules 按照 module 长度从长到短排列，保证最长匹配优先。
+	tags    map[string]Level
+}
+
+type moduleLevel struct {
+	module string
+	level  Level
+}
+
+func newLevelOverrides(modules, tags map[string]string) *levelOverrides {
+	if len(modules) == 0 && len(tags) == 0 {
+		return nil
+	}
+
+	lo := &levelOverrides{
+		tags: make(map[string]Level, len(tags)),
+	}
+
+	for module, level := range modules {
+		module = strings.Trim(module, "/")
+
+		if module == "" {
+			continue
+		}
+
+		lo.modules = append(lo.modules, moduleLevel{
+			module: module,
+			level:  parseLevel(level),
+		})
+	}
+
+	sort.Slice(lo.modules, func(i, j int) bool {
+		return len(lo.modules[i].module) > len(lo.modules[j].module)
+	})
+
+	for tag, level := range tags {
+		lo.tags[tag] = parseLevel(level)
+	}
+
+	return lo
+}
+
+// verboseLevel 返回 maxLevel 和所有覆盖规则中最详细的级别，低于这个级别的日志一定不会输出。
+func (lo *levelOverrides) verboseLevel(maxLevel Level) Level {
+	if lo == nil {
+		return maxLevel
+	}
+
+	verbose := maxLevel
+
+	for _, ml := range lo.modules {
+		if !ml.level.within(verbose) {
+			verbose = ml.level
+		}
+	}
+
+	for _, level := range lo.tags {
+		if !level.within(verbose) {
+			verbose = level
+		}
+	}
+
+	return verbose
+}
+
+// moduleLevel 查找 pkg 的日志级别。
+// module 可以是 pkg 的完整路径，或者是路径的最后几段，pkg 的子 package 同样匹配。
+func (lo *levelOverrides) moduleLevel(pkg string) (level Level, ok bool) {
+	if lo == nil || pkg == "" {
+		return
+	}
+
+	for _, ml := range lo.modules {
+		if matchModule(pkg, ml.module) {
+			return ml.level, true
+		}
+	}
+
+	return
+}
+
+func (lo *levelOverrides) tagLevel(tag string) (level Level, ok bool) {
+	if lo == nil || len(lo.tags) == 0 {
+		return
+	}
+
+	level, ok = lo.tags[tag]
+	return
+}
+
+func matchModule(pkg, module string) bool {
+	for {
+		if pkg == module || strings.HasPrefix(pkg, module+"/") {
+			return true
+		}
+
+		idx := strings.IndexByte(pkg, '/')
+
+		if idx < 0 {
+			return false
+		}
+
+		pkg = pkg[idx+1:]
+	}
+}
+
+// packageName 从函数全名中解析出 package 路径，
+// 例如 "github.com/altstory/go-log.(*logger).log" 的 package 是 "github.com/altstory/go-log"。
+func packageName(funcName string) string {
+	lastSlash := strings.LastIndexByte(funcName, '/')
+
+	if lastSlash < 0 {
+		lastSlash = 0
+	}
+
+	if idx := strings.IndexByte(funcName[lastSlash:], '.'); idx >= 0 {
+		return funcName[:lastSlash+idx]
+	}
+
+	return funcName
+}
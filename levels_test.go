@@ -0,0 +1,161 @@
+package log
+
+import (
+	"context"
+	"testing"
+)
+
+func TestPackageName(t *testing.T) {
+	cases := map[string]string{
+		"github.com/altstory/go-log.(*logger).log": "github.com/altstory/go-log",
+		"github.com/altstory/go-log.doLog.func1":   "github.com/altstory/go-log",
+		"main.main":                                "main",
+		"net/http.(*conn).serve":                   "net/http",
+	}
+
+	for name, expected := range cases {
+		if actual := packageName(name); actual != expected {
+			t.Fatalf("invalid package name. [name:%v] [expected:%v] [actual:%v]", name, expected, actual)
+		}
+	}
+}
+
+func TestModuleLevels(t *testing.T) {
+	lo := newLevelOverrides(map[string]string{
+		"dao":     "debug",
+		"app/dao": "error",
+		"http":    "warn",
+	}, nil)
+	cases := []struct {
+		pkg   string
+		level Level
+		ok    bool
+	}{
+		{"github.com/foo/app/dao", LogError, true},
+		{"github.com/foo/other/dao", LogDebug, true},
+		{"github.com/foo/other/dao/mysql", LogDebug, true},
+		{"github.com/foo/http", LogWarn, true},
+		{"github.com/foo/httpx", 0, false},
+		{"github.com/foo/service", 0, false},
+	}
+
+	for _, c := range cases {
+		level, ok := lo.moduleLevel(c.pkg)
+
+		if level != c.level || ok != c.ok {
+			t.Fatalf("invalid module level. [pkg:%v] [expected:%v,%v] [actual:%v,%v]", c.pkg, c.level, c.ok, level, ok)
+		}
+	}
+}
+
+func TestTagLevels(t *testing.T) {
+	l, lines := newTestLogger(&Config{
+		TagLevels: map[string]string{"verbose": "debug"},
+	})
+	defer l.Close()
+
+	ctx := context.Background()
+	l.Debugf(ctx, "hidden")
+	l.Debugf(WithTag(ctx, "verbose"), "shown")
+
+	if len(*lines) != 1 {
+		t.Fatalf("only debug log with tag should be written. [lines:%v]", *lines)
+	}
+}
+
+func TestIsEnabled(t *testing.T) {
+	l, lines := newTestLogger(&Config{
+		ModuleLevels: map[string]string{"go-log": "warn"},
+	})
+	defer l.Close()
+
+	ctx := context.Background()
+
+	if l.isEnabled(ctx, 0, LogInfo) {
+		t.Fatalf("info should be disabled by module level.")
+	}
+
+	if !l.isEnabled(ctx, 0, LogWarn) {
+		t.Fatalf("warn should be enabled.")
+	}
+
+	called := false
+	l.Infof(ctx, "%v", LazyString(func() string {
+		called = true
+		return "lazy"
+	}))
+
+	if called || len(*lines) != 0 {
+		t.Fatalf("lazy string should not be evaluated when level is disabled.")
+	}
+}
+
+func TestNop(t *testing.T) {
+	l := Nop()
+	ctx := context.Background()
+	l.Errorf(ctx, "discarded")
+	l.Fatalf(ctx, "discarded")
+	l.Printf(ctx, "discarded")
+
+	if err := l.Close(); err != nil {
+		t.Fatalf("fail to close nop logger. [err:%v]", err)
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	cases := map[string]Level{
+		"debug":   LogDebug,
+		"INFO":    LogInfo,
+		"Trace":   LogTrace,
+		"warning": LogWarn,
+		"error":   LogError,
+		"panic":   LogPanic,
+		"fatal":   LogFatal,
+	}
+
+	for s, expected := range cases {
+		level, err := ParseLevel(s)
+
+		if err != nil || level != expected {
+			t.Fatalf("fail to parse level. [s:%v] [expected:%v] [actual:%v] [err:%v]", s, expected, level, err)
+		}
+	}
+
+	if _, err := ParseLevel("verbose"); err == nil {
+		t.Fatalf("unknown level should be rejected.")
+	}
+
+	if s := LogWarn.String(); s != "WARN" {
+		t.Fatalf("invalid level name. [expected:WARN] [actual:%v]", s)
+	}
+}
+
+func TestLevelSeverity(t *testing.T) {
+	// 已有日志级别的值不能改变，否则会破坏保存下来的日志级别。
+	values := map[Level]int{
+		LogFatal: 1,
+		LogError: 2,
+		LogWarn:  4,
+		LogTrace: 8,
+		LogInfo:  16,
+		LogDebug: 32,
+	}
+
+	for level, value := range values {
+		if int(level) != value {
+			t.Fatalf("level value must not change. [level:%v] [expected:%v] [actual:%v]", level, value, int(level))
+		}
+	}
+
+	levels := []Level{logAudit, logPrint, LogFatal, LogPanic, LogError, LogWarn, LogTrace, LogInfo, LogDebug}
+
+	for i := 1; i < len(levels); i++ {
+		if levels[i-1].Severity() >= levels[i].Severity() {
+			t.Fatalf("invalid severity order. [prev:%v] [level:%v]", levels[i-1], levels[i])
+		}
+	}
+
+	if !LogPanic.within(LogError) || LogPanic.within(LogFatal) {
+		t.Fatalf("panic must be between fatal and error.")
+	}
+}
@@ -2,31 +2,83 @@ package log
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"sync/atomic"
+	"time"
 	"unsafe"
-
-	"golang.org/x/crypto/ssh/terminal"
 )
 
-var (
-	defaultLoggerPtr = unsafe.Pointer(newLogger(nil))
-
-	isStdoutTerminal bool
-	isStderrTerminal bool
-)
+var defaultLoggerPtr = unsafe.Pointer(newLogger(nil))
 
 // Init 初始化日志配置。
+// 如果 config 不为空，环境变量中的配置会覆盖 config 中的同名配置，详见 EnvPrefix。
+// 如果配置不合法，错误会输出到 stderr，日志依然按照原有方式初始化，需要检查错误时应该使用 InitE。
 func Init(config *Config) {
-	isStdoutTerminal = terminal.IsTerminal(int(os.Stdout.Fd()))
-	isStderrTerminal = terminal.IsTerminal(int(os.Stderr.Fd()))
+	if c, err := applyEnv(config); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+	} else {
+		config = c
+	}
+
+	if err := validateConfig(config); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+	}
 
+	initLogger(config)
+}
+
+// InitE 初始化日志配置，会自动创建日志文件所在的目录。
+// 如果 config 不为空，环境变量中的配置会覆盖 config 中的同名配置，详见 EnvPrefix。
+// 如果日志级别、输出格式等配置不合法，日志路径冲突或者无法创建日志目录，返回错误并且不修改当前的日志配置。
+func InitE(config *Config) error {
+	config, err := applyEnv(config)
+
+	if err != nil {
+		return err
+	}
+
+	if err := validateConfig(config); err != nil {
+		return err
+	}
+
+	initLogger(config)
+	return nil
+}
+
+func initLogger(config *Config) {
 	l := newLogger(config)
+	setDefaultLogger(l)
+
+	if config != nil && config.LogConfig {
+		l.logConfig(config)
+	}
+}
+
+// setDefaultLogger 替换默认日志，并关闭之前的默认日志。
+// 如果新日志设置了 DrainTimeout，关闭之前会等待正在写入旧日志的调用完成。
+func setDefaultLogger(l *logger) {
+	swapDefaultLogger(l, l.drainTimeout)
+}
+
+func swapDefaultLogger(l *logger, drainTimeout time.Duration) error {
 	old := (*logger)(atomic.SwapPointer(&defaultLoggerPtr, unsafe.Pointer(l)))
 
-	if old != nil {
-		old.Close()
+	if old == nil {
+		return nil
+	}
+
+	if drainTimeout > 0 {
+		old.drain(drainTimeout)
 	}
+
+	return old.Close()
+}
+
+// Close 刷新并关闭默认日志，之后的日志会输出到 stdout，直到再次调用 Init。
+// 一般在程序退出前调用，多次调用是安全的。
+func Close() error {
+	return swapDefaultLogger(newLogger(nil), DefaultDrainTimeout)
 }
 
 func defaultLogger() *logger {
@@ -35,37 +87,84 @@ func defaultLogger() *logger {
 
 // Debugf 输出调试日志，默认情况日志级别下不会输出，通过修改配置中的 LogLevel，将级别设置为 LogDebug 来显示这个级别的日志。
 func Debugf(ctx context.Context, fmt string, args ...interface{}) {
-	defaultLogger().Debugf(ctx, fmt, args...)
+	defaultLogger().log(ctx, 0, LogDebug, fmt, args...)
 }
 
 // Infof 输出普通日志，通常的业务日志多数都为这种格式。
 func Infof(ctx context.Context, fmt string, args ...interface{}) {
-	defaultLogger().Infof(ctx, fmt, args...)
+	defaultLogger().log(ctx, 0, LogInfo, fmt, args...)
 }
 
 // Tracef 输出跟踪日志，一般框架使用，用于输出一些可以在日志采集中使用的结构化日志。
 func Tracef(ctx context.Context, fmt string, args ...interface{}) {
-	defaultLogger().Tracef(ctx, fmt, args...)
+	defaultLogger().log(ctx, 0, LogTrace, fmt, args...)
 }
 
 // Warnf 输出告警日志，如果程序走到了一些不预期的分支，需要人工关注，应该用这个级别。
 func Warnf(ctx context.Context, fmt string, args ...interface{}) {
-	defaultLogger().Warnf(ctx, fmt, args...)
+	defaultLogger().log(ctx, 0, LogWarn, fmt, args...)
 }
 
 // Errorf 输出错误日志，如果程序发生了严重错误，应该用这个级别。
 func Errorf(ctx context.Context, fmt string, args ...interface{}) {
-	defaultLogger().Errorf(ctx, fmt, args...)
+	defaultLogger().log(ctx, 0, LogError, fmt, args...)
+}
+
+// WarnIf 在 err 不为 nil 时输出一条告警日志，内容是 "msg: err"，返回 err 方便链式调用：
+//
+//	return log.WarnIf(ctx, cache.Set(key, value), "fail to set cache")
+func WarnIf(ctx context.Context, err error, msg string) error {
+	if err != nil {
+		defaultLogger().log(ctx, 0, LogWarn, "%s: %v", msg, err)
+	}
+
+	return err
+}
+
+// ErrorIf 在 err 不为 nil 时输出一条错误日志，内容是 "msg: err"，返回 err 方便链式调用：
+//
+//	if err := log.ErrorIf(ctx, db.Save(user), "fail to save user"); err != nil {
+//		return err
+//	}
+func ErrorIf(ctx context.Context, err error, msg string) error {
+	if err != nil {
+		defaultLogger().log(ctx, 0, LogError, "%s: %v", msg, err)
+	}
+
+	return err
+}
+
+// Panicf 输出一条 PANIC 级别的日志，然后用格式化之后的内容调用 panic。
+// 和 Fatalf 不同，Panicf 不会终止进程，panic 可以被 recover。无论日志级别是否开启都会 panic。
+func Panicf(ctx context.Context, format string, args ...interface{}) {
+	defaultLogger().panicf(ctx, 0, format, args...)
 }
 
 // Fatalf 直接终止程序，在业务中几乎用不到这种日志，一般只在程序启动的时候用作快速返回。
 func Fatalf(ctx context.Context, fmt string, args ...interface{}) {
-	defaultLogger().Fatalf(ctx, fmt, args...)
+	defaultLogger().log(ctx, 0, LogFatal, fmt, args...)
+}
+
+// Auditf 输出审计日志，审计日志无视日志级别，同步写入单独的审计日志文件并且立即落盘，
+// 用于记录安全相关、必须在进程崩溃后依然可查的事件。写入失败时返回错误。
+func Auditf(ctx context.Context, fmt string, args ...interface{}) error {
+	return defaultLogger().auditf(ctx, fmt, args...)
 }
 
 // Printf 可以无视日志级别，始终对外输出日志，一般只用于框架，业务不使用。
 func Printf(ctx context.Context, fmt string, args ...interface{}) {
-	defaultLogger().Printf(ctx, fmt, args...)
+	defaultLogger().log(ctx, 0, logPrint, fmt, args...)
+}
+
+// Enabled 判断当前调用者在 ctx 下输出 level 级别的日志是否会被输出。
+// 如果构造日志参数的代价很高，可以先用这个函数判断一下。
+func Enabled(ctx context.Context, level Level) bool {
+	return defaultLogger().isEnabled(ctx, 1, level)
+}
+
+// DebugEnabled 判断当前调用者在 ctx 下是否会输出调试日志。
+func DebugEnabled(ctx context.Context) bool {
+	return defaultLogger().isEnabled(ctx, 1, LogDebug)
 }
 
 // Flush 将所有缓冲区的内容强制写入磁盘。
@@ -73,7 +172,22 @@ func Flush() error {
 	return defaultLogger().Flush()
 }
 
+// FlushContext 和 Flush 一样将所有缓冲区的内容强制写入磁盘，但最多等到 ctx 结束，超时返回 ctx.Err()。
+// 适合在程序退出时使用，避免磁盘很慢时无限等待，例如：
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+//	defer cancel()
+//	log.FlushContext(ctx)
+func FlushContext(ctx context.Context) error {
+	return defaultLogger().FlushContext(ctx)
+}
+
 // Rotate 重新打开所有的日志文件，方便做日志切割。
 func Rotate() error {
 	return defaultLogger().Rotate()
 }
+
+// Stats 返回默认日志的缓冲区使用情况，可以用来在缓冲区写满丢日志之前报警。
+func Stats() Statistics {
+	return defaultLogger().Stats()
+}
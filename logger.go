@@ -5,6 +5,7 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 	"path"
 	"reflect"
@@ -12,22 +13,29 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
-
-	"gopkg.in/natefinch/lumberjack.v2"
 )
 
 const (
 	logTimeFormat   = "2006-01-02T15:04:05.999Z07:00"
 	maxLogLine      = 4096
-	loggerSkipLevel = 3
+	loggerSkipLevel = 2
+
+	minBufferedLineSize = 64
+	minBufferedLines    = 1024
 
 	replaceStdPackagePrefix = "<std>"
 )
 
 var (
+	bufferPool = sync.Pool{
+		New: func() interface{} {
+			return &bytes.Buffer{}
+		},
+	}
+
 	stdPackagePrefix string
-	fakeNow          time.Time
 	logSeparator     = []byte("||")
 )
 
@@ -40,6 +48,22 @@ func init() {
 	}
 }
 
+// maxPooledBufferSize 是放回 bufferPool 的最大 buffer 大小，避免偶尔出现的超长日志长期占用内存。
+const maxPooledBufferSize = 64 << 10
+
+func getBuffer() *bytes.Buffer {
+	return bufferPool.Get().(*bytes.Buffer)
+}
+
+func putBuffer(buf *bytes.Buffer) {
+	if buf.Cap() > maxPooledBufferSize {
+		return
+	}
+
+	buf.Reset()
+	bufferPool.Put(buf)
+}
+
 // Logger 代表一个标准日志接口。
 type Logger interface {
 	io.Closer
@@ -59,6 +83,9 @@ type Logger interface {
 	// Errorf 输出错误日志，如果程序发生了严重错误，应该用这个级别。
 	Errorf(ctx context.Context, fmt string, args ...interface{})
 
+	// Panicf 输出一条 PANIC 级别的日志，然后用格式化之后的内容调用 panic，panic 可以被 recover。
+	Panicf(ctx context.Context, fmt string, args ...interface{})
+
 	// Fatalf 直接终止程序，在业务中几乎用不到这种日志，一般只在程序启动的时候用作快速返回。
 	Fatalf(ctx context.Context, fmt string, args ...interface{})
 
@@ -66,23 +93,92 @@ type Logger interface {
 	Printf(ctx context.Context, fmt string, args ...interface{})
 }
 
+// ManagedLogger 是可以管理缓冲区和日志文件的 Logger，New、Nop 和 WithCallerSkip 返回的 Logger 都实现了这个接口，
+// 持有 Logger 的代码可以通过它刷新或者切割日志，而不需要知道具体的类型。
+type ManagedLogger interface {
+	Logger
+
+	// Flush 将所有缓冲区的内容强制写入磁盘。
+	Flush() error
+
+	// FlushContext 和 Flush 一样刷新所有缓冲区，但最多等到 ctx 结束，超时返回 ctx.Err()。
+	FlushContext(ctx context.Context) error
+
+	// Rotate 重新打开所有的日志文件，方便做日志切割。
+	Rotate() error
+
+	// SetOutput 让之后输出的日志都写入 w 而不是日志文件，w 为 nil 时恢复写入日志文件，详见 SetOutput 函数。
+	SetOutput(w io.Writer)
+}
+
+var _ ManagedLogger = new(logger)
+
 type logger struct {
+	seq      uint64 // seq 是最后一条日志的序号，放在最前面保证 32 位系统上原子操作时对齐。
+	inflight int64  // inflight 是正在输出的日志条数，用于在关闭之前等待这些日志写完。
+
 	maxLevel   Level
 	errorLevel Level
 	pkgPrefix  string
-
-	allLogger io.Writer
-	wfLogger  io.Writer
-
-	files   []*lumberjack.Logger
+	callerPath string
+	goroutine  bool
+	stamps     []Info // stamps 是每条日志都会带上的服务信息。
+	utc        bool
+	sequence   bool
+	stable     bool
+	verbosity  int
+
+	drainTimeout time.Duration
+	summary      string // summary 是日志配置的摘要，由 LogBuildInfo 输出。
+	encoder      Encoder
+	framed       bool // framed 为 true 时每条日志自带长度信息，不追加换行符，也不截断。
+	noConsole    bool
+	consoleOut   io.Writer // consoleOut 是普通日志回显的位置，为 nil 时不回显。
+	consoleErr   io.Writer // consoleErr 是错误日志回显的位置，为 nil 时不回显。
+	consoleLevel Level     // consoleLevel 是回显到终端的最低日志级别。
+	onFatal      FatalHandler
+	audit        io.WriteCloser
+	clock        func() time.Time
+	sinks        []Sink
+
+	routes []route
+
+	shadowEncoder Encoder // shadowEncoder 不为空时，每条日志会额外编码一次写入 shadowRoutes。
+	shadowRoutes  []route
+
+	levels       *levelOverrides
+	verboseLevel Level
+	filters      []filter
+	sharded      bool
+	tee          io.Writer
+
+	teeWriters atomic.Value // teeWriters 是额外接收每行日志的 []*teeWriter，由 Config.TeeWriters 和 AddWriter 设置。
+	teeMu      sync.Mutex
+	redirect   atomic.Value // redirect 是 SetOutput 设置的 *teeWriter，不为 nil 时代替 routes 写入日志。
+
+	recent    *recentBuffer // recent 不为 nil 时保存最近输出的日志，详见 DumpRecent。
+	recentAll bool          // recentAll 为 true 时因为日志级别没有输出的日志也会放入 recent。
+	hold      *debugHold    // hold 不为 nil 时按照请求 ID 暂存因为日志级别没有输出的日志，详见 Config.DebugOnError。
+
+	files   []rotator
 	writers []*AsyncWriter
+	paths   []string
+
+	degraded     int32 // degraded 不为 0 时磁盘空间不足，只输出不低于 degradeLevel 的日志。
+	degradeLevel Level
 
 	pcCache sync.Map
+
+	closing   chan bool
+	closeOnce sync.Once
 }
 
 type stack struct {
-	line         []byte
-	panicContext string
+	caller string
+	pkg    string
+
+	level    Level // level 是调用者所在 package 的日志级别，仅在 hasLevel 为 true 时有效。
+	hasLevel bool
 }
 
 const (
@@ -92,239 +188,648 @@ const (
 // newLogger 创建一个新的日志实例。
 // 如果 config 不为空，日志写入到指定文件，否则写入到 stdout/stderr。
 func newLogger(config *Config) *logger {
-	var allLogger io.WriteCloser = dummyCloser{Writer: os.Stdout}
-	var wfLogger io.WriteCloser = dummyCloser{Writer: os.Stderr}
-
 	if config == nil {
 		return &logger{
-			maxLevel:  logMax,
-			allLogger: allLogger,
-			wfLogger:  wfLogger,
+			maxLevel:     logMax,
+			verboseLevel: logMax,
+			encoder:      textEncoder{},
+			onFatal:      FatalPanic,
+			routes:       []route{allRoute(os.Stdout)},
+			audit:        dummyCloser{Writer: os.Stdout},
+			summary:      configSummary(nil),
 		}
 	}
 
-	logPath := config.LogPath
-	logLevelString := config.LogLevel
-	errorLogPath := config.ErrorLogPath
-	errorLogLevelString := config.ErrorLogLevel
-	bufferedLines := config.BufferedLines
-	pkgPrefix := config.PackagePrefix
+	config = withDefaults(config)
+
+	var l *logger
+
+	switch config.Output {
+	case OutputStdout:
+		l = newStreamLogger(config, dummyCloser{Writer: os.Stdout})
+	case OutputDiscard:
+		l = newStreamLogger(config, dummyCloser{Writer: ioutil.Discard})
+		l.routes = nil
+	case OutputJournal:
+		l = newStreamLogger(config, dummyCloser{Writer: ioutil.Discard})
+		l.routes = nil
+	case OutputSidecar:
+		var w io.WriteCloser = dummyCloser{Writer: os.Stdout}
+
+		if config.SidecarPath != "" {
+			w = &pipeFile{path: config.SidecarPath}
+		}
+
+		l = newStreamLogger(config, NewFrameWriter(w))
+		l.framed = true
+	default:
+		l = newFileLogger(config)
+	}
 
-	if logPath == "" {
-		logPath = DefaultLogPath
+	// 二进制格式不适合输出到终端。
+	if config.Format == FormatBinary {
+		l.framed = true
+		l.noConsole = true
 	}
 
-	if logLevelString == "" {
-		logLevelString = DefaultLogLevel
+	l.encoder = configureEncoder(l.encoder, config)
+
+	if l.shadowEncoder != nil {
+		l.shadowEncoder = configureEncoder(l.shadowEncoder, config)
 	}
 
-	if errorLogPath == "" {
-		errorLogPath = DefaultErrorLogPath
+	l.sinks = config.Sinks
+
+	for _, w := range config.TeeWriters {
+		l.addTeeWriter(w)
 	}
 
-	if errorLogLevelString == "" {
-		errorLogLevelString = DefaultErrorLogLevel
+	if config.RecentEntries > 0 {
+		l.recent = newRecentBuffer(config.RecentEntries)
+		l.recentAll = config.RecentAll
 	}
 
-	if bufferedLines <= 0 {
-		bufferedLines = DefaultBufferedLines
+	if config.DebugOnError > 0 {
+		l.hold = newDebugHold(config.DebugOnError, config.DebugOnErrorRequests)
 	}
 
-	if pkgPrefix != "" {
-		idx := strings.LastIndex(pkgPrefix, "/")
+	l.callerPath = config.CallerPath
+	l.goroutine = config.Goroutine
+	l.stamps = newStamps(config)
+	l.utc = config.UTC
+	l.sequence = config.Sequence
+	l.stable = config.StableFields
+	l.verbosity = config.Verbosity
+	l.drainTimeout = config.DrainTimeout
+	l.summary = configSummary(config)
+	l.consoleOut, l.consoleErr = newConsole(config.Console)
+	l.consoleLevel = parseLevel(config.ConsoleLevel)
+
+	if config.BufferedBytes > 0 {
+		maxBytes := config.BufferedBytes
+
+		if l.sharded {
+			maxBytes = (maxBytes + config.Shards - 1) / config.Shards
+		}
 
-		if idx >= 0 {
-			pkgPrefix = pkgPrefix[:idx+1]
+		for _, w := range l.writers {
+			w.SetMaxBytes(maxBytes)
 		}
 	}
 
-	var files []*lumberjack.Logger
-	var writers []*AsyncWriter
+	if config.Output == OutputJournal {
+		l.sinks = append([]Sink{NewJournalSink("")}, l.sinks...)
+	}
+
+	l.clock = config.Clock
+	l.levels = newLevelOverrides(config.ModuleLevels, config.TagLevels)
+	l.verboseLevel = l.levels.verboseLevel(l.maxLevel)
+	l.filters = newFilters(config.Filters)
+	l.onFatal = config.OnFatal
 
-	allFile := &lumberjack.Logger{
-		Filename: logPath,
-		MaxSize:  maxLogFileSize,
+	if l.onFatal == nil {
+		l.onFatal = FatalPanic
 	}
-	files = append(files, allFile)
-	w := NewAsyncWriter(allFile, bufferedLines)
-	writers = append(writers, w)
-	allLogger = w
 
-	if errorLogPath != logPath {
-		wfFile := &lumberjack.Logger{
-			Filename: errorLogPath,
-			MaxSize:  maxLogFileSize,
-		}
-		files = append(files, wfFile)
-		w := NewAsyncWriter(wfFile, bufferedLines)
-		writers = append(writers, w)
-		wfLogger = w
+	// 只写 stdout 时，如果没有指定审计日志文件，审计日志也同步写入 stdout。
+	if config.AuditLogPath == "" && config.Output == OutputStdout {
+		l.audit = dummyCloser{Writer: os.Stdout}
+	} else if config.AuditLogPath == "" {
+		l.audit = dummyCloser{Writer: ioutil.Discard}
 	} else {
-		wfLogger = allLogger
+		l.audit = newAuditFile(config.AuditLogPath, newFileModes(config))
 	}
+	l.setErrorHandler(config)
+	l.closing = make(chan bool)
 
-	return &logger{
+	if config.FlushInterval > 0 {
+		go l.autoFlush(config.FlushInterval)
+	}
+
+	if config.RuntimeStatsInterval > 0 {
+		go l.reportRuntime(config.RuntimeStatsInterval)
+	}
+
+	if config.DiskMinFree > 0 && len(l.paths) > 0 {
+		l.startDiskGuard(config)
+	}
+
+	return l
+}
+
+// newFileLogger 创建一个写文件的日志实例。
+func newFileLogger(config *Config) *logger {
+	logPath := config.LogPath
+	logLevelString := config.LogLevel
+	errorLogPath := config.ErrorLogPath
+	errorLogLevelString := config.ErrorLogLevel
+	bufferedLines := bufferedLinesOf(config)
+	pkgPrefix := normalizePackagePrefix(config.PackagePrefix)
+	format := config.Format
+	routeConfigs := config.Routes
+
+	// 没有设置路由规则时，所有日志写入 LogPath，错误日志额外写入 ErrorLogPath。
+	if len(routeConfigs) == 0 {
+		routeConfigs = append(routeConfigs, Route{
+			Path: logPath,
+		})
+
+		if errorLogPath != logPath {
+			routeConfigs = append(routeConfigs, Route{
+				MinLevel: errorLogLevelString,
+				Path:     errorLogPath,
+			})
+		}
+	}
+
+	// 每个路径只打开一次文件，多条规则可以共享同一个文件。
+	// 不分片时使用 writerPool 里的 AsyncWriter，重新 Init 时可以复用之前打开的文件。
+	var files []logFile
+	var pooled []*AsyncWriter
+	var paths []string
+	pathIndex := map[string]int{}
+	reuse := config.Shards <= 1 && !config.SinglePipeline
+
+	for _, rc := range routeConfigs {
+		if _, ok := pathIndex[rc.Path]; ok {
+			continue
+		}
+
+		var file logFile
+
+		if reuse {
+			var w *AsyncWriter
+			w, file = acquireWriter(rc.Path, newFileOptions(config, bufferedLines))
+			pooled = append(pooled, w)
+		} else {
+			file = openLogFile(rc.Path, newFileOptions(config, bufferedLines))
+		}
+
+		pathIndex[rc.Path] = len(files)
+		files = append(files, file)
+		paths = append(paths, rc.Path)
+	}
+
+	l := &logger{
 		maxLevel:   parseLevel(logLevelString),
 		errorLevel: parseLevel(errorLogLevelString),
 		pkgPrefix:  pkgPrefix,
+		encoder:    NewEncoder(format),
+		paths:      paths,
+	}
+
+	for _, file := range files {
+		l.files = append(l.files, file)
+	}
+
+	if config.ShadowFormat != "" {
+		l.openShadow(config, routeConfigs, bufferedLines)
+	}
+
+	// 所有文件共享同一个缓冲区，每条日志只入队一次，由 teeFile 分发到各个文件。
+	// 文件数量超过 maxTeeFiles 时 validateConfig 会报错，Init 输出错误之后每个文件使用独立的缓冲区。
+	if config.SinglePipeline && len(files) <= maxTeeFiles {
+		w := NewAsyncWriter(newTeeFile(files), bufferedLines)
+		l.writers = append(l.writers, w)
+		l.tee = w
+
+		for i, rc := range routeConfigs {
+			r := newRoute(rc, i == 0)
+			r.mask = 1 << uint(pathIndex[rc.Path])
+			l.routes = append(l.routes, r)
+		}
+
+		return l
+	}
+
+	shardsList := make([][]io.Writer, len(files))
+
+	for i, file := range files {
+		if config.Shards > 1 {
+			// 多个分片共享同一个文件，最后一个分片关闭时才真正关闭文件。
+			shared := newSharedCloser(file, config.Shards)
+			size := (bufferedLines + config.Shards - 1) / config.Shards
+
+			for j := 0; j < config.Shards; j++ {
+				w := NewAsyncWriter(shared, size)
+				l.writers = append(l.writers, w)
+				shardsList[i] = append(shardsList[i], w)
+			}
+		} else {
+			var w *AsyncWriter
 
-		allLogger: allLogger,
-		wfLogger:  wfLogger,
+			if reuse {
+				w = pooled[i]
+			} else {
+				w = NewAsyncWriter(file, bufferedLines)
+			}
+
+			l.writers = append(l.writers, w)
+			shardsList[i] = append(shardsList[i], w)
+		}
+	}
 
-		files:   files,
-		writers: writers,
+	for i, rc := range routeConfigs {
+		l.routes = append(l.routes, newRoute(rc, i == 0, shardsList[pathIndex[rc.Path]]...))
 	}
+
+	l.sharded = config.Shards > 1
+	return l
+}
+
+// newStreamLogger 创建一个只写 stream 的日志实例，不会创建任何日志文件。
+// 所有级别的日志都写入 stream，默认使用 JSON 格式，适合在容器中使用。
+func newStreamLogger(config *Config, stream io.WriteCloser) *logger {
+	logLevelString := config.LogLevel
+	bufferedLines := bufferedLinesOf(config)
+	format := config.Format
+	w := NewAsyncWriter(stream, bufferedLines)
+
+	return &logger{
+		maxLevel:   parseLevel(logLevelString),
+		errorLevel: logPrint,
+		pkgPrefix:  normalizePackagePrefix(config.PackagePrefix),
+		encoder:    NewEncoder(format),
+		noConsole:  true,
+
+		routes: []route{allRoute(w)},
+
+		writers: []*AsyncWriter{w},
+	}
+}
+
+// bufferedLinesOf 返回每个日志文件缓冲区的条数。
+// 只设置了 BufferedBytes 时，按照每条日志 minBufferedLineSize 字节估算条数，避免只需要很少内存时也预留大量条数。
+func bufferedLinesOf(config *Config) int {
+	if config.BufferedLines > 0 {
+		return config.BufferedLines
+	}
+
+	if config.BufferedBytes <= 0 {
+		return DefaultBufferedLines
+	}
+
+	lines := config.BufferedBytes / minBufferedLineSize
+
+	if lines < minBufferedLines {
+		lines = minBufferedLines
+	} else if lines > DefaultBufferedLines {
+		lines = DefaultBufferedLines
+	}
+
+	return lines
+}
+
+func normalizePackagePrefix(pkgPrefix string) string {
+	if pkgPrefix == "" {
+		return ""
+	}
+
+	if idx := strings.LastIndex(pkgPrefix, "/"); idx >= 0 {
+		pkgPrefix = pkgPrefix[:idx+1]
+	}
+
+	return pkgPrefix
 }
 
 func (l *logger) Debugf(ctx context.Context, fmt string, args ...interface{}) {
-	l.log(ctx, LogDebug, fmt, args...)
+	l.log(ctx, 0, LogDebug, fmt, args...)
 }
 
 func (l *logger) Infof(ctx context.Context, fmt string, args ...interface{}) {
-	l.log(ctx, LogInfo, fmt, args...)
+	l.log(ctx, 0, LogInfo, fmt, args...)
 }
 
 func (l *logger) Tracef(ctx context.Context, fmt string, args ...interface{}) {
-	l.log(ctx, LogTrace, fmt, args...)
+	l.log(ctx, 0, LogTrace, fmt, args...)
 }
 
 func (l *logger) Warnf(ctx context.Context, fmt string, args ...interface{}) {
-	l.log(ctx, LogWarn, fmt, args...)
+	l.log(ctx, 0, LogWarn, fmt, args...)
 }
 
 func (l *logger) Errorf(ctx context.Context, fmt string, args ...interface{}) {
-	l.log(ctx, LogError, fmt, args...)
+	l.log(ctx, 0, LogError, fmt, args...)
+}
+
+func (l *logger) Panicf(ctx context.Context, fmt string, args ...interface{}) {
+	l.panicf(ctx, 0, fmt, args...)
 }
 
 func (l *logger) Fatalf(ctx context.Context, fmt string, args ...interface{}) {
-	l.log(ctx, LogFatal, fmt, args...)
+	l.log(ctx, 0, LogFatal, fmt, args...)
 }
 
 func (l *logger) Printf(ctx context.Context, fmt string, args ...interface{}) {
-	l.log(ctx, logPrint, fmt, args...)
+	l.log(ctx, 0, logPrint, fmt, args...)
 }
 
-func (l *logger) log(ctx context.Context, level Level, format string, args ...interface{}) {
-	if l.maxLevel < level {
+// panicf 输出一条 PANIC 级别的日志并刷新缓冲区，然后用格式化之后的内容调用 panic，
+// 无论日志级别是否开启都会 panic。skip 是调用者信息需要额外跳过的调用栈层数。
+func (l *logger) panicf(ctx context.Context, skip int, format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	l.log(ctx, skip+1, LogPanic, "%s", msg)
+	l.Flush()
+	panic(msg)
+}
+
+// log 输出一条日志，skip 是调用者信息需要额外跳过的调用栈层数。
+func (l *logger) log(ctx context.Context, skip int, level Level, format string, args ...interface{}) {
+	if l.disabled(ctx, level) {
+		if level != logPrint && l.keepsSuppressed(ctx) {
+			l.suppress(ctx, callerPC(loggerSkipLevel+skip), level, format, args)
+		}
+
 		return
 	}
 
-	panicContext := ""
+	if !level.within(l.degradeLevel) && atomic.LoadInt32(&l.degraded) != 0 {
+		return
+	}
 
-	// 日志格式：
-	//     [INFO] 2019-07-03T12:34:56.789Z08:00 *||key1=value1||this is custom log text
-	buf := &bytes.Buffer{}
+	var pc uintptr
 
 	if level != logPrint {
-		// 输出 `[level]`
-		levelName := "UNKNOWN"
+		pc = callerPC(loggerSkipLevel + skip)
+
+		if !l.enabled(ctx, pc, level) {
+			if l.keepsSuppressed(ctx) {
+				l.suppress(ctx, pc, level, format, args)
+			}
 
-		switch level {
-		case LogDebug:
-			levelName = "DEBUG"
-		case LogInfo:
-			levelName = "INFO"
-		case LogTrace:
-			levelName = "TRACE"
-		case LogWarn:
-			levelName = "WARN"
-		case LogError:
-			levelName = "ERROR"
-		case LogFatal:
-			levelName = "FATAL"
+			return
 		}
 
-		buf.WriteByte('[')
-		buf.WriteString(levelName)
-		buf.WriteByte(']')
+		if l.hold != nil && level.within(LogError) {
+			l.releaseHeld(ctx)
+		}
+	}
 
-		// 输出时间戳。
-		now := time.Now()
+	l.output(ctx, pc, level, format, args...)
+}
 
-		if !fakeNow.IsZero() {
-			now = fakeNow
-		}
+// output 输出一条日志，pc 是调用者的位置，如果 pc 为 0 则不输出调用栈。
+// 调用方需要自己检查日志级别。
+func (l *logger) output(ctx context.Context, pc uintptr, level Level, format string, args ...interface{}) {
+	l.emit(ctx, pc, l.newEntry(ctx, pc, level, format, args...), args)
+}
 
-		buf.WriteByte('[')
-		buf.WriteString(now.Format(logTimeFormat))
-		buf.WriteByte(']')
+// emit 过滤并输出 entry，args 是格式化消息用的参数。
+func (l *logger) emit(ctx context.Context, pc uintptr, entry *Entry, args []interface{}) {
+	atomic.AddInt64(&l.inflight, 1)
+	defer atomic.AddInt64(&l.inflight, -1)
 
-		// 输出调用栈。
-		if pc, _, _, ok := runtime.Caller(loggerSkipLevel); ok {
-			var st stack
+	level := entry.Level
 
-			if cache, ok := l.pcCache.Load(pc); ok {
-				st = cache.(stack)
-			} else {
-				st = l.parsePC(pc)
-				l.pcCache.Store(pc, st)
+	if !l.classifyError(ctx, pc, entry, args) {
+		return
+	}
+
+	if len(l.filters) != 0 && !l.filter(ctx, pc, entry) {
+		return
+	}
+
+	// 序号在过滤之后分配，被过滤的日志不会产生空洞。
+	if l.sequence && level != logPrint {
+		fields := make([]Info, 0, len(entry.Fields)+1)
+		fields = append(fields, Info{Key: SequenceKey, Value: atomic.AddUint64(&l.seq, 1)})
+		entry.Fields = append(fields, entry.Fields...)
+	}
+
+	level = entry.Level
+
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	// 使用 tee 时，第一个字节用来记录日志需要写入哪些文件。
+	start := 0
+
+	if l.tee != nil {
+		buf.WriteByte(0)
+		start = 1
+	}
+
+	l.encoder.Encode(buf, entry)
+
+	if !l.framed {
+		buf.WriteByte('\n')
+	}
+
+	line := buf.Bytes()[start:]
+
+	if !l.framed && len(line) > maxLogLine {
+		line = line[:maxLogLine]
+	}
+
+	// plain 是写入 tee、最近日志和终端等普通 writer 的日志。
+	// 日志文件由 FrameWriter 分隔时 line 没有换行符，但是普通 writer 依然需要用换行符分隔每条日志。
+	plain := line
+
+	if l.framed && !selfDelimited(l.encoder) {
+		buf.WriteByte('\n')
+		plain = buf.Bytes()[start:]
+		line = plain[:len(plain)-1]
+	}
+
+	if w := l.redirected(); w != nil {
+		w.Write(plain)
+	} else if l.tee != nil {
+		var mask byte
+
+		for i := range l.routes {
+			if r := &l.routes[i]; r.match(level) {
+				mask |= r.mask
 			}
+		}
 
-			buf.Write(st.line)
-			panicContext = st.panicContext
+		if mask != 0 {
+			data := buf.Bytes()[:start+len(line)]
+			data[0] = mask
+			l.tee.Write(data)
 		}
+	} else {
+		shardKey := uint32(0)
 
-		// 输出 tag。
-		tag := tag(ctx)
+		if l.sharded {
+			shardKey = l.shardKey(entry.Tag, pc)
+		}
 
-		if tag == "" {
-			tag = "*"
+		for i := range l.routes {
+			if r := &l.routes[i]; r.match(level) {
+				r.write(shardKey, line)
+			}
 		}
+	}
 
-		buf.WriteByte(' ')
-		buf.WriteString(tag)
+	l.writeTee(plain)
 
-		// 准备开始输出用户日志。
-		buf.Write(logSeparator)
+	if l.recent != nil {
+		l.recent.add(plain)
+	}
 
-		// 输出 ctx 中的各种信息。
-		more := findMoreInfo(ctx)
+	if l.shadowEncoder != nil {
+		l.writeShadow(level, entry)
+	}
+
+	for _, sink := range l.sinks {
+		sink.Write(entry)
+	}
 
-		for _, info := range more {
-			fmt.Fprintf(buf, "%s=%v", info.Key, info.Value)
-			buf.Write(logSeparator)
+	if !l.noConsole && !entry.noConsole && level.within(l.consoleLevel) {
+		if !level.within(l.errorLevel) || level == logPrint {
+			if l.consoleOut != nil {
+				l.consoleOut.Write(plain)
+			}
+		} else if l.consoleErr != nil {
+			l.consoleErr.Write(plain)
 		}
 	}
 
-	fmt.Fprintf(buf, format, args...)
+	if level == LogFatal {
+		l.Flush()
+		l.onFatal(entry)
+	}
+}
 
-	buf.WriteByte('\n')
-	line := buf.Bytes()
+// enabled 判断调用者在 pc 位置输出的 level 级别日志是否需要输出。
+// tag 的日志级别优先于 package 的日志级别，package 的日志级别优先于全局日志级别。
+func (l *logger) enabled(ctx context.Context, pc uintptr, level Level) bool {
+	if IsForceDebug(ctx) {
+		return true
+	}
 
-	if len(line) > maxLogLine {
-		line = line[:maxLogLine]
+	if max, ok := tagOverrideLevel(ctx); ok {
+		return level.within(max)
+	}
+
+	if l.levels == nil {
+		return level.within(l.maxLevel)
 	}
 
-	if level > l.errorLevel || level == logPrint {
-		l.allLogger.Write(line)
+	max := l.maxLevel
 
-		if isStdoutTerminal {
-			os.Stdout.Write(line)
+	if pc != 0 {
+		if st := l.lookupStack(pc); st.hasLevel {
+			max = st.level
 		}
-	} else {
-		l.allLogger.Write(line)
+	}
+
+	if tagLevel, ok := l.levels.tagLevel(tag(ctx)); ok {
+		max = tagLevel
+	}
+
+	return level.within(max)
+}
+
+// newEntry 创建一条日志，pc 是调用者的位置，如果 pc 为 0 则不记录调用栈。
+func (l *logger) newEntry(ctx context.Context, pc uintptr, level Level, format string, args ...interface{}) *Entry {
+	entry := &Entry{
+		Level: level,
+		Time:  l.now(),
+	}
+
+	if level != logPrint {
+		// 记录调用栈。
+		if pc != 0 {
+			entry.Caller = l.lookupStack(pc).caller
+			entry.pc = pc
+		}
+
+		// 记录 tag 和 ctx 中的各种信息。
+		entry.Tag = tag(ctx)
+		entry.Fields = resolveFields(findMoreInfo(ctx))
 
-		if l.wfLogger != l.allLogger {
-			l.wfLogger.Write(line)
+		if l.stable {
+			entry.Fields = l.stableFields(entry.Fields)
 		}
 
-		if isStderrTerminal {
-			os.Stderr.Write(line)
+		// 服务信息、请求 ID、worker 标签和 goroutine ID 总是放在最前面。
+		id := RequestID(ctx)
+		worker := Worker(ctx)
+
+		if id != "" || worker != "" || l.goroutine || len(l.stamps) != 0 {
+			fields := make([]Info, 0, len(l.stamps)+len(entry.Fields)+3)
+			fields = append(fields, l.stamps...)
+
+			if id != "" {
+				fields = append(fields, Info{Key: RequestIDKey, Value: id})
+			}
+
+			if worker != "" {
+				fields = append(fields, Info{Key: WorkerKey, Value: worker})
+			}
+
+			if l.goroutine {
+				fields = append(fields, Info{Key: GoroutineKey, Value: goroutineID()})
+			}
+
+			entry.Fields = append(fields, entry.Fields...)
 		}
 	}
 
-	if level == LogFatal {
-		l.Flush()
-		panic(panicContext)
+	// 没有格式化参数的时候直接使用 format，避免 fmt 的开销。
+	if len(args) == 0 && strings.IndexByte(format, '%') < 0 {
+		entry.Message = format
+	} else {
+		entry.Message = fmt.Sprintf(format, args...)
 	}
+
+	return entry
+}
+
+// isEnabled 判断调用者输出 level 级别的日志是否会被输出，skip 是调用者相对 isEnabled 调用方的栈深度。
+func (l *logger) isEnabled(ctx context.Context, skip int, level Level) bool {
+	if l.disabled(ctx, level) {
+		return false
+	}
+
+	var pc uintptr
+
+	if l.levels != nil {
+		pc = callerPC(skip + 1)
+	}
+
+	return l.enabled(ctx, pc, level)
+}
+
+// callerPC 返回调用栈上第 skip 层调用者的 pc，在调用者中等价于 runtime.Caller(skip) 返回的 pc，
+// 但是不会分配内存，适合在输出日志的热路径上使用。
+func callerPC(skip int) uintptr {
+	var pcs [1]uintptr
+
+	if runtime.Callers(skip+2, pcs[:]) == 0 {
+		return 0
+	}
+
+	// runtime.Callers 返回的是返回地址，减一之后才是调用指令所在的位置。
+	return pcs[0] - 1
+}
+
+func (l *logger) lookupStack(pc uintptr) stack {
+	if cache, ok := l.pcCache.Load(pc); ok {
+		return cache.(stack)
+	}
+
+	st := l.parsePC(pc)
+	l.pcCache.Store(pc, st)
+	return st
 }
 
 func (l *logger) parsePC(pc uintptr) stack {
 	f := runtime.FuncForPC(pc)
 	file, line := f.FileLine(pc)
-	file = path.Base(file)
 	name := f.Name()
 	prefix := ""
+	pkg := packageName(name)
+	level, hasLevel := l.levels.moduleLevel(pkg)
+
+	switch l.callerPath {
+	case CallerFull:
+	case CallerPackage:
+		file = l.trimPackagePrefix(pkg) + "/" + path.Base(file)
+	default:
+		file = path.Base(file)
+	}
 
 	// 简化日志中的 package 路径，避免输出过多无用信息。
 	if l.pkgPrefix != "" && strings.HasPrefix(name, l.pkgPrefix) {
@@ -334,26 +839,25 @@ func (l *logger) parsePC(pc uintptr) stack {
 		prefix = replaceStdPackagePrefix
 	}
 
-	lineBuf := &bytes.Buffer{}
-	lineBuf.WriteByte('[')
-	lineBuf.WriteString(file)
-	lineBuf.WriteByte(':')
-	lineBuf.WriteString(strconv.Itoa(line))
-	lineBuf.WriteByte('@')
-
-	if prefix != "" {
-		lineBuf.WriteString(prefix)
+	return stack{
+		caller:   file + ":" + strconv.Itoa(line) + "@" + prefix + name,
+		pkg:      pkg,
+		level:    level,
+		hasLevel: hasLevel,
 	}
+}
 
-	lineBuf.WriteString(name)
-	lineBuf.WriteByte(']')
-
-	panicContext := fmt.Sprintf("go-log: log.Fatalf at %v:%v@%v%v", file, line, prefix, name)
+// trimPackagePrefix 用和函数名相同的规则简化 package 路径。
+func (l *logger) trimPackagePrefix(pkg string) string {
+	if l.pkgPrefix != "" && strings.HasPrefix(pkg, l.pkgPrefix) {
+		return pkg[len(l.pkgPrefix):]
+	}
 
-	return stack{
-		line:         lineBuf.Bytes(),
-		panicContext: panicContext,
+	if stdPackagePrefix != "" && strings.HasPrefix(pkg, stdPackagePrefix) {
+		return replaceStdPackagePrefix + pkg[len(stdPackagePrefix):]
 	}
+
+	return pkg
 }
 
 // Rotate 重新打开所有的日志文件，方便做日志切割。
@@ -364,6 +868,12 @@ func (l *logger) Rotate() (err error) {
 		}
 	}
 
+	if r, ok := l.audit.(rotator); ok {
+		if e := r.Rotate(); e != nil {
+			err = e
+		}
+	}
+
 	return
 }
 
@@ -376,20 +886,186 @@ func (l *logger) Flush() (err error) {
 		}
 	}
 
+	if e := l.flushSinks(); e != nil {
+		err = e
+	}
+
 	return
 }
 
+// FlushContext 和 Flush 一样刷新所有缓冲区，但最多等到 ctx 结束，超时返回 ctx.Err()。
+func (l *logger) FlushContext(ctx context.Context) (err error) {
+	for _, w := range l.writers {
+		if e := w.FlushContext(ctx); e != nil {
+			err = e
+		}
+
+		if e := ctx.Err(); e != nil {
+			return e
+		}
+	}
+
+	// Sink 的 Flush 不支持 ctx，只能放在单独的 goroutine 里面等待。
+	done := make(chan error, 1)
+
+	go func() {
+		done <- l.flushSinks()
+	}()
+
+	select {
+	case e := <-done:
+		if e != nil {
+			err = e
+		}
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	return
+}
+
+// flushSinks 刷新所有自带缓冲区的 Sink。
+func (l *logger) flushSinks() (err error) {
+	for _, sink := range l.sinks {
+		if f, ok := sink.(flusher); ok {
+			if e := f.Flush(); e != nil {
+				err = e
+			}
+		}
+	}
+
+	return
+}
+
+// setErrorHandler 让所有 AsyncWriter 在写入失败时调用 config.OnWriteError，并把数据写入备用输出。
+func (l *logger) setErrorHandler(config *Config) {
+	onWriteError := config.OnWriteError
+	var fallback io.Writer
+
+	switch config.Fallback {
+	case FallbackStdout:
+		fallback = os.Stdout
+	case FallbackStderr:
+		fallback = os.Stderr
+	}
+
+	if onWriteError == nil && fallback == nil {
+		return
+	}
+
+	// 使用 tee 时，数据的第一个字节是 mask，需要去掉。
+	skip := 0
+
+	if l.tee != nil {
+		skip = 1
+	}
+
+	handler := func(err error, data []byte) {
+		if len(data) < skip {
+			return
+		}
+
+		line := data[skip:]
+
+		if onWriteError != nil {
+			onWriteError(err, line)
+		}
+
+		if fallback != nil {
+			fallback.Write(line)
+		}
+	}
+
+	for _, w := range l.writers {
+		w.SetErrorHandler(handler)
+	}
+}
+
+// autoFlush 定期刷新缓冲区，保证日志落盘的延迟不超过 interval。
+func (l *logger) autoFlush(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			l.Flush()
+		case <-l.closing:
+			return
+		}
+	}
+}
+
 // Close 关闭所有日志并且确保所有日志可以落盘。
 func (l *logger) Close() (err error) {
+	l.closeOnce.Do(func() {
+		if l.closing != nil {
+			close(l.closing)
+		}
+	})
+
 	for _, w := range l.writers {
+		// 被新的日志实例复用的文件不能关闭。
+		if !releaseWriter(w) {
+			continue
+		}
+
 		if e := w.Close(); e != nil {
 			err = e
 		}
 	}
 
+	if l.audit != nil {
+		if e := l.audit.Close(); e != nil {
+			err = e
+		}
+	}
+
+	for _, sink := range l.sinks {
+		if e := sink.Close(); e != nil {
+			err = e
+		}
+	}
+
 	return
 }
 
+// drain 等待正在输出的日志写完，最多等待 timeout。
+func (l *logger) drain(timeout time.Duration) {
+	deadline := time.Now().Add(timeout)
+
+	for atomic.LoadInt64(&l.inflight) > 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// Stats 返回所有缓冲区的使用情况。
+func (l *logger) Stats() Statistics {
+	stats := Statistics{
+		Writers: make([]WriterStats, 0, len(l.writers)),
+	}
+
+	for _, w := range l.writers {
+		peakLen, peakBytes := w.Peak()
+		stats.Writers = append(stats.Writers, WriterStats{
+			Len:       w.Len(),
+			Cap:       w.Cap(),
+			Bytes:     w.Bytes(),
+			MaxBytes:  w.MaxBytes(),
+			PeakLen:   peakLen,
+			PeakBytes: peakBytes,
+		})
+	}
+
+	for _, sink := range l.sinks {
+		if s, ok := sink.(SinkStatser); ok {
+			stats.Sinks = append(stats.Sinks, s.SinkStats()...)
+		}
+	}
+
+	return stats
+}
+
 type dummyCloser struct {
 	io.Writer
 }
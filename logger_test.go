@@ -33,12 +33,13 @@ func doLog(t *testing.T) {
 
 func TestLogger(t *testing.T) {
 	now := "2019-07-03T12:34:56.789+08:00"
-	fakeNow, _ = time.Parse(logTimeFormat, now)
+	fakeNow, _ := time.Parse(logTimeFormat, now)
+	SetClock(func() time.Time { return fakeNow })
 	os.Remove(DefaultLogPath)
 	os.Remove(DefaultErrorLogPath)
 
 	defer func() {
-		fakeNow = time.Time{}
+		SetClock(nil)
 	}()
 
 	pkgPath := reflect.TypeOf(Config{}).PkgPath()
@@ -115,3 +116,47 @@ func BenchmarkWriteLog(b *testing.B) {
 	b.StopTimer()
 	Flush()
 }
+
+func TestOnFatal(t *testing.T) {
+	var fatal *Entry
+	l := newLogger(&Config{
+		Output: OutputStdout,
+		OnFatal: func(entry *Entry) {
+			fatal = entry
+		},
+	})
+	defer l.Close()
+
+	l.Fatalf(context.Background(), "fatal %v", 1)
+
+	if fatal == nil {
+		t.Fatalf("OnFatal should be called.")
+	}
+
+	if fatal.Message != "fatal 1" {
+		t.Fatalf("invalid fatal message. [message:%v]", fatal.Message)
+	}
+}
+
+// newTestLogger 创建一个把所有日志记录在内存中的 logger，日志使用文本格式。
+func newTestLogger(config *Config) (l *logger, lines *[]string) {
+	lines = &[]string{}
+	config.Output = OutputStdout
+
+	if config.Format == "" {
+		config.Format = FormatText
+	}
+
+	l = newLogger(config)
+	l.routes = []route{allRoute(writerFunc(func(data []byte) (int, error) {
+		*lines = append(*lines, string(data))
+		return len(data), nil
+	}))}
+	return
+}
+
+type writerFunc func(data []byte) (int, error)
+
+func (f writerFunc) Write(data []byte) (int, error) {
+	return f(data)
+}
@@ -0,0 +1,10 @@
+module github.com/altstory/go-log/loggin
+
+go 1.12
+
+require (
+	github.com/altstory/go-log v0.0.0
+	github.com/gin-gonic/gin v1.6.3
+)
+
+replace github.com/altstory/go-log => ../
@@ -0,0 +1,84 @@
+// Package loggin 让 gin 使用 go-log 输出访问日志和 panic 日志。
+//
+// 典型用法：
+//
+//	loggin.ReplaceDefaultWriters()
+//	r := gin.New()
+//	r.Use(loggin.Middleware())
+//
+// loggin 是一个独立的 module，需要单独 go get github.com/altstory/go-log/loggin，
+// 这样只使用 go-log 的项目不会依赖 gin。
+package loggin
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"time"
+
+	log "github.com/altstory/go-log"
+	"github.com/gin-gonic/gin"
+)
+
+// Middleware 返回一个 gin 中间件，为每个请求输出一条 Trace 级别的访问日志，
+// 并把请求 ID 存入 c.Request 的 context，handler 中应该用 c.Request.Context() 输出日志。
+//
+// opts 会传给 log.ExtractHTTP，和 loghttp.Middleware 一样，只有请求全部来自可信的内部服务时
+// 才应该使用 log.TrustUpstream 和 log.AllowForceDebug。
+//
+// 如果 handler 发生 panic，中间件会输出一条带调用栈的 Error 日志并返回 500，可以代替 gin.Recovery。
+func Middleware(opts ...log.ExtractOption) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		r := c.Request
+		ctx := log.WithRequestID(log.ExtractHTTP(r.Context(), r.Header, opts...))
+		c.Request = r.WithContext(ctx)
+		log.InjectRequestID(ctx, c.Writer.Header())
+
+		if recovered := log.CapturePanic(ctx, c.Next); recovered != nil {
+			c.AbortWithStatus(http.StatusInternalServerError)
+		}
+
+		// handler 可能用 log.WithMoreInfo 等替换了 c.Request，访问日志也要带上这些信息。
+		log.Tracef(log.WithMoreInfo(c.Request.Context(),
+			log.String("method", r.Method),
+			log.String("path", r.URL.Path),
+			log.String("route", c.FullPath()),
+			log.Int("status", c.Writer.Status()),
+			log.Duration("latency", time.Since(start)),
+			log.Int("bytes", c.Writer.Size()),
+			log.String("remote_addr", c.ClientIP()),
+		), "http request")
+	}
+}
+
+// ReplaceDefaultWriters 把 gin 默认输出到 stdout 和 stderr 的日志改为用 go-log 输出，
+// gin.DefaultWriter 使用 Info 级别，gin.DefaultErrorWriter 使用 Error 级别。
+func ReplaceDefaultWriters() {
+	gin.DefaultWriter = &writer{level: log.LogInfo}
+	gin.DefaultErrorWriter = &writer{level: log.LogError}
+}
+
+// writer 把每次写入的内容作为一条日志输出。
+type writer struct {
+	level log.Level
+}
+
+func (w *writer) Write(data []byte) (int, error) {
+	msg := string(bytes.TrimSpace(data))
+
+	if msg == "" {
+		return len(data), nil
+	}
+
+	ctx := context.Background()
+
+	switch w.level {
+	case log.LogError:
+		log.Errorf(ctx, "%v", msg)
+	default:
+		log.Infof(ctx, "%v", msg)
+	}
+
+	return len(data), nil
+}
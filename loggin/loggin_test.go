@@ -0,0 +1,94 @@
+package loggin
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	log "github.com/altstory/go-log"
+	"github.com/altstory/go-log/loghttp"
+	"github.com/altstory/go-log/logtest"
+	"github.com/gin-gonic/gin"
+)
+
+func TestMiddleware(t *testing.T) {
+	rec, restore := logtest.Install()
+	defer restore()
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(Middleware())
+	r.GET("/users/:id", func(c *gin.Context) {
+		log.Infof(c.Request.Context(), "in handler")
+		c.String(http.StatusOK, "ok")
+	})
+	r.GET("/panic", func(c *gin.Context) {
+		panic("boom")
+	})
+
+	req := httptest.NewRequest("GET", "/users/1", nil)
+	req.Header.Set(loghttp.RequestIDHeader, "req-1")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	entries := rec.Find(log.LogInfo, "in handler")
+
+	if len(entries) != 1 || fieldValue(entries[0], log.RequestIDKey) != "req-1" {
+		t.Fatalf("request id should be set. [entries:%v]", entries)
+	}
+
+	rec.AssertContains(t, log.LogTrace, "http request")
+
+	resp := httptest.NewRecorder()
+	r.ServeHTTP(resp, httptest.NewRequest("GET", "/panic", nil))
+
+	if resp.Code != http.StatusInternalServerError {
+		t.Fatalf("panic should be mapped to 500. [code:%v]", resp.Code)
+	}
+
+	rec.AssertContains(t, log.LogError, "panic: boom")
+}
+
+func TestMiddlewareTrustUpstream(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var ctx context.Context
+	handler := func(c *gin.Context) {
+		ctx = c.Request.Context()
+	}
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(log.TagHeader, "forged")
+	req.Header.Set(log.FieldsHeader, "uid=1")
+
+	r := gin.New()
+	r.Use(Middleware())
+	r.GET("/", handler)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	header := http.Header{}
+	log.InjectHTTP(ctx, header)
+
+	if header.Get(log.TagHeader) != "" || header.Get(log.FieldsHeader) != "" {
+		t.Fatalf("untrusted tag and fields must be ignored. [header:%v]", header)
+	}
+
+	r = gin.New()
+	r.Use(Middleware(log.TrustUpstream()))
+	r.GET("/", handler)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	header = http.Header{}
+	log.InjectHTTP(ctx, header)
+
+	if header.Get(log.TagHeader) != "forged" || header.Get(log.FieldsHeader) != "uid=1" {
+		t.Fatalf("trusted tag and fields must be restored. [header:%v]", header)
+	}
+}
+
+func fieldValue(entry log.Entry, key string) interface{} {
+	for _, f := range entry.Fields {
+		if f.Key == key {
+			return f.Value
+		}
+	}
+
+	return nil
+}
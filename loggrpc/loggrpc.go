@@ -0,0 +1,296 @@
+// Package loggrpc 提供输出 gRPC 访问日志的拦截器。
+//
+// 服务端拦截器为每个 RPC 输出一条 Trace 级别的日志，包含 method、code、latency 和 peer，
+// 并且把请求 ID 存入 context，handler 中输出的日志都会带上相同的请求 ID。
+// 客户端传递的 tag 和字段只有在设置了 TrustUpstream 时才会存入 context，
+// 强制调试标记只有在设置了 AllowForceDebug 时才会生效。
+// 客户端拦截器输出同样格式的日志，并且把 context 中的请求 ID 传给服务端。
+//
+// loggrpc 是一个独立的 module，需要单独 go get github.com/altstory/go-log/loggrpc，
+// 这样只使用 go-log 的项目不会依赖 gRPC。
+package loggrpc
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+	"unicode/utf8"
+
+	log "github.com/altstory/go-log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// 传递日志上下文的 metadata key。
+const (
+	RequestIDKey = "x-request-id" // RequestIDKey 是传递请求 ID 的 metadata key。
+	TagKey       = "x-log-tag"    // TagKey 是传递 tag 的 metadata key。
+	FieldsKey    = "x-log-fields" // FieldsKey 是传递 WithMoreInfo 字段的 metadata key，格式和 log.FieldsHeader 一样。
+	DebugKey     = "x-log-debug"  // DebugKey 是传递 log.WithForceDebug 标记的 metadata key。
+)
+
+// metadataHeaders 是 metadata key 和 log 包中 HTTP header 的对应关系。
+var metadataHeaders = map[string]string{
+	RequestIDKey: log.RequestIDHeader,
+	TagKey:       log.TagHeader,
+	FieldsKey:    log.FieldsHeader,
+	DebugKey:     log.ForceDebugHeader,
+}
+
+// DefaultPayloadLimit 是 WithPayload 默认输出的最大长度。
+const DefaultPayloadLimit = 1024
+
+// Option 是拦截器的配置项。
+type Option func(opts *options)
+
+type options struct {
+	payload      bool
+	payloadLimit int
+	extract      []log.ExtractOption
+}
+
+// TrustUpstream 让服务端拦截器恢复客户端通过 TagKey 和 FieldsKey 传递的 tag 和字段，
+// 只有客户端全部是可信的内部服务时才能使用，详见 log.TrustUpstream。
+func TrustUpstream() Option {
+	return func(opts *options) {
+		opts.extract = append(opts.extract, log.TrustUpstream())
+	}
+}
+
+// WithPayload 让拦截器在 Debug 级别输出请求和响应的内容，超过 limit 字节的部分会被截断，
+// limit 不大于 0 时使用 DefaultPayloadLimit。
+func WithPayload(limit int) Option {
+	return func(opts *options) {
+		if limit <= 0 {
+			limit = DefaultPayloadLimit
+		}
+
+		opts.payload = true
+		opts.payloadLimit = limit
+	}
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{}
+
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return o
+}
+
+// UnaryServerInterceptor 返回输出访问日志的服务端 unary 拦截器。
+func UnaryServerInterceptor(opts ...Option) grpc.UnaryServerInterceptor {
+	o := newOptions(opts)
+
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		ctx = serverContext(ctx, o)
+		o.logPayload(ctx, "request", req)
+		resp, err := handler(ctx, req)
+
+		if err == nil {
+			o.logPayload(ctx, "response", resp)
+		}
+
+		logRPC(ctx, info.FullMethod, peerAddr(ctx), start, err)
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor 返回输出访问日志的服务端 stream 拦截器。
+func StreamServerInterceptor(opts ...Option) grpc.StreamServerInterceptor {
+	o := newOptions(opts)
+
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		ctx := serverContext(ss.Context(), o)
+		err := handler(srv, &serverStream{
+			ServerStream: ss,
+			ctx:          ctx,
+			opts:         o,
+		})
+		logRPC(ctx, info.FullMethod, peerAddr(ctx), start, err)
+		return err
+	}
+}
+
+// UnaryClientInterceptor 返回输出访问日志的客户端 unary 拦截器。
+func UnaryClientInterceptor(opts ...Option) grpc.UnaryClientInterceptor {
+	o := newOptions(opts)
+
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, callOpts ...grpc.CallOption) error {
+		start := time.Now()
+		ctx = clientContext(ctx)
+		o.logPayload(ctx, "request", req)
+		err := invoker(ctx, method, req, reply, cc, callOpts...)
+
+		if err == nil {
+			o.logPayload(ctx, "response", reply)
+		}
+
+		logRPC(ctx, method, cc.Target(), start, err)
+		return err
+	}
+}
+
+// StreamClientInterceptor 返回输出访问日志的客户端 stream 拦截器，日志在创建 stream 之后输出。
+func StreamClientInterceptor(opts ...Option) grpc.StreamClientInterceptor {
+	o := newOptions(opts)
+
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, callOpts ...grpc.CallOption) (grpc.ClientStream, error) {
+		start := time.Now()
+		ctx = clientContext(ctx)
+		cs, err := streamer(ctx, desc, cc, method, callOpts...)
+		logRPC(ctx, method, cc.Target(), start, err)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return &clientStream{
+			ClientStream: cs,
+			ctx:          ctx,
+			opts:         o,
+		}, nil
+	}
+}
+
+// AllowForceDebug 让服务端拦截器接受客户端通过 DebugKey 传递的强制调试标记，
+// 只有客户端全部是可信的内部服务时才能使用，详见 log.AllowForceDebug。
+func AllowForceDebug() Option {
+	return func(opts *options) {
+		opts.extract = append(opts.extract, log.AllowForceDebug())
+	}
+}
+
+// serverContext 从 metadata 中读取请求 ID，没有请求 ID 时生成一个新的，
+// 设置了 TrustUpstream 时同时读取 tag 和字段。
+func serverContext(ctx context.Context, o *options) context.Context {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		header := http.Header{}
+
+		for key, name := range metadataHeaders {
+			if values := md.Get(key); len(values) > 0 {
+				header.Set(name, values[0])
+			}
+		}
+
+		ctx = log.ExtractHTTP(ctx, header, o.extract...)
+	}
+
+	return log.WithRequestID(ctx)
+}
+
+// clientContext 把 context 中已有的请求 ID、tag 和字段传给服务端。
+func clientContext(ctx context.Context) context.Context {
+	header := http.Header{}
+	log.InjectHTTP(ctx, header)
+	md, _ := metadata.FromOutgoingContext(ctx)
+	md = md.Copy()
+
+	// ctx 里面可能已经有上一次调用设置的 metadata，需要覆盖而不是追加，否则服务端会收到重复的值。
+	for key, name := range metadataHeaders {
+		if value := header.Get(name); value != "" {
+			md.Set(key, value)
+		}
+	}
+
+	return metadata.NewOutgoingContext(ctx, md)
+}
+
+func peerAddr(ctx context.Context) string {
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		return p.Addr.String()
+	}
+
+	return ""
+}
+
+func logRPC(ctx context.Context, method, peer string, start time.Time, err error) {
+	info := []log.Info{
+		log.String("method", method),
+		log.String("code", status.Code(err).String()),
+		log.Duration("latency", time.Since(start)),
+		log.String("peer", peer),
+	}
+
+	if err != nil {
+		info = append(info, log.Any("err", err))
+	}
+
+	log.Tracef(log.WithMoreInfo(ctx, info...), "grpc request")
+}
+
+func (o *options) logPayload(ctx context.Context, kind string, payload interface{}) {
+	if !o.payload || !log.DebugEnabled(ctx) {
+		return
+	}
+
+	s := fmt.Sprintf("%v", payload)
+
+	if len(s) > o.payloadLimit {
+		// 在字符边界截断，避免输出不完整的 UTF-8 字符。
+		n := o.payloadLimit
+
+		for n > 0 && !utf8.RuneStart(s[n]) {
+			n--
+		}
+
+		s = s[:n] + "..."
+	}
+
+	log.Debugf(ctx, "grpc %v: %v", kind, s)
+}
+
+type serverStream struct {
+	grpc.ServerStream
+
+	ctx  context.Context
+	opts *options
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *serverStream) RecvMsg(m interface{}) error {
+	err := s.ServerStream.RecvMsg(m)
+
+	if err == nil {
+		s.opts.logPayload(s.ctx, "request", m)
+	}
+
+	return err
+}
+
+func (s *serverStream) SendMsg(m interface{}) error {
+	s.opts.logPayload(s.ctx, "response", m)
+	return s.ServerStream.SendMsg(m)
+}
+
+type clientStream struct {
+	grpc.ClientStream
+
+	ctx  context.Context
+	opts *options
+}
+
+func (s *clientStream) SendMsg(m interface{}) error {
+	s.opts.logPayload(s.ctx, "request", m)
+	return s.ClientStream.SendMsg(m)
+}
+
+func (s *clientStream) RecvMsg(m interface{}) error {
+	err := s.ClientStream.RecvMsg(m)
+
+	if err == nil {
+		s.opts.logPayload(s.ctx, "response", m)
+	}
+
+	return err
+}
@@ -0,0 +1,116 @@
+package loggrpc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"unicode/utf8"
+
+	log "github.com/altstory/go-log"
+	"github.com/altstory/go-log/logtest"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	rec, restore := logtest.Install()
+	defer restore()
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(RequestIDKey, "req-1"))
+	info := &grpc.UnaryServerInfo{FullMethod: "/pkg.Service/Method"}
+	interceptor := UnaryServerInterceptor(WithPayload(8))
+	_, err := interceptor(ctx, "0123456789", info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		log.Infof(ctx, "in handler")
+		return nil, errors.New("failed")
+	})
+
+	if err == nil {
+		t.Fatalf("handler error should be returned.")
+	}
+
+	rec.AssertContains(t, log.LogDebug, "grpc request: 01234567...")
+	entries := rec.Find(log.LogInfo, "in handler")
+
+	if len(entries) != 1 || fieldValue(entries[0], log.RequestIDKey) != "req-1" {
+		t.Fatalf("request id should be set. [entries:%v]", entries)
+	}
+
+	entries = rec.Find(log.LogTrace, "grpc request")
+
+	if len(entries) != 1 {
+		t.Fatalf("there should be one access log. [entries:%v]", rec.Entries())
+	}
+
+	fields := map[string]interface{}{}
+
+	for _, f := range entries[0].Fields {
+		fields[f.Key] = f.Value
+	}
+
+	if fields["method"] != info.FullMethod || fields["code"] != "Unknown" {
+		t.Fatalf("invalid access log fields. [fields:%v]", fields)
+	}
+}
+
+func fieldValue(entry log.Entry, key string) interface{} {
+	for _, f := range entry.Fields {
+		if f.Key == key {
+			return f.Value
+		}
+	}
+
+	return nil
+}
+
+func TestPropagateContext(t *testing.T) {
+	ctx := log.SetRequestID(context.Background(), "req-2")
+	ctx = log.WithTag(ctx, "checkout")
+	ctx = log.WithMoreInfo(ctx, log.String("uid", "u 1"))
+	ctx = clientContext(ctx)
+
+	// 同一个 ctx 多次调用下游时不能重复追加 metadata。
+	ctx = clientContext(ctx)
+	md, _ := metadata.FromOutgoingContext(ctx)
+
+	if ids := md.Get(RequestIDKey); len(ids) != 1 {
+		t.Fatalf("request id must not be duplicated. [ids:%v]", ids)
+	}
+	ctx = serverContext(metadata.NewIncomingContext(context.Background(), md), newOptions([]Option{TrustUpstream()}))
+
+	if id := log.RequestID(ctx); id != "req-2" {
+		t.Fatalf("request id should be propagated. [id:%v]", id)
+	}
+
+	header := http.Header{}
+	log.InjectHTTP(ctx, header)
+
+	if tag, fields := header.Get(log.TagHeader), header.Get(log.FieldsHeader); tag != "checkout" || fields != "uid=u+1" {
+		t.Fatalf("tag and fields should be propagated. [tag:%v] [fields:%v]", tag, fields)
+	}
+
+	ctx = serverContext(metadata.NewIncomingContext(context.Background(), md), newOptions(nil))
+	header = http.Header{}
+	log.InjectHTTP(ctx, header)
+
+	if tag, fields := header.Get(log.TagHeader), header.Get(log.FieldsHeader); tag != "" || fields != "" {
+		t.Fatalf("tag and fields must not be propagated without TrustUpstream. [tag:%v] [fields:%v]", tag, fields)
+	}
+}
+
+func TestLogPayloadTruncate(t *testing.T) {
+	rec, restore := logtest.Install()
+	defer restore()
+
+	o := newOptions([]Option{WithPayload(4)})
+	o.logPayload(log.WithForceDebug(context.Background()), "request", "中文内容")
+
+	// "中" 占 3 个字节，第 4 个字节在 "文" 的中间，需要退回到字符边界。
+	rec.AssertContains(t, log.LogDebug, "grpc request: 中...")
+
+	for _, entry := range rec.Entries() {
+		if !utf8.ValidString(entry.Message) {
+			t.Fatalf("payload must be truncated at rune boundary. [msg:%q]", entry.Message)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+// Package loghttp 提供 net/http 的访问日志中间件。
+package loghttp
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"time"
+
+	log "github.com/altstory/go-log"
+)
+
+// RequestIDHeader 是传递请求 ID 的 HTTP header。
+const RequestIDHeader = log.RequestIDHeader
+
+// Middleware 为每个请求输出一条 Trace 级别的访问日志，
+// 日志中包含请求的 method、path、status、latency、bytes 和 remote_addr。
+//
+// 如果请求的 RequestIDHeader 不为空就用它作为请求 ID，否则生成一个新的请求 ID，
+// 请求 ID 会存入请求的 context，handler 中用这个 context 输出的日志都会带上相同的请求 ID，
+// 同时也会写入响应的 RequestIDHeader。
+//
+// opts 会传给 log.ExtractHTTP，只有请求全部来自可信的内部服务时才应该使用 log.TrustUpstream
+// 和 log.AllowForceDebug，让上游通过 log.InjectHTTP 传递的 tag、字段和强制调试标记也存入 context。
+func Middleware(next http.Handler, opts ...log.ExtractOption) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ctx := log.WithRequestID(log.ExtractHTTP(r.Context(), r.Header, opts...))
+		log.InjectRequestID(ctx, w.Header())
+		rw := &responseWriter{
+			ResponseWriter: w,
+			status:         http.StatusOK,
+		}
+		var hw http.ResponseWriter = rw
+
+		if _, ok := w.(http.Hijacker); ok {
+			hw = hijackResponseWriter{rw}
+		}
+
+		next.ServeHTTP(hw, r.WithContext(ctx))
+
+		log.Tracef(log.WithMoreInfo(ctx,
+			log.String("method", r.Method),
+			log.String("path", r.URL.Path),
+			log.Int("status", rw.status),
+			log.Duration("latency", time.Since(start)),
+			log.Int64("bytes", rw.bytes),
+			log.String("remote_addr", r.RemoteAddr),
+		), "http request")
+	})
+}
+
+// responseWriter 记录响应的状态码和长度。
+type responseWriter struct {
+	http.ResponseWriter
+
+	status      int
+	bytes       int64
+	wroteHeader bool
+}
+
+func (w *responseWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status = status
+		w.wroteHeader = true
+	}
+
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *responseWriter) Write(data []byte) (int, error) {
+	w.wroteHeader = true
+	n, err := w.ResponseWriter.Write(data)
+	w.bytes += int64(n)
+	return n, err
+}
+
+// Flush 实现 http.Flusher，让 handler 依然可以使用流式响应。
+func (w *responseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// hijackResponseWriter 只在底层 ResponseWriter 实现了 http.Hijacker 时使用，
+// 让 handler 依然可以使用 websocket 等协议，同时不会让 HTTP/2 等不支持的 ResponseWriter 误以为自己支持。
+type hijackResponseWriter struct {
+	*responseWriter
+}
+
+// Hijack 实现 http.Hijacker。
+func (w hijackResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return w.ResponseWriter.(http.Hijacker).Hijack()
+}
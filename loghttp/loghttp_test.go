@@ -0,0 +1,129 @@
+package loghttp
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	log "github.com/altstory/go-log"
+	"github.com/altstory/go-log/logtest"
+)
+
+func TestMiddleware(t *testing.T) {
+	rec, restore := logtest.Install()
+	defer restore()
+
+	var ctx context.Context
+	h := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx = r.Context()
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("not found"))
+	}))
+
+	req := httptest.NewRequest("GET", "/users/1", nil)
+	req.Header.Set(RequestIDHeader, "req-1")
+	resp := httptest.NewRecorder()
+	h.ServeHTTP(resp, req)
+
+	if id := resp.Header().Get(RequestIDHeader); id != "req-1" {
+		t.Fatalf("request id should be written to response. [id:%v]", id)
+	}
+
+	entries := rec.Entries()
+
+	if len(entries) != 1 {
+		t.Fatalf("there should be one access log. [entries:%v]", entries)
+	}
+
+	entry := entries[0]
+
+	if entry.Level != log.LogTrace || fieldValue(entry, log.RequestIDKey) != "req-1" {
+		t.Fatalf("invalid access log. [entry:%v]", entry)
+	}
+
+	fields := map[string]interface{}{}
+
+	for _, f := range entry.Fields {
+		fields[f.Key] = f.Value
+	}
+
+	if fields["method"] != "GET" || fields["path"] != "/users/1" || fields["status"] != http.StatusNotFound || fields["bytes"] != int64(9) {
+		t.Fatalf("invalid access log fields. [fields:%v]", fields)
+	}
+
+	if id := log.RequestID(ctx); id != "req-1" {
+		t.Fatalf("request id should be stored in context. [id:%v]", id)
+	}
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+	if entries = rec.Entries(); len(entries) != 2 || fieldValue(entries[1], log.RequestIDKey) == nil {
+		t.Fatalf("request id should be generated. [entries:%v]", entries)
+	}
+}
+
+func fieldValue(entry log.Entry, key string) interface{} {
+	for _, f := range entry.Fields {
+		if f.Key == key {
+			return f.Value
+		}
+	}
+
+	return nil
+}
+
+func TestMiddlewareTrustUpstream(t *testing.T) {
+	var ctx context.Context
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx = r.Context()
+	})
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(log.TagHeader, "forged")
+	req.Header.Set(log.FieldsHeader, "uid=1")
+
+	Middleware(handler).ServeHTTP(httptest.NewRecorder(), req)
+	header := http.Header{}
+	log.InjectHTTP(ctx, header)
+
+	if header.Get(log.TagHeader) != "" || header.Get(log.FieldsHeader) != "" {
+		t.Fatalf("untrusted tag and fields must be ignored. [header:%v]", header)
+	}
+
+	Middleware(handler, log.TrustUpstream()).ServeHTTP(httptest.NewRecorder(), req)
+	header = http.Header{}
+	log.InjectHTTP(ctx, header)
+
+	if header.Get(log.TagHeader) != "forged" || header.Get(log.FieldsHeader) != "uid=1" {
+		t.Fatalf("trusted tag and fields must be restored. [header:%v]", header)
+	}
+}
+
+type hijackRecorder struct {
+	*httptest.ResponseRecorder
+}
+
+func (hijackRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return nil, nil, nil
+}
+
+func TestMiddlewareHijacker(t *testing.T) {
+	var hijackable bool
+	handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, hijackable = w.(http.Hijacker)
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+	if hijackable {
+		t.Fatalf("writer must not implement http.Hijacker if the underlying writer does not.")
+	}
+
+	handler.ServeHTTP(hijackRecorder{httptest.NewRecorder()}, httptest.NewRequest("GET", "/", nil))
+
+	if !hijackable {
+		t.Fatalf("writer must implement http.Hijacker if the underlying writer does.")
+	}
+}
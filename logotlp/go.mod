@@ -0,0 +1,10 @@
+module github.com/altstory/go-log/logotlp
+
+go 1.12
+
+require (
+	github.com/altstory/go-log v0.0.0
+	google.golang.org/grpc v1.30.0
+)
+
+replace github.com/altstory/go-log => ../
@@ -0,0 +1,484 @@
+// Package logotlp 提供一个 OTLP 日志导出 Sink，将日志以 OpenTelemetry LogRecord 的形式发送给 collector，
+// 让日志和 trace、metrics 进入同一个 OTel 数据管道。
+//
+// 每条日志的级别转换成 severity，消息转换成 body，tag、调用者和字段转换成 attributes，
+// 字段中的 TraceIDKey 和 SpanIDKey 会被当做 trace context：
+//
+//	exporter, err := logotlp.New(&logotlp.Config{
+//		Endpoint:    "otel-collector:4317",
+//		Insecure:    true,
+//		ServiceName: "my-service",
+//	})
+//
+//	if err != nil {
+//		// 处理错误。
+//	}
+//
+//	log.Init(&log.Config{
+//		Sinks: []log.Sink{exporter},
+//	})
+//
+//	ctx = log.WithMoreInfo(ctx, log.Info{Key: logotlp.TraceIDKey, Value: traceID}, log.Info{Key: logotlp.SpanIDKey, Value: spanID})
+//
+// logotlp 是一个独立的 module，需要单独 go get github.com/altstory/go-log/logotlp，
+// 这样只使用 go-log 的项目不会依赖 gRPC。
+package logotlp
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/altstory/go-log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+)
+
+// 支持的传输协议。
+const (
+	ProtocolGRPC = "grpc" // ProtocolGRPC 使用 OTLP/gRPC 协议。
+	ProtocolHTTP = "http" // ProtocolHTTP 使用 OTLP/HTTP 协议，请求体是 protobuf。
+)
+
+// 字段中表示 trace context 的 key，值是十六进制字符串。
+const (
+	TraceIDKey = "trace_id" // TraceIDKey 的值是 32 个字符的 trace id。
+	SpanIDKey  = "span_id"  // SpanIDKey 的值是 16 个字符的 span id。
+)
+
+// 默认配置。
+const (
+	DefaultGRPCEndpoint  = "localhost:4317"
+	DefaultHTTPEndpoint  = "http://localhost:4318/v1/logs"
+	DefaultBatchSize     = 512
+	DefaultFlushInterval = time.Second
+	DefaultTimeout       = 10 * time.Second
+)
+
+const exportMethod = "/opentelemetry.proto.collector.logs.v1.LogsService/Export"
+
+// Config 是 Exporter 的配置。
+type Config struct {
+	Protocol string            `config:"protocol"` // Protocol 是传输协议，可以是 ProtocolGRPC 或 ProtocolHTTP，默认是 ProtocolGRPC。
+	Endpoint string            `config:"endpoint"` // Endpoint 是 collector 地址，gRPC 默认是 DefaultGRPCEndpoint，HTTP 需要写完整的 URL，默认是 DefaultHTTPEndpoint。
+	Insecure bool              `config:"insecure"` // Insecure 让 gRPC 不使用 TLS。
+	TLS      *tls.Config       `config:"-"`        // TLS 是 gRPC 和 HTTPS 使用的 TLS 配置。
+	Headers  map[string]string `config:"headers"`  // Headers 是每个请求额外携带的 header 或者 metadata，例如鉴权信息。
+
+	ServiceName string            `config:"service_name"` // ServiceName 是 resource 中的 service.name。
+	Resource    map[string]string `config:"resource"`     // Resource 是 resource 中额外的 attributes。
+
+	BatchSize     int           `config:"batch_size"`     // BatchSize 是每个请求最多包含的日志条数，默认是 DefaultBatchSize。
+	FlushInterval time.Duration `config:"flush_interval"` // FlushInterval 是发送不满一批的日志的间隔，默认是 DefaultFlushInterval。
+	BufferedLines int           `config:"buffered_lines"` // BufferedLines 是缓冲区大小，默认是 log.DefaultBufferedLines，缓冲区满时新的日志会被丢弃。
+	Timeout       time.Duration `config:"timeout"`        // Timeout 是每个请求的超时时间，默认是 DefaultTimeout。
+}
+
+// Exporter 是一个 log.Sink，日志先放入缓冲区，由单独的 goroutine 按批发送，
+// 任何情况下都不会阻塞输出日志的 goroutine。
+type Exporter struct {
+	resource  []byte
+	batchSize int
+	interval  time.Duration
+	timeout   time.Duration
+	transport transport
+
+	ch   chan item
+	done chan bool
+
+	mu     sync.RWMutex // mu 保证 ch 关闭之后不会再写入。
+	closed bool
+
+	statsMu             sync.Mutex
+	consecutiveFailures int
+	failures            int64
+	dropped             int64
+	lastError           error
+	lastErrorTime       time.Time
+}
+
+var _ log.Sink = new(Exporter)
+var _ log.SinkStatser = new(Exporter)
+
+// item 是缓冲区中的一条日志，如果 flushed 不为 nil，表示这是一个刷新请求。
+type item struct {
+	entry   *log.Entry
+	flushed chan bool
+}
+
+// transport 将编码好的请求发送给 collector。
+type transport interface {
+	io.Closer
+	export(ctx context.Context, data []byte) error
+}
+
+var errExporterFull = errors.New("logotlp: exporter buffer is full")
+
+// New 创建一个 Exporter，gRPC 连接会在后台建立，不会等待连接成功。
+func New(config *Config) (*Exporter, error) {
+	if config == nil {
+		config = &Config{}
+	}
+
+	var t transport
+	var err error
+
+	switch config.Protocol {
+	case "", ProtocolGRPC:
+		t, err = newGRPCTransport(config)
+	case ProtocolHTTP:
+		t = newHTTPTransport(config)
+	default:
+		err = fmt.Errorf("logotlp: unknown protocol %q", config.Protocol)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return newExporter(config, t), nil
+}
+
+func newExporter(config *Config, t transport) *Exporter {
+	attributes := map[string]string{}
+
+	for k, v := range config.Resource {
+		attributes[k] = v
+	}
+
+	if config.ServiceName != "" {
+		attributes["service.name"] = config.ServiceName
+	}
+
+	e := &Exporter{
+		resource:  encodeResource(attributes),
+		batchSize: config.BatchSize,
+		interval:  config.FlushInterval,
+		timeout:   config.Timeout,
+		transport: t,
+		done:      make(chan bool),
+	}
+
+	if e.batchSize <= 0 {
+		e.batchSize = DefaultBatchSize
+	}
+
+	if e.interval <= 0 {
+		e.interval = DefaultFlushInterval
+	}
+
+	if e.timeout <= 0 {
+		e.timeout = DefaultTimeout
+	}
+
+	size := config.BufferedLines
+
+	if size <= 0 {
+		size = log.DefaultBufferedLines
+	}
+
+	e.ch = make(chan item, size)
+	go e.run()
+	return e
+}
+
+// Write 将 entry 复制一份放入缓冲区，缓冲区满时丢弃这条日志并返回错误。
+func (e *Exporter) Write(entry *log.Entry) error {
+	cp := *entry
+	cp.Fields = append([]log.Info(nil), entry.Fields...)
+
+	if !e.send(item{entry: &cp}, false) {
+		e.statsMu.Lock()
+		e.dropped++
+		e.statsMu.Unlock()
+		return errExporterFull
+	}
+
+	return nil
+}
+
+// Flush 发送缓冲区中所有的日志，等待发送完成后返回最近一次发送的错误。
+func (e *Exporter) Flush() error {
+	flushed := make(chan bool, 1)
+
+	if !e.send(item{flushed: flushed}, true) {
+		return nil
+	}
+
+	<-flushed
+
+	e.statsMu.Lock()
+	defer e.statsMu.Unlock()
+
+	if e.consecutiveFailures > 0 {
+		return e.lastError
+	}
+
+	return nil
+}
+
+// Close 发送缓冲区中所有的日志，然后关闭连接，多次调用是安全的。
+func (e *Exporter) Close() error {
+	e.mu.Lock()
+
+	if e.closed {
+		e.mu.Unlock()
+		return nil
+	}
+
+	e.closed = true
+	close(e.ch)
+	e.mu.Unlock()
+
+	<-e.done
+	return e.transport.Close()
+}
+
+// SinkStats 返回 Exporter 的健康状况。
+func (e *Exporter) SinkStats() []log.SinkStats {
+	e.statsMu.Lock()
+	defer e.statsMu.Unlock()
+
+	return []log.SinkStats{{
+		Name:                fmt.Sprintf("%T", e),
+		Len:                 len(e.ch),
+		Cap:                 cap(e.ch),
+		Healthy:             e.consecutiveFailures == 0,
+		Connected:           e.consecutiveFailures == 0,
+		ConsecutiveFailures: e.consecutiveFailures,
+		Failures:            e.failures,
+		Dropped:             e.dropped,
+		LastError:           e.lastError,
+		LastErrorTime:       e.lastErrorTime,
+	}}
+}
+
+// send 将 item 放入缓冲区，如果 wait 为 false，缓冲区满时直接返回 false。
+func (e *Exporter) send(it item, wait bool) bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	if e.closed {
+		return false
+	}
+
+	if wait {
+		e.ch <- it
+		return true
+	}
+
+	select {
+	case e.ch <- it:
+		return true
+	default:
+		return false
+	}
+}
+
+func (e *Exporter) run() {
+	defer close(e.done)
+
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+
+	batch := make([]*log.Entry, 0, e.batchSize)
+
+	for {
+		select {
+		case it, ok := <-e.ch:
+			if !ok {
+				e.export(batch)
+				return
+			}
+
+			if it.flushed != nil {
+				e.export(batch)
+				batch = batch[:0]
+				it.flushed <- true
+				continue
+			}
+
+			batch = append(batch, it.entry)
+
+			if len(batch) >= e.batchSize {
+				e.export(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			e.export(batch)
+			batch = batch[:0]
+		}
+	}
+}
+
+// export 发送一批日志并记录结果，发送失败的日志不会重试。
+func (e *Exporter) export(batch []*log.Entry) {
+	if len(batch) == 0 {
+		return
+	}
+
+	data := encodeRequest(e.resource, batch)
+	ctx, cancel := context.WithTimeout(context.Background(), e.timeout)
+	err := e.transport.export(ctx, data)
+	cancel()
+
+	e.statsMu.Lock()
+	defer e.statsMu.Unlock()
+
+	if err == nil {
+		e.consecutiveFailures = 0
+		return
+	}
+
+	e.consecutiveFailures++
+	e.failures++
+	e.lastError = err
+	e.lastErrorTime = time.Now()
+}
+
+type grpcTransport struct {
+	conn    *grpc.ClientConn
+	headers metadata.MD
+}
+
+func newGRPCTransport(config *Config) (*grpcTransport, error) {
+	endpoint := config.Endpoint
+
+	if endpoint == "" {
+		endpoint = DefaultGRPCEndpoint
+	}
+
+	opts := []grpc.DialOption{}
+
+	if config.Insecure {
+		opts = append(opts, grpc.WithInsecure())
+	} else {
+		opts = append(opts, grpc.WithTransportCredentials(credentials.NewTLS(config.TLS)))
+	}
+
+	conn, err := grpc.Dial(endpoint, opts...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &grpcTransport{
+		conn:    conn,
+		headers: metadata.New(config.Headers),
+	}, nil
+}
+
+func (t *grpcTransport) export(ctx context.Context, data []byte) error {
+	if len(t.headers) != 0 {
+		ctx = metadata.NewOutgoingContext(ctx, t.headers)
+	}
+
+	var resp []byte
+	return t.conn.Invoke(ctx, exportMethod, data, &resp, grpc.ForceCodec(rawCodec{}))
+}
+
+func (t *grpcTransport) Close() error {
+	return t.conn.Close()
+}
+
+// rawCodec 直接发送编码好的 protobuf，响应内容不需要解析。
+type rawCodec struct{}
+
+func (rawCodec) Marshal(v interface{}) ([]byte, error) {
+	data, ok := v.([]byte)
+
+	if !ok {
+		return nil, fmt.Errorf("logotlp: unexpected message type %T", v)
+	}
+
+	return data, nil
+}
+
+func (rawCodec) Unmarshal(data []byte, v interface{}) error {
+	p, ok := v.(*[]byte)
+
+	if !ok {
+		return fmt.Errorf("logotlp: unexpected message type %T", v)
+	}
+
+	*p = append((*p)[:0], data...)
+	return nil
+}
+
+func (rawCodec) Name() string {
+	return "proto"
+}
+
+type httpTransport struct {
+	client  *http.Client
+	url     string
+	headers map[string]string
+}
+
+func newHTTPTransport(config *Config) *httpTransport {
+	url := config.Endpoint
+
+	if url == "" {
+		url = DefaultHTTPEndpoint
+	}
+
+	client := http.DefaultClient
+
+	if config.TLS != nil {
+		client = &http.Client{
+			Transport: &http.Transport{
+				Proxy:           http.ProxyFromEnvironment,
+				TLSClientConfig: config.TLS,
+			},
+		}
+	}
+
+	return &httpTransport{
+		client:  client,
+		url:     url,
+		headers: config.Headers,
+	}
+}
+
+func (t *httpTransport) export(ctx context.Context, data []byte) error {
+	req, err := http.NewRequest(http.MethodPost, t.url, bytes.NewReader(data))
+
+	if err != nil {
+		return err
+	}
+
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/x-protobuf")
+
+	for k, v := range t.headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := t.client.Do(req)
+
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+	body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("logotlp: collector responds %v: %v", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	return nil
+}
+
+func (t *httpTransport) Close() error {
+	return nil
+}
@@ -0,0 +1,262 @@
+package logotlp
+
+import (
+	"encoding/binary"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	log "github.com/altstory/go-log"
+	"google.golang.org/grpc"
+)
+
+// decodeMessage 将 protobuf 消息解析成字段号到原始值的映射，varint 和 fixed64 转换成 8 字节小端序。
+func decodeMessage(t *testing.T, data []byte) map[int][][]byte {
+	fields := map[int][][]byte{}
+
+	for len(data) > 0 {
+		tag, n := binary.Uvarint(data)
+		data = data[n:]
+		field := int(tag >> 3)
+
+		switch tag & 7 {
+		case wireVarint:
+			v, n := binary.Uvarint(data)
+			data = data[n:]
+			var b [8]byte
+			binary.LittleEndian.PutUint64(b[:], v)
+			fields[field] = append(fields[field], b[:])
+		case wireFixed64:
+			fields[field] = append(fields[field], data[:8])
+			data = data[8:]
+		case wireBytes:
+			size, n := binary.Uvarint(data)
+			data = data[n:]
+			fields[field] = append(fields[field], data[:size])
+			data = data[size:]
+		default:
+			t.Fatalf("unexpected wire type. [tag:%v]", tag)
+		}
+	}
+
+	return fields
+}
+
+// decodeRecords 从 ExportLogsServiceRequest 中取出所有 LogRecord。
+func decodeRecords(t *testing.T, data []byte) []map[int][][]byte {
+	var records []map[int][][]byte
+
+	for _, rl := range decodeMessage(t, data)[1] {
+		for _, sl := range decodeMessage(t, rl)[2] {
+			for _, r := range decodeMessage(t, sl)[2] {
+				records = append(records, decodeMessage(t, r))
+			}
+		}
+	}
+
+	return records
+}
+
+func attributes(t *testing.T, record map[int][][]byte) map[string]string {
+	attrs := map[string]string{}
+
+	for _, kv := range record[6] {
+		fields := decodeMessage(t, kv)
+		value := decodeMessage(t, fields[2][0])
+
+		if s, ok := value[1]; ok {
+			attrs[string(fields[1][0])] = string(s[0])
+		} else {
+			attrs[string(fields[1][0])] = "<non-string>"
+		}
+	}
+
+	return attrs
+}
+
+func testEntry() *log.Entry {
+	return &log.Entry{
+		Level:   log.LogError,
+		Time:    time.Unix(1, 2),
+		Caller:  "main.go:12@main.main",
+		Tag:     "my_tag",
+		Message: "hello",
+		Fields: []log.Info{
+			{Key: "user", Value: "alice"},
+			{Key: "count", Value: 3},
+			{Key: TraceIDKey, Value: "0102030405060708090a0b0c0d0e0f10"},
+			{Key: SpanIDKey, Value: "0102030405060708"},
+		},
+	}
+}
+
+func checkRecord(t *testing.T, record map[int][][]byte) {
+	if v := binary.LittleEndian.Uint64(record[1][0]); v != 1000000002 {
+		t.Fatalf("unexpected time. [time:%v]", v)
+	}
+
+	if v := binary.LittleEndian.Uint64(record[2][0]); v != severityError {
+		t.Fatalf("unexpected severity. [severity:%v]", v)
+	}
+
+	if v := string(record[3][0]); v != "ERROR" {
+		t.Fatalf("unexpected severity text. [text:%v]", v)
+	}
+
+	if body := decodeMessage(t, record[5][0]); string(body[1][0]) != "hello" {
+		t.Fatalf("unexpected body. [body:%v]", body)
+	}
+
+	attrs := attributes(t, record)
+
+	if attrs["user"] != "alice" || attrs["tag"] != "my_tag" || attrs["code.function"] != "main.main" || attrs["code.filepath"] != "main.go" {
+		t.Fatalf("unexpected attributes. [attrs:%v]", attrs)
+	}
+
+	if _, ok := attrs[TraceIDKey]; ok {
+		t.Fatalf("trace id must not be an attribute. [attrs:%v]", attrs)
+	}
+
+	if len(record[9]) != 1 || len(record[9][0]) != 16 || record[9][0][15] != 0x10 {
+		t.Fatalf("unexpected trace id. [trace_id:%v]", record[9])
+	}
+
+	if len(record[10]) != 1 || len(record[10][0]) != 8 {
+		t.Fatalf("unexpected span id. [span_id:%v]", record[10])
+	}
+}
+
+func TestHTTPExporter(t *testing.T) {
+	bodies := make(chan []byte, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ct := r.Header.Get("Content-Type"); ct != "application/x-protobuf" {
+			t.Errorf("unexpected content type. [content_type:%v]", ct)
+		}
+
+		if auth := r.Header.Get("Authorization"); auth != "token" {
+			t.Errorf("unexpected authorization. [authorization:%v]", auth)
+		}
+
+		data, _ := ioutil.ReadAll(r.Body)
+		bodies <- data
+	}))
+	defer server.Close()
+
+	e, err := New(&Config{
+		Protocol:    ProtocolHTTP,
+		Endpoint:    server.URL + "/v1/logs",
+		Headers:     map[string]string{"Authorization": "token"},
+		ServiceName: "test",
+	})
+
+	if err != nil {
+		t.Fatalf("fail to create exporter. [err:%v]", err)
+	}
+
+	e.Write(testEntry())
+	e.Write(testEntry())
+
+	if err := e.Flush(); err != nil {
+		t.Fatalf("fail to flush. [err:%v]", err)
+	}
+
+	e.Close()
+
+	records := decodeRecords(t, <-bodies)
+
+	if len(records) != 2 {
+		t.Fatalf("unexpected record count. [count:%v]", len(records))
+	}
+
+	checkRecord(t, records[0])
+}
+
+func TestHTTPExporterFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	e, _ := New(&Config{
+		Protocol: ProtocolHTTP,
+		Endpoint: server.URL,
+	})
+	defer e.Close()
+
+	e.Write(testEntry())
+
+	if err := e.Flush(); err == nil {
+		t.Fatalf("flush must fail.")
+	}
+
+	stats := e.SinkStats()[0]
+
+	if stats.Healthy || stats.Failures != 1 || stats.LastError == nil {
+		t.Fatalf("unexpected stats. [stats:%+v]", stats)
+	}
+}
+
+type serverCodec struct {
+	rawCodec
+}
+
+func (serverCodec) String() string {
+	return "proto"
+}
+
+func TestGRPCExporter(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+
+	if err != nil {
+		t.Fatalf("fail to listen. [err:%v]", err)
+	}
+
+	requests := make(chan []byte, 10)
+	server := grpc.NewServer(grpc.CustomCodec(serverCodec{}), grpc.UnknownServiceHandler(func(srv interface{}, stream grpc.ServerStream) error {
+		method, _ := grpc.MethodFromServerStream(stream)
+
+		if method != exportMethod {
+			return errors.New("unexpected method")
+		}
+
+		var data []byte
+
+		if err := stream.RecvMsg(&data); err != nil {
+			return err
+		}
+
+		requests <- data
+		return stream.SendMsg([]byte{})
+	}))
+	go server.Serve(lis)
+	defer server.Stop()
+
+	e, err := New(&Config{
+		Endpoint: lis.Addr().String(),
+		Insecure: true,
+	})
+
+	if err != nil {
+		t.Fatalf("fail to create exporter. [err:%v]", err)
+	}
+
+	e.Write(testEntry())
+
+	if err := e.Flush(); err != nil {
+		t.Fatalf("fail to flush. [err:%v]", err)
+	}
+
+	e.Close()
+
+	records := decodeRecords(t, <-requests)
+
+	if len(records) != 1 {
+		t.Fatalf("unexpected record count. [count:%v]", len(records))
+	}
+
+	checkRecord(t, records[0])
+}
@@ -0,0 +1,275 @@
+package logotlp
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/altstory/go-log"
+)
+
+// protobuf 的 wire type。
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+)
+
+// OTLP 的 SeverityNumber，Trace 在这个库中比 Info 严重，所以用 INFO2 表示，Panic 用 ERROR4 表示。
+const (
+	severityDebug  = 5
+	severityInfo   = 9
+	severityInfo2  = 10
+	severityWarn   = 13
+	severityError  = 17
+	severityError4 = 20
+	severityFatal  = 21
+)
+
+// scopeName 是 InstrumentationScope 的名字。
+const scopeName = "github.com/altstory/go-log"
+
+func appendVarint(b []byte, v uint64) []byte {
+	for v >= 0x80 {
+		b = append(b, byte(v)|0x80)
+		v >>= 7
+	}
+
+	return append(b, byte(v))
+}
+
+func appendTag(b []byte, field int, wire int) []byte {
+	return appendVarint(b, uint64(field)<<3|uint64(wire))
+}
+
+func appendVarintField(b []byte, field int, v uint64) []byte {
+	b = appendTag(b, field, wireVarint)
+	return appendVarint(b, v)
+}
+
+func appendFixed64Field(b []byte, field int, v uint64) []byte {
+	b = appendTag(b, field, wireFixed64)
+
+	var data [8]byte
+	binary.LittleEndian.PutUint64(data[:], v)
+	return append(b, data[:]...)
+}
+
+func appendBytesField(b []byte, field int, data []byte) []byte {
+	b = appendTag(b, field, wireBytes)
+	b = appendVarint(b, uint64(len(data)))
+	return append(b, data...)
+}
+
+func appendStringField(b []byte, field int, s string) []byte {
+	b = appendTag(b, field, wireBytes)
+	b = appendVarint(b, uint64(len(s)))
+	return append(b, s...)
+}
+
+// encodeAnyValue 编码 AnyValue，无法识别的类型用 fmt.Sprint 转换成字符串。
+func encodeAnyValue(value interface{}) []byte {
+	switch v := value.(type) {
+	case string:
+		return appendStringField(nil, 1, v)
+	case bool:
+		n := uint64(0)
+
+		if v {
+			n = 1
+		}
+
+		return appendVarintField(nil, 2, n)
+	case int:
+		return appendVarintField(nil, 3, uint64(v))
+	case int8:
+		return appendVarintField(nil, 3, uint64(v))
+	case int16:
+		return appendVarintField(nil, 3, uint64(v))
+	case int32:
+		return appendVarintField(nil, 3, uint64(v))
+	case int64:
+		return appendVarintField(nil, 3, uint64(v))
+	case uint:
+		return appendVarintField(nil, 3, uint64(v))
+	case uint8:
+		return appendVarintField(nil, 3, uint64(v))
+	case uint16:
+		return appendVarintField(nil, 3, uint64(v))
+	case uint32:
+		return appendVarintField(nil, 3, uint64(v))
+	case float32:
+		return appendFixed64Field(nil, 4, math.Float64bits(float64(v)))
+	case float64:
+		return appendFixed64Field(nil, 4, math.Float64bits(v))
+	case []byte:
+		return appendBytesField(nil, 7, v)
+	case time.Duration:
+		return appendStringField(nil, 1, v.String())
+	case error:
+		return appendStringField(nil, 1, v.Error())
+	default:
+		return appendStringField(nil, 1, fmt.Sprint(v))
+	}
+}
+
+func appendKeyValue(b []byte, field int, key string, value interface{}) []byte {
+	var kv []byte
+	kv = appendStringField(kv, 1, key)
+	kv = appendBytesField(kv, 2, encodeAnyValue(value))
+	return appendBytesField(b, field, kv)
+}
+
+// severity 将日志级别转换成 OTLP 的 SeverityNumber。
+func severity(level log.Level) uint64 {
+	switch level {
+	case log.LogDebug:
+		return severityDebug
+	case log.LogTrace:
+		return severityInfo2
+	case log.LogWarn:
+		return severityWarn
+	case log.LogError:
+		return severityError
+	case log.LogPanic:
+		return severityError4
+	case log.LogFatal:
+		return severityFatal
+	default:
+		return severityInfo
+	}
+}
+
+// decodeID 解析十六进制的 trace id 或 span id，长度不对时返回 nil。
+func decodeID(value interface{}, size int) []byte {
+	s, ok := value.(string)
+
+	if !ok || len(s) != size*2 {
+		return nil
+	}
+
+	id, err := hex.DecodeString(s)
+
+	if err != nil {
+		return nil
+	}
+
+	return id
+}
+
+// encodeLogRecord 编码一条 LogRecord。
+// 字段中的 TraceIDKey 和 SpanIDKey 会被当做 trace context，其他字段都是 attributes。
+func encodeLogRecord(entry *log.Entry) []byte {
+	var b []byte
+	b = appendFixed64Field(b, 1, uint64(entry.Time.UnixNano()))
+	b = appendVarintField(b, 2, severity(entry.Level))
+
+	if name := entry.Level.String(); name != "UNKNOWN" {
+		b = appendStringField(b, 3, name)
+	}
+
+	b = appendBytesField(b, 5, appendStringField(nil, 1, entry.Message))
+
+	if entry.Tag != "" {
+		b = appendKeyValue(b, 6, "tag", entry.Tag)
+	}
+
+	if entry.Caller != "" {
+		b = appendCaller(b, entry.Caller)
+	}
+
+	var traceID, spanID []byte
+
+	for _, info := range entry.Fields {
+		switch info.Key {
+		case TraceIDKey:
+			if id := decodeID(info.Value, 16); id != nil {
+				traceID = id
+				continue
+			}
+		case SpanIDKey:
+			if id := decodeID(info.Value, 8); id != nil {
+				spanID = id
+				continue
+			}
+		}
+
+		b = appendKeyValue(b, 6, info.Key, info.Value)
+	}
+
+	if traceID != nil {
+		b = appendBytesField(b, 9, traceID)
+	}
+
+	if spanID != nil {
+		b = appendBytesField(b, 10, spanID)
+	}
+
+	return b
+}
+
+// appendCaller 将 "file:line@func" 格式的调用者信息转换成 code.* 语义约定的 attributes。
+func appendCaller(b []byte, caller string) []byte {
+	file := caller
+	function := ""
+
+	if idx := strings.LastIndexByte(file, '@'); idx >= 0 {
+		function = file[idx+1:]
+		file = file[:idx]
+	}
+
+	if idx := strings.LastIndexByte(file, ':'); idx >= 0 {
+		if line, err := strconv.Atoi(file[idx+1:]); err == nil {
+			b = appendKeyValue(b, 6, "code.lineno", line)
+			file = file[:idx]
+		}
+	}
+
+	b = appendKeyValue(b, 6, "code.filepath", file)
+
+	if function != "" {
+		b = appendKeyValue(b, 6, "code.function", function)
+	}
+
+	return b
+}
+
+// encodeResource 编码 Resource，attributes 按照 key 排序。
+func encodeResource(attributes map[string]string) []byte {
+	keys := make([]string, 0, len(attributes))
+
+	for k := range attributes {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	var b []byte
+
+	for _, k := range keys {
+		b = appendKeyValue(b, 1, k, attributes[k])
+	}
+
+	return b
+}
+
+// encodeRequest 编码 ExportLogsServiceRequest，所有日志放在同一个 ResourceLogs 和 ScopeLogs 中。
+func encodeRequest(resource []byte, entries []*log.Entry) []byte {
+	var scopeLogs []byte
+	scopeLogs = appendBytesField(scopeLogs, 1, appendStringField(nil, 1, scopeName))
+
+	for _, entry := range entries {
+		scopeLogs = appendBytesField(scopeLogs, 2, encodeLogRecord(entry))
+	}
+
+	var resourceLogs []byte
+	resourceLogs = appendBytesField(resourceLogs, 1, resource)
+	resourceLogs = appendBytesField(resourceLogs, 2, scopeLogs)
+
+	return appendBytesField(nil, 1, resourceLogs)
+}
@@ -0,0 +1,261 @@
+// Package logparse 将 FormatText 格式的日志解析成 log.Entry，方便在测试、日志回放和分析脚本中使用。
+//
+// 文本格式如下，其中调用栈是可选的，tag 为空时输出 "*"：
+//
+//	[INFO][2019-07-03T12:34:56.789+08:00][file.go:12@pkg.Func] tag||key1=value1||this is custom log text
+//
+// 没有开启 log.Config.Escape 时，文本格式是有歧义的：第一个不包含 "=" 的部分以及之后的所有内容都被当做消息。
+// 开启 log.Config.Escape 的日志应该使用 ParseEscaped 或者设置 Reader.Escaped，可以无歧义的还原日志内容。
+//
+// 解析出的 Entry.Fields 中，所有值都是 string 类型。不以 "[" 开头的行被当做 log.Printf 输出的日志，整行都是消息。
+package logparse
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strings"
+	"time"
+
+	log "github.com/altstory/go-log"
+)
+
+const (
+	separator = "||"
+
+	// levelAudit 和 log 包中审计日志的级别一致。
+	levelAudit log.Level = -1
+)
+
+var errInvalidLine = errors.New("logparse: invalid log line")
+
+// Parse 解析一行没有转义的文本日志，line 结尾的换行符会被忽略。
+func Parse(line string) (*log.Entry, error) {
+	return parse(line, false)
+}
+
+// ParseEscaped 解析一行开启了 log.Config.Escape 的文本日志，line 结尾的换行符会被忽略。
+func ParseEscaped(line string) (*log.Entry, error) {
+	return parse(line, true)
+}
+
+func parse(line string, escaped bool) (*log.Entry, error) {
+	line = strings.TrimRight(line, "\r\n")
+
+	if !strings.HasPrefix(line, "[") {
+		return &log.Entry{Message: line}, nil
+	}
+
+	entry := &log.Entry{}
+	levelName, line, ok := cutBracket(line)
+
+	if !ok {
+		return nil, errInvalidLine
+	}
+
+	if levelName == "AUDIT" {
+		entry.Level = levelAudit
+	} else {
+		level, err := log.ParseLevel(levelName)
+
+		if err != nil {
+			return nil, err
+		}
+
+		entry.Level = level
+	}
+
+	t, line, ok := cutBracket(line)
+
+	if !ok {
+		return nil, errInvalidLine
+	}
+
+	tm, err := time.Parse(time.RFC3339Nano, t)
+
+	if err != nil {
+		return nil, err
+	}
+
+	entry.Time = tm
+
+	if strings.HasPrefix(line, "[") {
+		// 调用栈中可能包含 "]"，例如泛型函数，以 "] " 作为结尾。
+		idx := strings.Index(line, "] ")
+
+		if idx < 0 {
+			return nil, errInvalidLine
+		}
+
+		entry.Caller = line[1:idx]
+		line = line[idx+1:]
+	}
+
+	if !strings.HasPrefix(line, " ") {
+		return nil, errInvalidLine
+	}
+
+	var parts []string
+
+	if escaped {
+		parts = splitEscaped(line[1:])
+	} else {
+		parts = strings.Split(line[1:], separator)
+	}
+
+	if len(parts) < 2 {
+		return nil, errInvalidLine
+	}
+
+	if tag := parts[0]; tag != "*" {
+		entry.Tag = unescape(tag, escaped)
+	}
+
+	parts = parts[1:]
+
+	if escaped {
+		for _, part := range parts[:len(parts)-1] {
+			idx := indexUnescaped(part, '=')
+
+			if idx < 0 {
+				return nil, errInvalidLine
+			}
+
+			entry.Fields = append(entry.Fields, log.Info{
+				Key:   unescape(part[:idx], true),
+				Value: unescape(part[idx+1:], true),
+			})
+		}
+
+		entry.Message = unescape(parts[len(parts)-1], true)
+		return entry, nil
+	}
+
+	for len(parts) > 1 {
+		idx := strings.IndexByte(parts[0], '=')
+
+		if idx < 0 {
+			break
+		}
+
+		entry.Fields = append(entry.Fields, log.Info{
+			Key:   parts[0][:idx],
+			Value: parts[0][idx+1:],
+		})
+		parts = parts[1:]
+	}
+
+	entry.Message = strings.Join(parts, separator)
+	return entry, nil
+}
+
+// cutBracket 返回 line 开头 "[...]" 中的内容和剩下的字符串。
+func cutBracket(line string) (content, rest string, ok bool) {
+	if !strings.HasPrefix(line, "[") {
+		return
+	}
+
+	idx := strings.IndexByte(line, ']')
+
+	if idx < 0 {
+		return
+	}
+
+	return line[1:idx], line[idx+1:], true
+}
+
+// splitEscaped 用没有转义的 "||" 分割 s。
+func splitEscaped(s string) []string {
+	var parts []string
+	start := 0
+
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\':
+			i++
+		case strings.HasPrefix(s[i:], separator):
+			parts = append(parts, s[start:i])
+			start = i + len(separator)
+			i++
+		}
+	}
+
+	return append(parts, s[start:])
+}
+
+// indexUnescaped 返回 s 中第一个没有转义的 c 的位置。
+func indexUnescaped(s string, c byte) int {
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case c:
+			return i
+		}
+	}
+
+	return -1
+}
+
+func unescape(s string, escaped bool) string {
+	if !escaped || strings.IndexByte(s, '\\') < 0 {
+		return s
+	}
+
+	buf := make([]byte, 0, len(s))
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+
+		if c == '\\' && i+1 < len(s) {
+			i++
+			c = s[i]
+
+			switch c {
+			case 'n':
+				c = '\n'
+			case 'r':
+				c = '\r'
+			}
+		}
+
+		buf = append(buf, c)
+	}
+
+	return string(buf)
+}
+
+// Reader 逐行读取文本格式的日志。
+type Reader struct {
+	Escaped bool // Escaped 表示日志开启了 log.Config.Escape。
+
+	reader *bufio.Reader
+}
+
+// NewReader 创建一个 Reader。
+func NewReader(reader io.Reader) *Reader {
+	return &Reader{
+		reader: bufio.NewReader(reader),
+	}
+}
+
+// Next 读取并解析下一行日志，数据流结束时返回 io.EOF，空行会被跳过。
+func (r *Reader) Next() (*log.Entry, error) {
+	for {
+		line, err := r.reader.ReadString('\n')
+
+		if line == "" || line == "\n" {
+			if err != nil {
+				return nil, err
+			}
+
+			continue
+		}
+
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+
+		return parse(line, r.Escaped)
+	}
+}
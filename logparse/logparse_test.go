@@ -0,0 +1,76 @@
+package logparse
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	log "github.com/altstory/go-log"
+)
+
+func TestParse(t *testing.T) {
+	entry, err := Parse("[INFO][2019-07-03T12:34:56.789+08:00][a.go:12@pkg.Func] tag||k1=v1||k2=a=b||msg||with separator\n")
+
+	if err != nil {
+		t.Fatalf("fail to parse. [err:%v]", err)
+	}
+
+	if entry.Level != log.LogInfo || entry.Caller != "a.go:12@pkg.Func" || entry.Tag != "tag" ||
+		entry.Time.Format("15:04:05.000") != "12:34:56.789" || entry.Message != "msg||with separator" {
+		t.Fatalf("invalid entry. [entry:%+v]", entry)
+	}
+
+	if len(entry.Fields) != 2 || entry.Fields[0].Value != "v1" || entry.Fields[1].Key != "k2" || entry.Fields[1].Value != "a=b" {
+		t.Fatalf("invalid fields. [fields:%v]", entry.Fields)
+	}
+
+	entry, err = Parse("[WARN][2019-07-03T12:34:56Z] *||hello")
+
+	if err != nil || entry.Tag != "" || entry.Caller != "" || entry.Message != "hello" || len(entry.Fields) != 0 {
+		t.Fatalf("invalid entry. [entry:%+v] [err:%v]", entry, err)
+	}
+
+	if _, err := Parse("[INFO][not a time] *||hello"); err == nil {
+		t.Fatalf("invalid time should be rejected.")
+	}
+}
+
+func TestParseEscaped(t *testing.T) {
+	line := `[INFO][2019-07-03T12:34:56.789+08:00][a.go:12@pkg.Func] t\|g||k\=1=a\|\|b\=c||path=C:\\tmp||x=1\|\|line1\nline2`
+	entry, err := ParseEscaped(line)
+
+	if err != nil {
+		t.Fatalf("fail to parse. [err:%v]", err)
+	}
+
+	if entry.Tag != "t|g" || entry.Message != "x=1||line1\nline2" {
+		t.Fatalf("invalid entry. [entry:%+v]", entry)
+	}
+
+	if len(entry.Fields) != 2 || entry.Fields[0].Key != "k=1" || entry.Fields[0].Value != "a||b=c" || entry.Fields[1].Value != `C:\tmp` {
+		t.Fatalf("invalid fields. [fields:%v]", entry.Fields)
+	}
+}
+
+func TestReader(t *testing.T) {
+	r := NewReader(strings.NewReader("[INFO][2019-07-03T12:34:56Z] *||a\n\nraw print\n[ERROR][2019-07-03T12:34:56Z] *||b"))
+	var messages []string
+
+	for {
+		entry, err := r.Next()
+
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			t.Fatalf("fail to read. [err:%v]", err)
+		}
+
+		messages = append(messages, entry.Message)
+	}
+
+	if strings.Join(messages, ",") != "a,raw print,b" {
+		t.Fatalf("invalid messages. [messages:%v]", messages)
+	}
+}
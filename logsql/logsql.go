@@ -0,0 +1,511 @@
+// Package logsql 为 database/sql 的驱动添加 SQL 日志。
+//
+// 每条 SQL 执行完成后输出一条日志，包含 SQL 语句、参数、影响或返回的行数和耗时，
+// 日志使用调用者传入的 context，所以会带上 context 中的 tag 和其他信息。
+// 查询的日志在 Rows 关闭时输出，这时才知道返回了多少行数据。
+//
+// 典型用法：
+//
+//	connector, _ := mysqlDriver.OpenConnector(dsn)
+//	db := sql.OpenDB(logsql.NewConnector(connector))
+package logsql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"time"
+
+	log "github.com/altstory/go-log"
+)
+
+// Option 是 logsql 的配置项。
+type Option func(opts *options)
+
+type options struct {
+	level  log.Level
+	redact func(arg driver.NamedValue) interface{}
+}
+
+// WithLevel 设置 SQL 日志的级别，只能是 log.LogDebug 或 log.LogTrace，默认是 log.LogDebug。
+// 执行失败的 SQL 总是用 log.LogWarn 输出。
+func WithLevel(level log.Level) Option {
+	return func(opts *options) {
+		opts.level = level
+	}
+}
+
+// WithRedact 设置 SQL 参数的脱敏函数，返回值会代替参数输出到日志中。
+// 默认只输出参数的类型，不输出参数的值，避免泄露敏感数据。
+func WithRedact(redact func(arg driver.NamedValue) interface{}) Option {
+	return func(opts *options) {
+		opts.redact = redact
+	}
+}
+
+// ShowArgs 是一个不做任何脱敏的脱敏函数，可以传给 WithRedact 输出参数原值，一般只在开发环境使用。
+func ShowArgs(arg driver.NamedValue) interface{} {
+	return arg.Value
+}
+
+func redactArg(arg driver.NamedValue) interface{} {
+	if arg.Value == nil {
+		return nil
+	}
+
+	return fmt.Sprintf("<%T>", arg.Value)
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		level:  log.LogDebug,
+		redact: redactArg,
+	}
+
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return o
+}
+
+// NewConnector 包装 c，让所有通过它执行的 SQL 都输出日志。
+func NewConnector(c driver.Connector, opts ...Option) driver.Connector {
+	return &connector{
+		Connector: c,
+		opts:      newOptions(opts),
+	}
+}
+
+// Wrap 包装 d，让所有通过它执行的 SQL 都输出日志，用于没有实现 driver.Connector 的驱动：
+//
+//	sql.Register("mysql-log", logsql.Wrap(&mysql.MySQLDriver{}))
+//	db, _ := sql.Open("mysql-log", dsn)
+func Wrap(d driver.Driver, opts ...Option) driver.Driver {
+	return &wrappedDriver{
+		Driver: d,
+		opts:   newOptions(opts),
+	}
+}
+
+type wrappedDriver struct {
+	driver.Driver
+	opts *options
+}
+
+func (d *wrappedDriver) Open(name string) (driver.Conn, error) {
+	c, err := d.Driver.Open(name)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &conn{Conn: c, opts: d.opts}, nil
+}
+
+type connector struct {
+	driver.Connector
+	opts *options
+}
+
+func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
+	dc, err := c.Connector.Connect(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &conn{Conn: dc, opts: c.opts}, nil
+}
+
+func (c *connector) Driver() driver.Driver {
+	return &wrappedDriver{Driver: c.Connector.Driver(), opts: c.opts}
+}
+
+type conn struct {
+	driver.Conn
+	opts *options
+}
+
+func (c *conn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *conn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	var s driver.Stmt
+	var err error
+
+	if p, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		s, err = p.PrepareContext(ctx, query)
+	} else {
+		s, err = c.Conn.Prepare(query)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return wrapStmt(&stmt{Stmt: s, query: query, opts: c.opts}), nil
+}
+
+func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if b, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return b.BeginTx(ctx, opts)
+	}
+
+	// 和 database/sql 一样，驱动不支持 BeginTx 时不能忽略事务的配置。
+	if opts.Isolation != driver.IsolationLevel(sql.LevelDefault) {
+		return nil, errors.New("sql: driver does not support non-default isolation level")
+	}
+
+	if opts.ReadOnly {
+		return nil, errors.New("sql: driver does not support read-only transactions")
+	}
+
+	tx, err := c.Conn.Begin()
+
+	if err != nil {
+		return nil, err
+	}
+
+	select {
+	case <-ctx.Done():
+		tx.Rollback()
+		return nil, ctx.Err()
+	default:
+	}
+
+	return tx, nil
+}
+
+func (c *conn) Ping(ctx context.Context) error {
+	if p, ok := c.Conn.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+
+	return nil
+}
+
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	e, ok := c.Conn.(driver.ExecerContext)
+
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+
+	start := time.Now()
+	result, err := e.ExecContext(ctx, query, args)
+
+	if err != driver.ErrSkip {
+		c.opts.logExec(ctx, query, args, start, result, err)
+	}
+
+	return result, err
+}
+
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	q, ok := c.Conn.(driver.QueryerContext)
+
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+
+	start := time.Now()
+	rows, err := q.QueryContext(ctx, query, args)
+
+	if err == driver.ErrSkip {
+		return nil, err
+	}
+
+	return c.opts.wrapRows(ctx, query, args, start, rows, err)
+}
+
+func (c *conn) ResetSession(ctx context.Context) error {
+	if r, ok := c.Conn.(driver.SessionResetter); ok {
+		return r.ResetSession(ctx)
+	}
+
+	return nil
+}
+
+func (c *conn) CheckNamedValue(nv *driver.NamedValue) error {
+	if checker, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+
+	return driver.ErrSkip
+}
+
+func (c *conn) IsValid() bool {
+	if v, ok := c.Conn.(driver.Validator); ok {
+		return v.IsValid()
+	}
+
+	return true
+}
+
+type stmt struct {
+	driver.Stmt
+	query string
+	opts  *options
+}
+
+func (s *stmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.ExecContext(context.Background(), namedValues(args))
+}
+
+func (s *stmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.QueryContext(context.Background(), namedValues(args))
+}
+
+func (s *stmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	start := time.Now()
+	var result driver.Result
+	var err error
+
+	if e, ok := s.Stmt.(driver.StmtExecContext); ok {
+		result, err = e.ExecContext(ctx, args)
+	} else {
+		result, err = s.Stmt.Exec(values(args))
+	}
+
+	s.opts.logExec(ctx, s.query, args, start, result, err)
+	return result, err
+}
+
+func (s *stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	start := time.Now()
+	var rows driver.Rows
+	var err error
+
+	if q, ok := s.Stmt.(driver.StmtQueryContext); ok {
+		rows, err = q.QueryContext(ctx, args)
+	} else {
+		rows, err = s.Stmt.Query(values(args))
+	}
+
+	return s.opts.wrapRows(ctx, s.query, args, start, rows, err)
+}
+
+// wrapStmt 只在底层 Stmt 实现了 driver.NamedValueChecker 或 driver.ColumnConverter 时才实现这些接口，
+// database/sql 根据 Stmt 是否实现这些接口选择参数的转换方式，包装之后的行为必须和底层 Stmt 一致。
+func wrapStmt(s *stmt) driver.Stmt {
+	checker, hasChecker := s.Stmt.(driver.NamedValueChecker)
+	converter, hasConverter := s.Stmt.(driver.ColumnConverter)
+
+	switch {
+	case hasChecker && hasConverter:
+		return &struct {
+			*stmt
+			namedValueChecker
+			columnConverter
+		}{s, namedValueChecker{checker}, columnConverter{converter}}
+	case hasChecker:
+		return &struct {
+			*stmt
+			namedValueChecker
+		}{s, namedValueChecker{checker}}
+	case hasConverter:
+		return &struct {
+			*stmt
+			columnConverter
+		}{s, columnConverter{converter}}
+	default:
+		return s
+	}
+}
+
+type namedValueChecker struct {
+	checker driver.NamedValueChecker
+}
+
+func (c namedValueChecker) CheckNamedValue(nv *driver.NamedValue) error {
+	return c.checker.CheckNamedValue(nv)
+}
+
+type columnConverter struct {
+	converter driver.ColumnConverter
+}
+
+func (c columnConverter) ColumnConverter(idx int) driver.ValueConverter {
+	return c.converter.ColumnConverter(idx)
+}
+
+// rows 统计读取的行数，在关闭时输出日志。
+type rows struct {
+	driver.Rows
+
+	ctx   context.Context
+	query string
+	args  []driver.NamedValue
+	start time.Time
+	opts  *options
+	count int64
+	err   error
+}
+
+func (r *rows) Next(dest []driver.Value) error {
+	err := r.Rows.Next(dest)
+
+	if err == nil {
+		r.count++
+	} else if err != io.EOF {
+		r.err = err
+	}
+
+	return err
+}
+
+func (r *rows) Close() error {
+	err := r.Rows.Close()
+	r.opts.log(r.ctx, r.query, r.args, r.start, log.Int64("rows", r.count), r.err)
+	return err
+}
+
+// 以下方法转发 driver.Rows 的可选接口，底层 Rows 没有实现时返回和 database/sql 一样的默认值。
+
+func (r *rows) HasNextResultSet() bool {
+	if rs, ok := r.Rows.(driver.RowsNextResultSet); ok {
+		return rs.HasNextResultSet()
+	}
+
+	return false
+}
+
+func (r *rows) NextResultSet() error {
+	if rs, ok := r.Rows.(driver.RowsNextResultSet); ok {
+		return rs.NextResultSet()
+	}
+
+	return io.EOF
+}
+
+func (r *rows) ColumnTypeScanType(index int) reflect.Type {
+	if ct, ok := r.Rows.(driver.RowsColumnTypeScanType); ok {
+		return ct.ColumnTypeScanType(index)
+	}
+
+	return reflect.TypeOf(new(interface{})).Elem()
+}
+
+func (r *rows) ColumnTypeDatabaseTypeName(index int) string {
+	if ct, ok := r.Rows.(driver.RowsColumnTypeDatabaseTypeName); ok {
+		return ct.ColumnTypeDatabaseTypeName(index)
+	}
+
+	return ""
+}
+
+func (r *rows) ColumnTypeLength(index int) (length int64, ok bool) {
+	if ct, ok := r.Rows.(driver.RowsColumnTypeLength); ok {
+		return ct.ColumnTypeLength(index)
+	}
+
+	return 0, false
+}
+
+func (r *rows) ColumnTypeNullable(index int) (nullable, ok bool) {
+	if ct, ok := r.Rows.(driver.RowsColumnTypeNullable); ok {
+		return ct.ColumnTypeNullable(index)
+	}
+
+	return false, false
+}
+
+func (r *rows) ColumnTypePrecisionScale(index int) (precision, scale int64, ok bool) {
+	if ct, ok := r.Rows.(driver.RowsColumnTypePrecisionScale); ok {
+		return ct.ColumnTypePrecisionScale(index)
+	}
+
+	return 0, 0, false
+}
+
+func (o *options) wrapRows(ctx context.Context, query string, args []driver.NamedValue, start time.Time, dr driver.Rows, err error) (driver.Rows, error) {
+	if err != nil {
+		o.log(ctx, query, args, start, log.Int64("rows", 0), err)
+		return nil, err
+	}
+
+	return &rows{
+		Rows:  dr,
+		ctx:   ctx,
+		query: query,
+		args:  args,
+		start: start,
+		opts:  o,
+	}, nil
+}
+
+func (o *options) logExec(ctx context.Context, query string, args []driver.NamedValue, start time.Time, result driver.Result, err error) {
+	var affected int64
+
+	if err == nil {
+		affected, _ = result.RowsAffected()
+	}
+
+	o.log(ctx, query, args, start, log.Int64("rows_affected", affected), err)
+}
+
+func (o *options) log(ctx context.Context, query string, args []driver.NamedValue, start time.Time, rows log.Info, err error) {
+	level := o.level
+
+	if err != nil {
+		level = log.LogWarn
+	}
+
+	if !log.Enabled(ctx, level) {
+		return
+	}
+
+	redacted := make([]interface{}, 0, len(args))
+
+	for _, arg := range args {
+		redacted = append(redacted, o.redact(arg))
+	}
+
+	info := []log.Info{
+		log.String("sql", query),
+		log.Any("args", redacted),
+		rows,
+		log.Duration("latency", time.Since(start)),
+	}
+
+	if err != nil {
+		info = append(info, log.Any("err", err))
+	}
+
+	ctx = log.WithMoreInfo(ctx, info...)
+
+	switch level {
+	case log.LogWarn:
+		log.Warnf(ctx, "sql failed")
+	case log.LogTrace:
+		log.Tracef(ctx, "sql")
+	default:
+		log.Debugf(ctx, "sql")
+	}
+}
+
+func namedValues(args []driver.Value) []driver.NamedValue {
+	nvs := make([]driver.NamedValue, 0, len(args))
+
+	for i, v := range args {
+		nvs = append(nvs, driver.NamedValue{Ordinal: i + 1, Value: v})
+	}
+
+	return nvs
+}
+
+func values(args []driver.NamedValue) []driver.Value {
+	vs := make([]driver.Value, 0, len(args))
+
+	for _, arg := range args {
+		vs = append(vs, arg.Value)
+	}
+
+	return vs
+}
@@ -0,0 +1,225 @@
+package logsql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	log "github.com/altstory/go-log"
+	"github.com/altstory/go-log/logtest"
+)
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(name string) (driver.Conn, error) {
+	return fakeConn{}, nil
+}
+
+type fakeConn struct{}
+
+func (fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return fakeStmt{query: query}, nil
+}
+
+func (fakeConn) Close() error {
+	return nil
+}
+
+func (fakeConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("not supported")
+}
+
+type fakeStmt struct {
+	query string
+}
+
+func (fakeStmt) Close() error {
+	return nil
+}
+
+func (fakeStmt) NumInput() int {
+	return -1
+}
+
+// lastArgs 是最近一次 Exec 收到的参数。
+var lastArgs []driver.Value
+
+func (s fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	lastArgs = args
+
+	if s.query == "bad" {
+		return nil, errors.New("syntax error")
+	}
+
+	return driver.RowsAffected(2), nil
+}
+
+func (fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return &fakeRows{n: 3}, nil
+}
+
+type fakeRows struct {
+	n int
+}
+
+func (*fakeRows) Columns() []string {
+	return []string{"id"}
+}
+
+func (*fakeRows) Close() error {
+	return nil
+}
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.n == 0 {
+		return io.EOF
+	}
+
+	r.n--
+	dest[0] = int64(r.n)
+	return nil
+}
+
+func (*fakeRows) ColumnTypeDatabaseTypeName(index int) string {
+	return "BIGINT"
+}
+
+func (*fakeRows) HasNextResultSet() bool {
+	return true
+}
+
+func (r *fakeRows) NextResultSet() error {
+	r.n = 1
+	return nil
+}
+
+func fieldMap(entry log.Entry) map[string]interface{} {
+	fields := map[string]interface{}{}
+
+	for _, f := range entry.Fields {
+		fields[f.Key] = f.Value
+	}
+
+	return fields
+}
+
+func TestWrap(t *testing.T) {
+	rec, restore := logtest.Install()
+	defer restore()
+
+	sql.Register("logsql-fake", Wrap(fakeDriver{}))
+	db, err := sql.Open("logsql-fake", "")
+
+	if err != nil {
+		t.Fatalf("fail to open db. [err:%v]", err)
+	}
+
+	defer db.Close()
+	ctx := log.WithTag(context.Background(), "req-1")
+
+	if _, err := db.ExecContext(ctx, "update t set name = ?", "secret"); err != nil {
+		t.Fatalf("fail to exec. [err:%v]", err)
+	}
+
+	entries := rec.Find(log.LogDebug, "sql")
+
+	if len(entries) != 1 || entries[0].Tag != "req-1" {
+		t.Fatalf("invalid exec log. [entries:%v]", rec.Entries())
+	}
+
+	fields := fieldMap(entries[0])
+
+	if fields["rows_affected"] != int64(2) || fields["sql"] != "update t set name = ?" {
+		t.Fatalf("invalid exec fields. [fields:%v]", fields)
+	}
+
+	if args := fields["args"].([]interface{}); len(args) != 1 || args[0] != "<string>" {
+		t.Fatalf("args should be redacted. [args:%v]", args)
+	}
+
+	rec.Reset()
+	rows, err := db.QueryContext(ctx, "select id from t")
+
+	if err != nil {
+		t.Fatalf("fail to query. [err:%v]", err)
+	}
+
+	for rows.Next() {
+	}
+
+	rows.Close()
+	entries = rec.Find(log.LogDebug, "sql")
+
+	if len(entries) != 1 || fieldMap(entries[0])["rows"] != int64(3) {
+		t.Fatalf("invalid query log. [entries:%v]", rec.Entries())
+	}
+
+	db.ExecContext(ctx, "bad")
+	rec.AssertContains(t, log.LogWarn, "sql failed")
+}
+
+func TestRowsOptionalInterfaces(t *testing.T) {
+	_, restore := logtest.Install()
+	defer restore()
+
+	sql.Register("logsql-fake-types", Wrap(fakeDriver{}))
+	db, err := sql.Open("logsql-fake-types", "")
+
+	if err != nil {
+		t.Fatalf("fail to open db. [err:%v]", err)
+	}
+
+	defer db.Close()
+	rows, err := db.QueryContext(context.Background(), "select id from t")
+
+	if err != nil {
+		t.Fatalf("fail to query. [err:%v]", err)
+	}
+
+	defer rows.Close()
+	types, err := rows.ColumnTypes()
+
+	if err != nil || len(types) != 1 || types[0].DatabaseTypeName() != "BIGINT" {
+		t.Fatalf("column types must be forwarded. [types:%v] [err:%v]", types, err)
+	}
+
+	for rows.Next() {
+	}
+
+	if !rows.NextResultSet() || !rows.Next() {
+		t.Fatalf("next result set must be forwarded. [err:%v]", rows.Err())
+	}
+}
+
+func TestStmtConvertArgs(t *testing.T) {
+	_, restore := logtest.Install()
+	defer restore()
+
+	sql.Register("logsql-fake-args", Wrap(fakeDriver{}))
+	db, err := sql.Open("logsql-fake-args", "")
+
+	if err != nil {
+		t.Fatalf("fail to open db. [err:%v]", err)
+	}
+
+	defer db.Close()
+
+	// 底层 Stmt 没有实现 driver.ColumnConverter，参数需要和不包装时一样被默认转换。
+	if _, err := db.Exec("update t set id = ?", uint32(7)); err != nil {
+		t.Fatalf("fail to exec. [err:%v]", err)
+	}
+
+	if len(lastArgs) != 1 || lastArgs[0] != int64(7) {
+		t.Fatalf("args must be converted. [args:%#v]", lastArgs)
+	}
+
+	_, err = db.BeginTx(context.Background(), &sql.TxOptions{ReadOnly: true})
+
+	if err == nil || !strings.Contains(err.Error(), "read-only") {
+		t.Fatalf("read-only transaction must be rejected. [err:%v]", err)
+	}
+}
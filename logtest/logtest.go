@@ -0,0 +1,121 @@
+// Package logtest 提供在单元测试中检查日志输出的工具。
+//
+// 典型用法：
+//
+//	func TestSomething(t *testing.T) {
+//		rec, restore := logtest.Install()
+//		defer restore()
+//
+//		doSomething()
+//		rec.AssertContains(t, log.LogError, "something failed")
+//	}
+package logtest
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	log "github.com/altstory/go-log"
+)
+
+// Recorder 在内存中记录所有日志。
+type Recorder struct {
+	mu      sync.Mutex
+	entries []log.Entry
+}
+
+var _ log.Sink = new(Recorder)
+
+// NewRecorder 创建一个 Recorder，可以放到 log.Config.Sinks 中使用。
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+// Install 创建一个 Recorder 并把它设置为默认日志的唯一输出，所有级别的日志都会被记录，不写任何文件。
+// 调用 restore 可以恢复成写 stdout/stderr 的默认日志。
+func Install() (rec *Recorder, restore func()) {
+	rec = NewRecorder()
+	log.Init(&log.Config{
+		Output:   log.OutputDiscard,
+		LogLevel: "debug",
+		Sinks:    []log.Sink{rec},
+		OnFatal:  func(entry *log.Entry) {},
+	})
+	restore = func() {
+		log.Init(nil)
+	}
+	return
+}
+
+// Write 记录一条日志。
+func (r *Recorder) Write(entry *log.Entry) error {
+	e := *entry
+	e.Fields = append([]log.Info(nil), entry.Fields...)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.entries = append(r.entries, e)
+	return nil
+}
+
+// Close 实现 io.Closer，不做任何事情。
+func (r *Recorder) Close() error {
+	return nil
+}
+
+// Entries 返回目前记录的所有日志。
+func (r *Recorder) Entries() []log.Entry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]log.Entry(nil), r.entries...)
+}
+
+// Reset 清空所有记录的日志。
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.entries = nil
+}
+
+// Find 返回所有级别为 level 并且内容包含 substr 的日志。
+func (r *Recorder) Find(level log.Level, substr string) []log.Entry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var found []log.Entry
+
+	for _, e := range r.entries {
+		if e.Level == level && strings.Contains(e.Message, substr) {
+			found = append(found, e)
+		}
+	}
+
+	return found
+}
+
+// Contains 判断是否有级别为 level 并且内容包含 substr 的日志。
+func (r *Recorder) Contains(level log.Level, substr string) bool {
+	return len(r.Find(level, substr)) != 0
+}
+
+// AssertContains 检查是否有级别为 level 并且内容包含 substr 的日志，没有则让测试失败。
+func (r *Recorder) AssertContains(t testing.TB, level log.Level, substr string) {
+	t.Helper()
+
+	if !r.Contains(level, substr) {
+		t.Errorf("logtest: no log at level %v contains %q. [entries:%v]", level, substr, r.Entries())
+	}
+}
+
+// AssertNotContains 检查是否没有级别为 level 并且内容包含 substr 的日志，有则让测试失败。
+func (r *Recorder) AssertNotContains(t testing.TB, level log.Level, substr string) {
+	t.Helper()
+
+	if found := r.Find(level, substr); len(found) != 0 {
+		t.Errorf("logtest: unexpected log at level %v contains %q. [entries:%v]", level, substr, found)
+	}
+}
@@ -0,0 +1,37 @@
+package logtest
+
+import (
+	"context"
+	"testing"
+
+	log "github.com/altstory/go-log"
+)
+
+func TestInstall(t *testing.T) {
+	rec, restore := Install()
+	defer restore()
+
+	ctx := log.WithMoreInfo(context.Background(), log.String("k", "v"))
+	log.Debugf(ctx, "debug %v", 1)
+	log.Errorf(ctx, "something failed")
+
+	rec.AssertContains(t, log.LogDebug, "debug 1")
+	rec.AssertContains(t, log.LogError, "something failed")
+	rec.AssertNotContains(t, log.LogInfo, "something")
+
+	entries := rec.Entries()
+
+	if len(entries) != 2 {
+		t.Fatalf("invalid entry count. [entries:%v]", entries)
+	}
+
+	if f := entries[1].Fields; len(f) != 1 || f[0].Key != "k" || f[0].Value != "v" {
+		t.Fatalf("fields should be recorded. [fields:%v]", f)
+	}
+
+	rec.Reset()
+
+	if len(rec.Entries()) != 0 {
+		t.Fatalf("entries should be cleared.")
+	}
+}
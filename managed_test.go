@@ -0,0 +1,50 @@
+package log
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestManagedLogger(t *testing.T) {
+	dir := filepath.Join(os.TempDir(), "go-log-managed-test")
+	os.RemoveAll(dir)
+	defer os.RemoveAll(dir)
+
+	logPath := filepath.Join(dir, "all.log")
+	ml, err := New(WithConfig(&Config{
+		LogPath:      logPath,
+		ErrorLogPath: filepath.Join(dir, "error.log"),
+	}))
+
+	if err != nil {
+		t.Fatalf("fail to create logger. [err:%v]", err)
+	}
+
+	defer ml.Close()
+
+	var l Logger = WithCallerSkip(ml, 0)
+	l.Infof(context.Background(), "managed")
+	managed, ok := l.(ManagedLogger)
+
+	if !ok {
+		t.Fatalf("WithCallerSkip must return a ManagedLogger.")
+	}
+
+	if err := managed.Flush(); err != nil {
+		t.Fatalf("fail to flush. [err:%v]", err)
+	}
+
+	if lines := readLines(t, logPath); len(lines) != 1 {
+		t.Fatalf("log must be flushed. [lines:%v]", lines)
+	}
+
+	if err := managed.Rotate(); err != nil {
+		t.Fatalf("fail to rotate. [err:%v]", err)
+	}
+
+	if _, ok := Nop().(ManagedLogger); !ok {
+		t.Fatalf("Nop must return a ManagedLogger.")
+	}
+}
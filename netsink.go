@@ -0,0 +1,280 @@
+package log
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 网络日志的默认配置。
+const (
+	DefaultNetworkDialTimeout  = 5 * time.Second
+	DefaultNetworkWriteTimeout = 5 * time.Second
+	DefaultNetworkMaxBackoff   = 30 * time.Second
+
+	minNetworkBackoff = 100 * time.Millisecond
+)
+
+// NetworkConfig 是 NetworkSink 的配置。
+type NetworkConfig struct {
+	Network string      `config:"network"` // Network 是网络类型，可以是 "tcp"、"udp" 或 "unix"，默认是 "tcp"。
+	Address string      `config:"address"` // Address 是日志接收方的地址，例如 "10.0.0.1:5170"。
+	TLS     *tls.Config `config:"-"`       // TLS 不为 nil 时使用 TLS 连接，只支持 tcp。
+
+	Format        string `config:"format"`         // Format 是日志格式，默认是 FormatJSON，每条日志一行。
+	BufferedLines int    `config:"buffered_lines"` // BufferedLines 是最多在内存中缓存的日志条数，默认是 DefaultBufferedLines，缓冲区满时新的日志会被丢弃。
+
+	DialTimeout  time.Duration `config:"dial_timeout"`  // DialTimeout 是建立连接的超时时间，默认是 DefaultNetworkDialTimeout。
+	WriteTimeout time.Duration `config:"write_timeout"` // WriteTimeout 是每次写入的超时时间，默认是 DefaultNetworkWriteTimeout。
+	MaxBackoff   time.Duration `config:"max_backoff"`   // MaxBackoff 是重连的最大间隔，连接失败后重连间隔从 100ms 开始翻倍，默认最大是 DefaultNetworkMaxBackoff。
+}
+
+// NetworkSink 将日志通过 TCP 或 UDP 发送给远端的日志接收方，适合没有本地磁盘的机器。
+// 日志先写入内存缓冲区，由单独的 goroutine 发送，连接断开后会按照指数退避自动重连，
+// 重连期间的日志会被丢弃，不会阻塞输出日志的 goroutine。
+type NetworkSink struct {
+	dropped int64 // dropped 需要 64 位对齐，必须放在第一个字段。
+
+	encoder Encoder
+	framed  bool
+	writer  *AsyncWriter
+	conn    *netConn
+}
+
+var _ Sink = new(NetworkSink)
+
+// NewNetworkSink 创建一个 NetworkSink，第一次发送日志时才会建立连接。
+func NewNetworkSink(config *NetworkConfig) *NetworkSink {
+	format := config.Format
+
+	if format == "" {
+		format = FormatJSON
+	}
+
+	return newNetworkSink(config, NewEncoder(format), format == FormatBinary)
+}
+
+func newNetworkSink(config *NetworkConfig, encoder Encoder, framed bool) *NetworkSink {
+	conn := newNetConn(config)
+	return newNetworkSinkWriter(config, encoder, framed, conn, conn)
+}
+
+// newNetworkSinkWriter 创建一个写入 w 的 NetworkSink，w 最终写入 conn，用于需要在连接之上处理分包等逻辑的协议。
+func newNetworkSinkWriter(config *NetworkConfig, encoder Encoder, framed bool, conn *netConn, w io.WriteCloser) *NetworkSink {
+	bufferedLines := config.BufferedLines
+
+	if bufferedLines <= 0 {
+		bufferedLines = DefaultBufferedLines
+	}
+
+	return &NetworkSink{
+		encoder: encoder,
+		framed:  framed,
+		writer:  NewAsyncWriter(w, bufferedLines),
+		conn:    conn,
+	}
+}
+
+// Write 将 entry 编码之后放入发送缓冲区，缓冲区满时返回错误。
+func (s *NetworkSink) Write(entry *Entry) error {
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	s.encoder.Encode(buf, entry)
+
+	if !s.framed {
+		buf.WriteByte('\n')
+	}
+
+	_, err := s.writer.Write(buf.Bytes())
+
+	if err != nil {
+		atomic.AddInt64(&s.dropped, 1)
+	}
+
+	return err
+}
+
+// SinkStats 返回缓冲区的使用情况、连接状态和发送失败的情况。
+func (s *NetworkSink) SinkStats() []SinkStats {
+	stats := SinkStats{
+		Name: fmt.Sprintf("%T", s),
+		Len:  s.writer.Len(),
+		Cap:  s.writer.Cap(),
+	}
+	s.conn.health.fill(&stats)
+	stats.Connected = s.conn.connected()
+	stats.Dropped = atomic.LoadInt64(&s.dropped)
+	return []SinkStats{stats}
+}
+
+// SetErrorHandler 设置发送失败时的回调。
+func (s *NetworkSink) SetErrorHandler(handler ErrorHandler) {
+	s.writer.SetErrorHandler(handler)
+}
+
+// Flush 等待缓冲区中的日志发送完毕，连接断开时这些日志会被丢弃。
+func (s *NetworkSink) Flush() error {
+	return s.writer.Flush()
+}
+
+// Close 发送缓冲区中剩余的日志并关闭连接。
+func (s *NetworkSink) Close() error {
+	return s.writer.Close()
+}
+
+var errNetworkBackoff = errors.New("go-log: network sink is waiting to reconnect")
+
+// netConn 是一个自动重连的网络连接。
+type netConn struct {
+	mu     sync.Mutex
+	config NetworkConfig
+	conn   net.Conn
+
+	backoff  time.Duration
+	nextDial time.Time
+
+	health      sinkHealth
+	isConnected int32
+}
+
+func newNetConn(config *NetworkConfig) *netConn {
+	c := &netConn{
+		config: *config,
+	}
+
+	if c.config.Network == "" {
+		c.config.Network = "tcp"
+	}
+
+	if c.config.DialTimeout <= 0 {
+		c.config.DialTimeout = DefaultNetworkDialTimeout
+	}
+
+	if c.config.WriteTimeout <= 0 {
+		c.config.WriteTimeout = DefaultNetworkWriteTimeout
+	}
+
+	if c.config.MaxBackoff <= 0 {
+		c.config.MaxBackoff = DefaultNetworkMaxBackoff
+	}
+
+	return c
+}
+
+var _ buffersWriter = new(netConn)
+
+func (c *netConn) Write(data []byte) (int, error) {
+	bufs := net.Buffers{data}
+	n, err := c.WriteBuffers(&bufs)
+	return int(n), err
+}
+
+// WriteBuffers 一次写入多条日志，TCP 和 unix socket 会使用 writev 减少系统调用，
+// UDP 等基于数据包的网络每条日志依然单独发送一个数据包。
+func (c *netConn) WriteBuffers(bufs *net.Buffers) (n int64, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.conn == nil {
+		if err = c.dial(); err != nil {
+			c.health.record(err)
+			return
+		}
+	}
+
+	c.conn.SetWriteDeadline(time.Now().Add(c.config.WriteTimeout))
+
+	if c.isPacket() {
+		for len(*bufs) > 0 && err == nil {
+			var written int
+			written, err = c.conn.Write((*bufs)[0])
+			n += int64(written)
+
+			if err == nil {
+				*bufs = (*bufs)[1:]
+			}
+		}
+	} else {
+		n, err = bufs.WriteTo(c.conn)
+	}
+
+	c.health.record(err)
+
+	if err != nil {
+		c.conn.Close()
+		c.conn = nil
+		atomic.StoreInt32(&c.isConnected, 0)
+	}
+
+	return
+}
+
+// isPacket 判断网络是不是基于数据包的，这种网络不能把多条日志合并成一个数据包发送。
+func (c *netConn) isPacket() bool {
+	switch c.config.Network {
+	case "udp", "udp4", "udp6", "unixgram":
+		return true
+	}
+
+	return false
+}
+
+// connected 返回当前是否已经建立连接，不会等待正在进行的写入。
+func (c *netConn) connected() bool {
+	return atomic.LoadInt32(&c.isConnected) != 0
+}
+
+// dial 建立连接，失败之后在退避时间内不会再次尝试。
+func (c *netConn) dial() error {
+	now := time.Now()
+
+	if now.Before(c.nextDial) {
+		return errNetworkBackoff
+	}
+
+	dialer := &net.Dialer{Timeout: c.config.DialTimeout}
+	var conn net.Conn
+	var err error
+
+	if c.config.TLS != nil {
+		conn, err = tls.DialWithDialer(dialer, c.config.Network, c.config.Address, c.config.TLS)
+	} else {
+		conn, err = dialer.Dial(c.config.Network, c.config.Address)
+	}
+
+	if err != nil {
+		if c.backoff < minNetworkBackoff {
+			c.backoff = minNetworkBackoff
+		} else if c.backoff *= 2; c.backoff > c.config.MaxBackoff {
+			c.backoff = c.config.MaxBackoff
+		}
+
+		c.nextDial = now.Add(c.backoff)
+		return err
+	}
+
+	c.conn = conn
+	c.backoff = 0
+	atomic.StoreInt32(&c.isConnected, 1)
+	return nil
+}
+
+func (c *netConn) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.conn == nil {
+		return nil
+	}
+
+	err := c.conn.Close()
+	c.conn = nil
+	atomic.StoreInt32(&c.isConnected, 0)
+	return err
+}
@@ -0,0 +1,77 @@
+package log
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNetworkSink(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+
+	if err != nil {
+		t.Fatalf("fail to listen. [err:%v]", err)
+	}
+
+	defer ln.Close()
+	lines := make(chan string, 10)
+
+	go func() {
+		conn, err := ln.Accept()
+
+		if err != nil {
+			return
+		}
+
+		defer conn.Close()
+		scanner := bufio.NewScanner(conn)
+
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+	}()
+
+	sink := NewNetworkSink(&NetworkConfig{
+		Address: ln.Addr().String(),
+	})
+	defer sink.Close()
+
+	sink.Write(&Entry{Level: LogInfo, Time: time.Now(), Message: "hello"})
+	sink.Flush()
+
+	select {
+	case line := <-lines:
+		if !strings.Contains(line, `"msg":"hello"`) {
+			t.Fatalf("invalid line. [line:%v]", line)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timeout waiting for log.")
+	}
+}
+
+func TestNetConnBackoff(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+
+	if err != nil {
+		t.Fatalf("fail to listen. [err:%v]", err)
+	}
+
+	addr := ln.Addr().String()
+	ln.Close()
+
+	c := newNetConn(&NetworkConfig{Address: addr})
+
+	if _, err := c.Write([]byte("a")); err == nil || err == errNetworkBackoff {
+		t.Fatalf("dial should fail. [err:%v]", err)
+	}
+
+	if _, err := c.Write([]byte("a")); err != errNetworkBackoff {
+		t.Fatalf("should wait before reconnecting. [err:%v]", err)
+	}
+
+	if c.backoff != minNetworkBackoff {
+		t.Fatalf("invalid backoff. [backoff:%v]", c.backoff)
+	}
+}
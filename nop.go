@@ -0,0 +1,27 @@
+package log
+
+import "io/ioutil"
+
+// logNop 比所有日志级别都低，用于关闭所有日志。
+const logNop = logAudit - 1
+
+// Nop 返回一个丢弃所有日志的 Logger，Fatalf 也不会终止程序。
+// 适合在测试和性能测试中让依赖这个库的代码保持安静。
+func Nop() ManagedLogger {
+	return newNopLogger()
+}
+
+// InitNop 将默认日志设置为丢弃所有日志的 Logger，效果和 Nop 相同。
+func InitNop() {
+	setDefaultLogger(newNopLogger())
+}
+
+func newNopLogger() *logger {
+	return &logger{
+		maxLevel:     logNop,
+		verboseLevel: logNop,
+		encoder:      textEncoder{},
+		onFatal:      func(entry *Entry) {},
+		audit:        dummyCloser{Writer: ioutil.Discard},
+	}
+}
@@ -0,0 +1,156 @@
+package log
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// 展开 map 和结构体的限制。
+const (
+	MaxObjectDepth  = 3  // MaxObjectDepth 是最多展开的嵌套层数，更深的值直接按照 "%v" 输出。
+	MaxObjectFields = 64 // MaxObjectFields 是一次最多展开的字段数，超出的字段会被丢弃。
+)
+
+// Fields 将 m 展开成多个 Info，key 按照字典序排列，值中的 map 和结构体会用 Object 的规则继续展开。
+//
+//	ctx = log.WithMoreInfo(ctx, log.Fields(map[string]interface{}{"user": uid, "order": order})...)
+func Fields(m map[string]interface{}) []Info {
+	keys := make([]string, 0, len(m))
+
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+	fields := make([]Info, 0, len(m))
+
+	for _, k := range keys {
+		fields = expandObject(fields, k, reflect.ValueOf(m[k]), 1)
+	}
+
+	return fields
+}
+
+// Object 将结构体或者 key 为字符串的 map 展开成多个 Info，key 是 "key.字段名"，key 为空时直接使用字段名。
+// 字段名优先使用 `log:"name"` tag，其次是 `json:"name"` tag，tag 为 "-" 的字段和未导出的字段会被忽略，
+// 没有 tag 的匿名结构体字段会展开到当前层级。实现了 fmt.Stringer 或 error 的值不会被展开。
+// 最多展开 MaxObjectDepth 层和 MaxObjectFields 个字段，避免输出一个巨大的 "%+v"。
+func Object(key string, v interface{}) []Info {
+	return expandObject(nil, key, reflect.ValueOf(v), 0)
+}
+
+var (
+	stringerType = reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+	errorType    = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+func expandObject(fields []Info, key string, v reflect.Value, depth int) []Info {
+	if len(fields) >= MaxObjectFields {
+		return fields
+	}
+
+	for v.IsValid() && (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			return append(fields, Info{Key: key, Value: nil})
+		}
+
+		if v.Type().Implements(stringerType) || v.Type().Implements(errorType) {
+			break
+		}
+
+		v = v.Elem()
+	}
+
+	if !v.IsValid() {
+		return append(fields, Info{Key: key, Value: nil})
+	}
+
+	t := v.Type()
+
+	if depth >= MaxObjectDepth || t.Implements(stringerType) || t.Implements(errorType) {
+		return append(fields, Info{Key: key, Value: v.Interface()})
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		for i := 0; i < t.NumField() && len(fields) < MaxObjectFields; i++ {
+			sf := t.Field(i)
+
+			if sf.PkgPath != "" && !sf.Anonymous {
+				continue
+			}
+
+			name, tagged := objectFieldName(sf)
+
+			if name == "-" {
+				continue
+			}
+
+			// 没有 tag 的匿名结构体展开到当前层级。
+			if sf.Anonymous && !tagged && indirectKind(sf.Type) == reflect.Struct {
+				fields = expandObject(fields, key, v.Field(i), depth)
+				continue
+			}
+
+			if sf.PkgPath != "" {
+				continue
+			}
+
+			fields = expandObject(fields, joinObjectKey(key, name), v.Field(i), depth+1)
+		}
+
+		return fields
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			break
+		}
+
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return keys[i].String() < keys[j].String()
+		})
+
+		for _, k := range keys {
+			fields = expandObject(fields, joinObjectKey(key, k.String()), v.MapIndex(k), depth+1)
+		}
+
+		return fields
+	}
+
+	return append(fields, Info{Key: key, Value: v.Interface()})
+}
+
+// objectFieldName 返回结构体字段在日志中的名字，tagged 表示名字是否来自 tag。
+func objectFieldName(sf reflect.StructField) (name string, tagged bool) {
+	for _, tag := range []string{"log", "json"} {
+		if value, ok := sf.Tag.Lookup(tag); ok {
+			if idx := strings.IndexByte(value, ','); idx >= 0 {
+				value = value[:idx]
+			}
+
+			if value != "" {
+				return value, true
+			}
+		}
+	}
+
+	return sf.Name, false
+}
+
+func indirectKind(t reflect.Type) reflect.Kind {
+	if t.Kind() == reflect.Ptr {
+		return t.Elem().Kind()
+	}
+
+	return t.Kind()
+}
+
+func joinObjectKey(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+
+	return prefix + "." + name
+}
@@ -0,0 +1,87 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+type objectBase struct {
+	ID int `json:"id"`
+}
+
+type objectUser struct {
+	objectBase
+
+	Name    string            `log:"name"`
+	Secret  string            `log:"-"`
+	Email   string            `json:"email,omitempty"`
+	Created time.Time         `json:"created"`
+	Err     error             `json:"err"`
+	Tags    map[string]string `json:"tags"`
+	Friend  *objectUser       `json:"friend"`
+	private int
+}
+
+func TestObject(t *testing.T) {
+	now := time.Unix(0, 0)
+	err := errors.New("failed")
+	user := &objectUser{
+		objectBase: objectBase{ID: 1},
+		Name:       "alice",
+		Secret:     "x",
+		Email:      "a@b.c",
+		Created:    now,
+		Err:        err,
+		Tags:       map[string]string{"b": "2", "a": "1"},
+		Friend: &objectUser{
+			Name:   "bob",
+			Friend: &objectUser{Name: "carol"},
+		},
+	}
+
+	fields := Object("user", user)
+	actual := fmt.Sprint(fields)
+	expected := fmt.Sprint([]Info{
+		{Key: "user.id", Value: 1},
+		{Key: "user.name", Value: "alice"},
+		{Key: "user.email", Value: "a@b.c"},
+		{Key: "user.created", Value: now},
+		{Key: "user.err", Value: err},
+		{Key: "user.tags.a", Value: "1"},
+		{Key: "user.tags.b", Value: "2"},
+		{Key: "user.friend.id", Value: 0},
+		{Key: "user.friend.name", Value: "bob"},
+		{Key: "user.friend.email", Value: ""},
+		{Key: "user.friend.created", Value: time.Time{}},
+		{Key: "user.friend.err", Value: nil},
+		{Key: "user.friend.friend.id", Value: 0},
+		{Key: "user.friend.friend.name", Value: "carol"},
+		{Key: "user.friend.friend.email", Value: ""},
+		{Key: "user.friend.friend.created", Value: time.Time{}},
+		{Key: "user.friend.friend.err", Value: nil},
+		{Key: "user.friend.friend.tags", Value: map[string]string(nil)},
+		{Key: "user.friend.friend.friend", Value: (*objectUser)(nil)},
+	})
+
+	if actual != expected {
+		t.Fatalf("invalid object fields.\n  expected:\n%v\n  actual:\n%v", expected, actual)
+	}
+
+	fields = Fields(map[string]interface{}{"b": 2, "a": map[string]int{"x": 1}})
+
+	if actual := fmt.Sprint(fields); actual != "[{a.x 1} {b 2}]" {
+		t.Fatalf("invalid map fields. [fields:%v]", actual)
+	}
+
+	big := map[string]int{}
+
+	for i := 0; i < MaxObjectFields*2; i++ {
+		big[fmt.Sprint(i)] = i
+	}
+
+	if fields := Object("", big); len(fields) != MaxObjectFields {
+		t.Fatalf("fields must be limited. [len:%v]", len(fields))
+	}
+}
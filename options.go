@@ -0,0 +1,106 @@
+package log
+
+import "time"
+
+// Option 是 New 的配置项。
+type Option func(opts *options)
+
+type options struct {
+	config  Config
+	encoder Encoder
+}
+
+// New 根据 opts 创建一个新的 Logger，不会修改默认日志。
+// 没有任何 opts 时效果和 InitE(&Config{}) 一样，New 返回的 Logger 需要调用者自己 Close。
+func New(opts ...Option) (ManagedLogger, error) {
+	o := &options{}
+
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	if err := validateConfig(&o.config); err != nil {
+		return nil, err
+	}
+
+	l := newLogger(&o.config)
+
+	if o.encoder != nil {
+		l.encoder = o.encoder
+	}
+
+	return l, nil
+}
+
+// WithConfig 使用 config 作为基础配置，之后的 Option 会覆盖其中对应的配置。
+func WithConfig(config *Config) Option {
+	return func(opts *options) {
+		if config != nil {
+			opts.config = *config
+		}
+	}
+}
+
+// WithLevel 设置日志级别。
+func WithLevel(level Level) Option {
+	return func(opts *options) {
+		opts.config.LogLevel = level.String()
+	}
+}
+
+// WithPath 设置日志文件名。
+func WithPath(path string) Option {
+	return func(opts *options) {
+		opts.config.LogPath = path
+	}
+}
+
+// WithErrorPath 设置错误日志文件名和错误日志级别。
+func WithErrorPath(path string, level Level) Option {
+	return func(opts *options) {
+		opts.config.ErrorLogPath = path
+		opts.config.ErrorLogLevel = level.String()
+	}
+}
+
+// WithOutput 设置日志输出方式，可以是 OutputFile、OutputStdout、OutputSidecar 或 OutputDiscard。
+func WithOutput(output string) Option {
+	return func(opts *options) {
+		opts.config.Output = output
+	}
+}
+
+// WithFormat 设置日志格式，可以是 FormatText、FormatJSON、FormatLogfmt、FormatTSV 或 FormatBinary。
+func WithFormat(format string) Option {
+	return func(opts *options) {
+		opts.config.Format = format
+	}
+}
+
+// WithEncoder 使用自定义的 Encoder 编码日志，设置之后 WithFormat 不再生效。
+func WithEncoder(encoder Encoder) Option {
+	return func(opts *options) {
+		opts.encoder = encoder
+	}
+}
+
+// WithSinks 添加额外的日志输出目标。
+func WithSinks(sinks ...Sink) Option {
+	return func(opts *options) {
+		opts.config.Sinks = append(opts.config.Sinks, sinks...)
+	}
+}
+
+// WithClock 设置获取当前时间的函数。
+func WithClock(clock func() time.Time) Option {
+	return func(opts *options) {
+		opts.config.Clock = clock
+	}
+}
+
+// WithOnFatal 设置 Fatalf 输出日志之后的行为。
+func WithOnFatal(onFatal FatalHandler) Option {
+	return func(opts *options) {
+		opts.config.OnFatal = onFatal
+	}
+}
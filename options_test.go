@@ -0,0 +1,54 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+type sinkFunc func(entry *Entry) error
+
+func (f sinkFunc) Write(entry *Entry) error {
+	return f(entry)
+}
+
+func (f sinkFunc) Close() error {
+	return nil
+}
+
+type upperEncoder struct{}
+
+func (upperEncoder) Encode(buf *bytes.Buffer, entry *Entry) {
+	buf.WriteString(entry.Level.String())
+}
+
+func TestNew(t *testing.T) {
+	var entries []*Entry
+	sink := sinkFunc(func(entry *Entry) error {
+		entries = append(entries, entry)
+		return nil
+	})
+
+	l, err := New(WithOutput(OutputDiscard), WithLevel(LogWarn), WithSinks(sink), WithEncoder(upperEncoder{}))
+
+	if err != nil {
+		t.Fatalf("fail to create logger. [err:%v]", err)
+	}
+
+	defer l.Close()
+	ctx := context.Background()
+	l.Infof(ctx, "ignored")
+	l.Warnf(ctx, "warn")
+
+	if len(entries) != 1 || entries[0].Message != "warn" {
+		t.Fatalf("invalid entries. [entries:%v]", entries)
+	}
+
+	if _, ok := l.(*logger).encoder.(upperEncoder); !ok {
+		t.Fatalf("encoder should be replaced.")
+	}
+
+	if _, err := New(WithFormat("xml")); err == nil {
+		t.Fatalf("invalid format should be rejected.")
+	}
+}
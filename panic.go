@@ -0,0 +1,164 @@
+package log
+
+import (
+	"context"
+	"path"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const maxPanicStackDepth = 32
+
+// DefaultCrashFlushTimeout 是 Main 和 Go 在程序崩溃之前最多等待刷新日志的时间。
+const DefaultCrashFlushTimeout = 2 * time.Second
+
+// RecoverAndLog 拦截 panic 并输出一条 Error 日志，日志中包含 panic 的值和调用栈，输出后会刷新缓冲区。
+// 这个函数必须直接用 defer 调用才能拦截 panic，一般用在 goroutine 的入口处：
+//
+//	go func() {
+//		defer log.RecoverAndLog(ctx)
+//		// ...
+//	}()
+func RecoverAndLog(ctx context.Context) {
+	if r := recover(); r != nil {
+		logPanic(ctx, r)
+	}
+}
+
+// RecoverAndRepanic 和 RecoverAndLog 一样输出 panic 日志，然后用原来的值重新 panic。
+// 这个函数必须直接用 defer 调用。
+func RecoverAndRepanic(ctx context.Context) {
+	if r := recover(); r != nil {
+		logPanic(ctx, r)
+		panic(r)
+	}
+}
+
+// CapturePanic 执行 f，如果 f 发生 panic 就输出 panic 日志，并且返回 panic 的值。
+func CapturePanic(ctx context.Context, f func()) (recovered interface{}) {
+	defer func() {
+		if r := recover(); r != nil {
+			logPanic(ctx, r)
+			recovered = r
+		}
+	}()
+
+	f()
+	return
+}
+
+// Main 执行程序的主逻辑 f，如果 f 发生没有被拦截的 panic，先输出 panic 日志并刷新缓冲区，
+// 再用原来的值重新 panic 让程序崩溃，避免缓冲区中的日志随着进程一起丢失。
+// 刷新最多等待 DefaultCrashFlushTimeout，磁盘卡住时程序依然可以退出。一般在 main 函数中使用：
+//
+//	func main() {
+//		log.Init(config)
+//		log.Main(ctx, run)
+//	}
+func Main(ctx context.Context, f func()) {
+	defer recoverAndCrash(ctx)
+	f()
+}
+
+// Go 在新的 goroutine 中执行 f，f 发生 panic 时和 Main 一样先输出日志并刷新缓冲区再让程序崩溃。
+// Go 语言无法拦截其他 goroutine 的 panic，所有可能 panic 的 goroutine 都需要用 Go 启动才能保证日志不丢。
+func Go(ctx context.Context, f func()) {
+	go func() {
+		defer recoverAndCrash(ctx)
+		f()
+	}()
+}
+
+// recoverAndCrash 拦截 panic，输出日志并刷新缓冲区之后重新 panic，必须直接用 defer 调用。
+func recoverAndCrash(ctx context.Context) {
+	r := recover()
+
+	if r == nil {
+		return
+	}
+
+	l := defaultLogger()
+
+	// Fatalf 触发的 panic 已经输出了日志。
+	if _, ok := r.(*FatalError); !ok {
+		outputPanic(ctx, l, r)
+	}
+
+	flushCtx, cancel := context.WithTimeout(context.Background(), DefaultCrashFlushTimeout)
+	l.FlushContext(flushCtx)
+	cancel()
+
+	panic(r)
+}
+
+func logPanic(ctx context.Context, r interface{}) {
+	l := defaultLogger()
+	outputPanic(ctx, l, r)
+	l.Flush()
+}
+
+func outputPanic(ctx context.Context, l *logger, r interface{}) {
+	// 和普通日志一样遵守 tag 和 package 的日志级别。
+	if l.disabled(ctx, LogError) {
+		return
+	}
+
+	pc, stack := panicStack()
+
+	if !l.enabled(ctx, pc, LogError) {
+		return
+	}
+
+	l.output(WithMoreInfo(ctx, Info{Key: "stack", Value: stack}), pc, LogError, "panic: %v", r)
+}
+
+// panicStack 返回发生 panic 的位置和当时的调用栈。
+// 调用栈格式为 "file.go:12@pkg.Func <- file.go:34@pkg.Caller"。
+func panicStack() (pc uintptr, stack string) {
+	pcs := make([]uintptr, 64)
+	n := runtime.Callers(3, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	all := make([]runtime.Frame, 0, n)
+	start := 0
+
+	for {
+		frame, more := frames.Next()
+		all = append(all, frame)
+
+		// 跳过 runtime 中处理 panic 的调用栈，从 panic 的位置开始记录。
+		if frame.Function == "runtime.gopanic" {
+			start = len(all)
+		}
+
+		if !more {
+			break
+		}
+	}
+
+	all = all[start:]
+
+	if len(all) > maxPanicStackDepth {
+		all = all[:maxPanicStackDepth]
+	}
+
+	if len(all) == 0 {
+		return
+	}
+
+	// 如果 panic 是 runtime 触发的，例如空指针，跳过 runtime 内部的调用栈。
+	for len(all) > 1 && strings.HasPrefix(all[0].Function, "runtime.") {
+		all = all[1:]
+	}
+
+	lines := make([]string, 0, len(all))
+
+	for _, frame := range all {
+		lines = append(lines, path.Base(frame.File)+":"+strconv.Itoa(frame.Line)+"@"+frame.Function)
+	}
+
+	pc = all[0].PC
+	stack = strings.Join(lines, " <- ")
+	return
+}
@@ -0,0 +1,104 @@
+package log
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCapturePanic(t *testing.T) {
+	dir := filepath.Join(os.TempDir(), "go-log-panic-test")
+	os.RemoveAll(dir)
+	defer os.RemoveAll(dir)
+
+	logPath := filepath.Join(dir, "all.log")
+	Init(&Config{
+		LogPath:      logPath,
+		ErrorLogPath: logPath,
+	})
+	defer Init(nil)
+
+	r := CapturePanic(context.Background(), func() {
+		panic("oops")
+	})
+
+	if r != "oops" {
+		t.Fatalf("CapturePanic should return panic value. [r:%v]", r)
+	}
+
+	data, err := ioutil.ReadFile(logPath)
+
+	if err != nil {
+		t.Fatalf("fail to read log file. [err:%v]", err)
+	}
+
+	line := string(data)
+
+	if !strings.Contains(line, "[ERROR]") || !strings.Contains(line, "panic: oops") {
+		t.Fatalf("invalid panic log. [line:%v]", line)
+	}
+
+	if !strings.Contains(line, "panic_test.go:25@<std>/go-log.TestCapturePanic.func1]") {
+		t.Fatalf("caller should be the panic site. [line:%v]", line)
+	}
+}
+
+func TestMainFlushesOnPanic(t *testing.T) {
+	l, lines := newTestLogger(&Config{})
+	setDefaultLogger(l)
+	defer Init(nil)
+
+	r := CapturePanic(context.Background(), func() {
+		Main(context.Background(), func() {
+			panic("crash")
+		})
+	})
+
+	if r != "crash" {
+		t.Fatalf("Main must panic again with the original value. [r:%v]", r)
+	}
+
+	// 外层 CapturePanic 也会输出一条日志。
+	if len(*lines) != 2 || !strings.Contains((*lines)[0], "panic: crash") {
+		t.Fatalf("Main must log the panic. [lines:%v]", *lines)
+	}
+
+	*lines = nil
+	fatal := &FatalError{Message: "fatal"}
+	CapturePanic(context.Background(), func() {
+		Main(context.Background(), func() {
+			panic(fatal)
+		})
+	})
+
+	if len(*lines) != 1 {
+		t.Fatalf("Main must not log FatalError again. [lines:%v]", *lines)
+	}
+}
+
+func TestCapturePanicTagLevel(t *testing.T) {
+	l, lines := newTestLogger(&Config{
+		TagLevels: map[string]string{"quiet": "fatal"},
+	})
+	setDefaultLogger(l)
+	defer Init(nil)
+
+	CapturePanic(WithTag(context.Background(), "quiet"), func() {
+		panic("oops")
+	})
+
+	if len(*lines) != 0 {
+		t.Fatalf("panic log must follow tag level. [lines:%v]", *lines)
+	}
+
+	CapturePanic(context.Background(), func() {
+		panic("oops")
+	})
+
+	if len(*lines) != 1 {
+		t.Fatalf("panic log must be written. [lines:%v]", *lines)
+	}
+}
@@ -0,0 +1,46 @@
+package log
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestPanicf(t *testing.T) {
+	l, lines := newTestLogger(&Config{})
+	setDefaultLogger(l)
+	defer Init(nil)
+
+	r := CapturePanic(context.Background(), func() {
+		Panicf(context.Background(), "bad state %v", 42)
+	})
+
+	if r != "bad state 42" {
+		t.Fatalf("panic value must be the formatted message. [recovered:%v]", r)
+	}
+
+	if len(*lines) == 0 || !strings.HasPrefix((*lines)[0], "[PANIC]") || !strings.HasSuffix((*lines)[0], "||bad state 42\n") {
+		t.Fatalf("invalid panic line. [lines:%v]", *lines)
+	}
+
+	if !strings.Contains((*lines)[0], "panicf_test.go") {
+		t.Fatalf("caller must be the caller of Panicf. [line:%v]", (*lines)[0])
+	}
+
+	if !LogPanic.within(LogError) || LogPanic.within(LogFatal) {
+		t.Fatalf("panic level must be between error and fatal.")
+	}
+}
+
+func TestLoggerPanicf(t *testing.T) {
+	l, lines := newTestLogger(&Config{})
+	var logger ManagedLogger = l
+
+	r := CapturePanic(context.Background(), func() {
+		logger.Panicf(context.Background(), "bad state %v", 42)
+	})
+
+	if r != "bad state 42" || len(*lines) == 0 || !strings.Contains((*lines)[0], "panicf_test.go") {
+		t.Fatalf("invalid panic. [recovered:%v] [lines:%v]", r, *lines)
+	}
+}
@@ -0,0 +1,175 @@
+package log
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	// TagHeader 是传递 tag 的 HTTP header。
+	TagHeader = "X-Log-Tag"
+
+	// FieldsHeader 是传递 WithMoreInfo 字段的 HTTP header，格式和 URL query 一样，例如 "uid=123&from=app"。
+	FieldsHeader = "X-Log-Fields"
+
+	// MaxPropagatedFieldsSize 是 FieldsHeader 的最大长度，超出的字段不会传递，也不会被解析。
+	MaxPropagatedFieldsSize = 4096
+
+	// MaxPropagatedFields 是 FieldsHeader 中最多的字段个数，超出的字段不会传递，也不会被解析。
+	MaxPropagatedFields = 32
+)
+
+// ExtractOption 是 ExtractHTTP 的配置项。
+type ExtractOption func(opts *extractOptions)
+
+type extractOptions struct {
+	trustUpstream bool
+	forceDebug    bool
+}
+
+// TrustUpstream 让 ExtractHTTP 恢复上游通过 TagHeader 和 FieldsHeader 传递的 tag 和字段。
+// tag 会影响 TagLevels 和 SetTagLevel 的判断，字段会出现在每条日志中，
+// 只有请求全部来自可信的内部服务时才能使用，否则任何调用者都可以伪造日志的 tag 和字段。
+func TrustUpstream() ExtractOption {
+	return func(opts *extractOptions) {
+		opts.trustUpstream = true
+	}
+}
+
+// InjectHTTP 将 ctx 里面的请求 ID、tag、WithMoreInfo 字段和 WithForceDebug 标记写入 header，用于调用下游服务时传递日志上下文，
+// 下游服务用 ExtractHTTP 恢复之后，输出的日志会带上相同的 tag 和字段。
+// 所有字段的值都会转成字符串，编码之后超过 MaxPropagatedFieldsSize 的字段会被丢弃。
+func InjectHTTP(ctx context.Context, header http.Header) {
+	InjectRequestID(ctx, header)
+
+	if t := tag(ctx); t != "" {
+		header.Set(TagHeader, t)
+	}
+
+	if fields := encodeFields(resolveFields(findMoreInfo(ctx))); fields != "" {
+		header.Set(FieldsHeader, fields)
+	}
+
+	if IsForceDebug(ctx) {
+		header.Set(ForceDebugHeader, "1")
+	}
+}
+
+// AllowForceDebug 让 ExtractHTTP 接受 ForceDebugHeader，被标记的请求会输出所有级别的日志。
+// 任何能发送这个 header 的调用者都可以让服务输出大量日志，只有请求全部来自可信的内部服务，
+// 或者网关会删除外部请求中的这个 header 时才能使用。
+func AllowForceDebug() ExtractOption {
+	return func(opts *extractOptions) {
+		opts.forceDebug = true
+	}
+}
+
+// ExtractHTTP 从 header 中读取 InjectHTTP 写入的请求 ID、tag、字段和强制调试标记并保存到 ctx 里面。
+// 和 RequestIDFromHeader 不同，header 中没有请求 ID 时不会生成新的请求 ID。
+// 默认只恢复请求 ID，tag 和字段需要 TrustUpstream 才会恢复，强制调试标记需要 AllowForceDebug 才会恢复。
+func ExtractHTTP(ctx context.Context, header http.Header, opts ...ExtractOption) context.Context {
+	o := &extractOptions{}
+
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	if id := header.Get(RequestIDHeader); id != "" {
+		ctx = SetRequestID(ctx, id)
+	}
+
+	if o.trustUpstream {
+		if t := header.Get(TagHeader); t != "" {
+			ctx = WithTag(ctx, t)
+		}
+
+		if fields := decodeFields(header.Get(FieldsHeader)); len(fields) != 0 {
+			ctx = WithMoreInfo(ctx, fields...)
+		}
+	}
+
+	if o.forceDebug && header.Get(ForceDebugHeader) == "1" {
+		ctx = WithForceDebug(ctx)
+	}
+
+	return ctx
+}
+
+// encodeFields 把 fields 编码成 URL query 格式，保持字段原有的顺序。
+func encodeFields(fields []Info) string {
+	var buf []byte
+
+	if len(fields) > MaxPropagatedFields {
+		fields = fields[:MaxPropagatedFields]
+	}
+
+	for _, info := range fields {
+		n := len(buf)
+
+		if n > 0 {
+			buf = append(buf, '&')
+		}
+
+		buf = append(buf, url.QueryEscape(info.Key)...)
+		buf = append(buf, '=')
+		buf = append(buf, url.QueryEscape(valueString(info.Value))...)
+
+		if len(buf) > MaxPropagatedFieldsSize {
+			buf = buf[:n]
+			break
+		}
+	}
+
+	return string(buf)
+}
+
+// decodeFields 解析 encodeFields 的结果，格式错误的字段会被忽略。
+// 超过 MaxPropagatedFieldsSize 的部分和超过 MaxPropagatedFields 个的字段也会被忽略，防止调用者用很大的 header 放大日志。
+func decodeFields(s string) []Info {
+	var fields []Info
+
+	if len(s) > MaxPropagatedFieldsSize {
+		s = s[:MaxPropagatedFieldsSize]
+
+		// 最后一个字段可能被截断了。
+		if i := strings.LastIndexByte(s, '&'); i >= 0 {
+			s = s[:i]
+		} else {
+			s = ""
+		}
+	}
+
+	for s != "" && len(fields) < MaxPropagatedFields {
+		pair := s
+
+		if i := strings.IndexByte(s, '&'); i >= 0 {
+			pair, s = s[:i], s[i+1:]
+		} else {
+			s = ""
+		}
+
+		i := strings.IndexByte(pair, '=')
+
+		if i <= 0 {
+			continue
+		}
+
+		key, err := url.QueryUnescape(pair[:i])
+
+		if err != nil {
+			continue
+		}
+
+		value, err := url.QueryUnescape(pair[i+1:])
+
+		if err != nil {
+			continue
+		}
+
+		fields = append(fields, String(key, value))
+	}
+
+	return fields
+}
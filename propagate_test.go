@@ -0,0 +1,57 @@
+package log
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestPropagateHTTP(t *testing.T) {
+	ctx := SetRequestID(context.Background(), "req-1")
+	ctx = WithTag(ctx, "order")
+	ctx = WithMoreInfo(ctx, String("user", "alice&bob"), Int("id", 42), String("note", "a=b c"))
+
+	header := http.Header{}
+	InjectHTTP(ctx, header)
+
+	if fields := header.Get(FieldsHeader); fields != "user=alice%26bob&id=42&note=a%3Db+c" {
+		t.Fatalf("unexpected fields header. [fields:%v]", fields)
+	}
+
+	l, lines := newTestLogger(&Config{})
+	l.Infof(ExtractHTTP(context.Background(), header, TrustUpstream()), "hello")
+
+	if len(*lines) != 1 || !strings.Contains((*lines)[0], "order||request_id=req-1||user=alice&bob||id=42||note=a=b c||hello") {
+		t.Fatalf("context should be restored. [lines:%v]", *lines)
+	}
+
+	if ctx := ExtractHTTP(context.Background(), header); RequestID(ctx) != "req-1" || tag(ctx) != "" || findMoreInfo(ctx) != nil {
+		t.Fatalf("tag and fields must not be restored without TrustUpstream.")
+	}
+
+	if ctx := ExtractHTTP(context.Background(), http.Header{}); RequestID(ctx) != "" || tag(ctx) != "" || findMoreInfo(ctx) != nil {
+		t.Fatalf("empty header must not change ctx.")
+	}
+}
+
+func TestPropagateFieldsLimit(t *testing.T) {
+	long := strings.Repeat("x", MaxPropagatedFieldsSize)
+	fields := encodeFields([]Info{String("a", "1"), String("long", long), String("b", "2")})
+
+	if fields != "a=1" {
+		t.Fatalf("fields over the limit must be dropped. [fields:%v]", fields)
+	}
+
+	if decoded := decodeFields("a=1&bad&=x&b=%zz&c=3"); len(decoded) != 2 || decoded[0].Key != "a" || decoded[1].Key != "c" {
+		t.Fatalf("malformed fields must be ignored. [decoded:%v]", decoded)
+	}
+
+	if decoded := decodeFields("a=1&long=" + long); len(decoded) != 1 || decoded[0].Key != "a" {
+		t.Fatalf("fields over the size limit must not be decoded. [decoded:%v]", decoded)
+	}
+
+	if decoded := decodeFields(strings.Repeat("k=v&", MaxPropagatedFields*2)); len(decoded) != MaxPropagatedFields {
+		t.Fatalf("fields over the count limit must not be decoded. [count:%v]", len(decoded))
+	}
+}
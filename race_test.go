@@ -0,0 +1,7 @@
+//go:build race
+// +build race
+
+package log
+
+// raceEnabled 表示是否开启了 race 检测，开启之后内存分配的次数会变多。
+const raceEnabled = true
@@ -0,0 +1,96 @@
+package log
+
+import (
+	"io"
+	"net/http"
+	"sync"
+)
+
+// recentBuffer 是保存最近若干行日志的环形缓冲区。
+type recentBuffer struct {
+	mu    sync.Mutex
+	lines [][]byte
+	next  int
+	full  bool
+}
+
+func newRecentBuffer(size int) *recentBuffer {
+	return &recentBuffer{
+		lines: make([][]byte, size),
+	}
+}
+
+// add 复制 line 并放入缓冲区，缓冲区满时覆盖最早的一行。
+func (r *recentBuffer) add(line []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.lines[r.next] = append(r.lines[r.next][:0], line...)
+	r.next++
+
+	if r.next == len(r.lines) {
+		r.next = 0
+		r.full = true
+	}
+}
+
+// dump 按照从旧到新的顺序将缓冲区中的日志写入 w。
+// add 会复用缓冲区的内存，所以先在锁内复制所有日志，再在锁外写入 w，避免 w 太慢时阻塞所有日志。
+func (r *recentBuffer) dump(w io.Writer) error {
+	_, err := w.Write(r.snapshot())
+	return err
+}
+
+// snapshot 按照从旧到新的顺序复制缓冲区中的日志。
+func (r *recentBuffer) snapshot() []byte {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var data []byte
+
+	if r.full {
+		for _, line := range r.lines[r.next:] {
+			data = append(data, line...)
+		}
+	}
+
+	for _, line := range r.lines[:r.next] {
+		data = append(data, line...)
+	}
+
+	return data
+}
+
+// keepRecent 将一条因为日志级别没有输出的日志放入最近日志的缓冲区。
+func (l *logger) keepRecent(entry *Entry) {
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	l.encoder.Encode(buf, entry)
+
+	if !selfDelimited(l.encoder) {
+		buf.WriteByte('\n')
+	}
+
+	l.recent.add(buf.Bytes())
+}
+
+// DumpRecent 将默认日志最近输出的日志按照从旧到新的顺序写入 w，格式和日志文件相同，
+// 一般在发生错误时调用，用来查看错误之前的上下文。需要设置 Config.RecentEntries，否则什么都不写入。
+func DumpRecent(w io.Writer) error {
+	if r := defaultLogger().recent; r != nil {
+		return r.dump(w)
+	}
+
+	return nil
+}
+
+// RecentHandler 返回一个输出 DumpRecent 内容的 http.Handler，可以注册到调试用的 HTTP 服务中：
+//
+//	http.Handle("/debug/log/recent", log.RecentHandler())
+func RecentHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		DumpRecent(w)
+	})
+}
@@ -0,0 +1,108 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecentBuffer(t *testing.T) {
+	r := newRecentBuffer(3)
+	buf := &bytes.Buffer{}
+
+	for _, line := range []string{"1\n", "2\n", "3\n", "4\n", "5\n"} {
+		r.add([]byte(line))
+	}
+
+	if err := r.dump(buf); err != nil {
+		t.Fatalf("fail to dump. [err:%v]", err)
+	}
+
+	if expected := "3\n4\n5\n"; buf.String() != expected {
+		t.Fatalf("invalid recent lines. [expected:%q] [actual:%q]", expected, buf.String())
+	}
+}
+
+func TestDumpRecent(t *testing.T) {
+	l, lines := newTestLogger(&Config{LogLevel: "info", RecentEntries: 10, RecentAll: true})
+	setDefaultLogger(l)
+	defer Init(nil)
+
+	ctx := context.Background()
+	Debugf(ctx, "debug context")
+	Infof(ctx, "info")
+	Errorf(ctx, "error")
+
+	if len(*lines) != 2 {
+		t.Fatalf("debug log must not be written. [lines:%v]", *lines)
+	}
+
+	buf := &bytes.Buffer{}
+	DumpRecent(buf)
+	recent := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+
+	if len(recent) != 3 || !strings.HasPrefix(recent[0], "[DEBUG]") || !strings.Contains(recent[0], "recent_test.go") || !strings.HasSuffix(recent[0], "||debug context") {
+		t.Fatalf("recent logs must contain debug log. [recent:%v]", recent)
+	}
+
+	if recent[1]+"\n" != (*lines)[0] || recent[2]+"\n" != (*lines)[1] {
+		t.Fatalf("recent logs must be the same as written logs. [recent:%v] [lines:%v]", recent, *lines)
+	}
+
+	rec := httptest.NewRecorder()
+	RecentHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/debug/log/recent", nil))
+
+	if rec.Body.String() != buf.String() {
+		t.Fatalf("handler must dump recent logs. [body:%v]", rec.Body.String())
+	}
+}
+
+type blockedWriter struct {
+	started chan bool
+	release chan bool
+}
+
+func (w *blockedWriter) Write(p []byte) (int, error) {
+	w.started <- true
+	<-w.release
+	return len(p), nil
+}
+
+func TestRecentBufferSlowWriter(t *testing.T) {
+	r := newRecentBuffer(3)
+	r.add([]byte("1\n"))
+
+	w := &blockedWriter{
+		started: make(chan bool),
+		release: make(chan bool),
+	}
+	done := make(chan error)
+
+	go func() {
+		done <- r.dump(w)
+	}()
+
+	<-w.started
+	added := make(chan bool)
+
+	go func() {
+		r.add([]byte("2\n"))
+		close(added)
+	}()
+
+	// 写入 w 时不能持有锁，否则 add 会被阻塞。
+	select {
+	case <-added:
+	case <-time.After(time.Second):
+		t.Fatalf("add must not be blocked by slow writer.")
+	}
+
+	close(w.release)
+
+	if err := <-done; err != nil {
+		t.Fatalf("fail to dump. [err:%v]", err)
+	}
+}
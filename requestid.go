@@ -0,0 +1,88 @@
+package log
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"sync/atomic"
+)
+
+const (
+	// RequestIDKey 是请求 ID 在日志中的字段名。
+	RequestIDKey = "request_id"
+
+	// RequestIDHeader 是传递请求 ID 的 HTTP header。
+	RequestIDHeader = "X-Request-Id"
+)
+
+type logRequestID struct{}
+
+var (
+	keyLogRequestID logRequestID
+
+	requestIDGenerator atomic.Value
+)
+
+// SetRequestIDGenerator 设置生成请求 ID 的函数，默认生成 16 个字符的随机十六进制字符串。
+// gen 为 nil 时恢复默认值。
+func SetRequestIDGenerator(gen func() string) {
+	if gen == nil {
+		gen = newRequestID
+	}
+
+	requestIDGenerator.Store(gen)
+}
+
+// NewRequestID 用 SetRequestIDGenerator 设置的函数生成一个新的请求 ID。
+func NewRequestID() string {
+	if gen, ok := requestIDGenerator.Load().(func() string); ok {
+		return gen()
+	}
+
+	return newRequestID()
+}
+
+func newRequestID() string {
+	var id [8]byte
+	rand.Read(id[:])
+	return hex.EncodeToString(id[:])
+}
+
+// WithRequestID 在 ctx 里面保存一个新生成的请求 ID，如果 ctx 里面已经有请求 ID 则直接返回 ctx。
+// 请求 ID 会作为 RequestIDKey 字段输出到每条日志中。
+func WithRequestID(ctx context.Context) context.Context {
+	if RequestID(ctx) != "" {
+		return ctx
+	}
+
+	return SetRequestID(ctx, NewRequestID())
+}
+
+// SetRequestID 在 ctx 里面保存指定的请求 ID，一般用于保存上游传过来的请求 ID。
+func SetRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, keyLogRequestID, id)
+}
+
+// RequestID 返回 ctx 里面保存的请求 ID，没有时返回空字符串。
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(keyLogRequestID).(string)
+	return id
+}
+
+// RequestIDFromHeader 读取 header 中的 RequestIDHeader 作为请求 ID 保存到 ctx 里面，
+// 如果 header 中没有请求 ID 就生成一个新的。
+func RequestIDFromHeader(ctx context.Context, header http.Header) context.Context {
+	if id := header.Get(RequestIDHeader); id != "" {
+		return SetRequestID(ctx, id)
+	}
+
+	return WithRequestID(ctx)
+}
+
+// InjectRequestID 将 ctx 里面的请求 ID 写入 header，用于调用下游服务时传递请求 ID。
+func InjectRequestID(ctx context.Context, header http.Header) {
+	if id := RequestID(ctx); id != "" {
+		header.Set(RequestIDHeader, id)
+	}
+}
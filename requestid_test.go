@@ -0,0 +1,49 @@
+package log
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestRequestID(t *testing.T) {
+	ctx := context.Background()
+
+	if id := RequestID(ctx); id != "" {
+		t.Fatalf("there should be no request id. [id:%v]", id)
+	}
+
+	ctx = WithRequestID(ctx)
+	id := RequestID(ctx)
+
+	if len(id) != 16 || RequestID(WithRequestID(ctx)) != id {
+		t.Fatalf("invalid request id. [id:%v]", id)
+	}
+
+	SetRequestIDGenerator(func() string { return "fixed" })
+	defer SetRequestIDGenerator(nil)
+
+	header := http.Header{}
+
+	if id := RequestID(RequestIDFromHeader(context.Background(), header)); id != "fixed" {
+		t.Fatalf("request id should be generated. [id:%v]", id)
+	}
+
+	header.Set(RequestIDHeader, "upstream")
+	ctx = RequestIDFromHeader(context.Background(), header)
+	out := http.Header{}
+	InjectRequestID(ctx, out)
+
+	if id := out.Get(RequestIDHeader); id != "upstream" {
+		t.Fatalf("request id should be injected. [id:%v]", id)
+	}
+
+	l, lines := newTestLogger(&Config{})
+	l.Infof(ctx, "hello")
+	l.Flush()
+
+	if len(*lines) != 1 || !strings.Contains((*lines)[0], "request_id=upstream") {
+		t.Fatalf("request id should be printed. [lines:%v]", *lines)
+	}
+}
@@ -0,0 +1,69 @@
+package log
+
+import "io"
+
+// route 是解析后的路由规则。
+// 日志级别的值越小越严重，所以 minLevel 的值大于等于 maxLevel。
+type route struct {
+	minLevel Level
+	maxLevel Level
+	print    bool
+	writer   io.Writer   // writer 是没有分片时使用的 writer。
+	shards   []io.Writer // shards 是分片的 writer，同一个分片中的日志保证有序。
+	mask     byte        // mask 是使用 teeFile 时这条规则对应的文件。
+}
+
+func newRoute(rc Route, print bool, writers ...io.Writer) route {
+	minLevel := LogDebug
+	maxLevel := LogFatal
+
+	if rc.MinLevel != "" {
+		minLevel = parseLevel(rc.MinLevel)
+	}
+
+	if rc.MaxLevel != "" {
+		maxLevel = parseLevel(rc.MaxLevel)
+	}
+
+	r := route{
+		minLevel: minLevel,
+		maxLevel: maxLevel,
+		print:    print,
+	}
+
+	if len(writers) == 1 {
+		r.writer = writers[0]
+	} else if len(writers) > 1 {
+		r.shards = writers
+	}
+
+	return r
+}
+
+// allRoute 返回一条接受所有日志的路由规则。
+func allRoute(writer io.Writer) route {
+	return route{
+		minLevel: logMax,
+		maxLevel: LogFatal,
+		print:    true,
+		writer:   writer,
+	}
+}
+
+func (r *route) match(level Level) bool {
+	if level == logPrint {
+		return r.print
+	}
+
+	return level.within(r.minLevel) && r.maxLevel.within(level)
+}
+
+// write 将 data 写入 key 对应的分片，没有分片时直接写入 writer。
+func (r *route) write(key uint32, data []byte) {
+	if r.shards == nil {
+		r.writer.Write(data)
+		return
+	}
+
+	r.shards[key%uint32(len(r.shards))].Write(data)
+}
@@ -0,0 +1,70 @@
+package log
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRoutes(t *testing.T) {
+	testRoutes(t, false)
+}
+
+func TestRoutesSinglePipeline(t *testing.T) {
+	testRoutes(t, true)
+}
+
+func testRoutes(t *testing.T, singlePipeline bool) {
+	dir := filepath.Join(os.TempDir(), "go-log-routes-test")
+	os.RemoveAll(dir)
+	defer os.RemoveAll(dir)
+
+	allPath := filepath.Join(dir, "all.log")
+	errorPath := filepath.Join(dir, "error.log")
+	tracePath := filepath.Join(dir, "trace.log")
+	l := newLogger(&Config{
+		LogLevel:       "debug",
+		SinglePipeline: singlePipeline,
+		Routes: []Route{
+			{Path: allPath},
+			{MinLevel: "warn", Path: errorPath},
+			{MinLevel: "trace", MaxLevel: "trace", Path: tracePath},
+		},
+	})
+
+	ctx := context.Background()
+	l.Debugf(ctx, "debug")
+	l.Tracef(ctx, "trace")
+	l.Warnf(ctx, "warn")
+	l.Printf(ctx, "print")
+	l.Close()
+
+	expected := map[string][]string{
+		allPath:   {"debug", "trace", "warn", "print"},
+		errorPath: {"warn"},
+		tracePath: {"trace"},
+	}
+
+	for p, messages := range expected {
+		data, err := ioutil.ReadFile(p)
+
+		if err != nil {
+			t.Fatalf("fail to read %v. [err:%v]", p, err)
+		}
+
+		lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+
+		if len(lines) != len(messages) {
+			t.Fatalf("invalid line count in %v. [expected:%v] [actual:%v]", p, len(messages), len(lines))
+		}
+
+		for i, msg := range messages {
+			if !strings.HasSuffix(lines[i], msg) {
+				t.Fatalf("invalid line in %v. [expected:%v] [actual:%v]", p, msg, lines[i])
+			}
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package log
+
+import (
+	"context"
+	"runtime"
+	"time"
+)
+
+// RuntimeTag 是运行时统计日志的 tag。
+const RuntimeTag = "runtime"
+
+// runtimeReporter 定期输出 goroutine 数量、内存和 GC 的统计信息。
+type runtimeReporter struct {
+	lastNumGC uint32
+}
+
+// reportRuntime 每隔 interval 输出一条运行时统计日志，直到日志被关闭。
+func (l *logger) reportRuntime(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	r := &runtimeReporter{}
+
+	for {
+		select {
+		case <-ticker.C:
+			r.report(l)
+		case <-l.closing:
+			return
+		}
+	}
+}
+
+func (r *runtimeReporter) report(l *logger) {
+	ctx := WithTag(context.Background(), RuntimeTag)
+
+	if l.disabled(ctx, LogInfo) || !l.enabled(ctx, 0, LogInfo) {
+		return
+	}
+
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+
+	// PauseNs 是一个环形缓冲区，只保存了最近 256 次 GC 的停顿时间。
+	gc := ms.NumGC - r.lastNumGC
+	r.lastNumGC = ms.NumGC
+	var maxPause uint64
+
+	for i := uint32(0); i < gc && i < uint32(len(ms.PauseNs)); i++ {
+		if pause := ms.PauseNs[(ms.NumGC-i+255)%256]; pause > maxPause {
+			maxPause = pause
+		}
+	}
+
+	ctx = WithMoreInfo(ctx,
+		Int("goroutines", runtime.NumGoroutine()),
+		Bytes("heap_alloc", int64(ms.HeapAlloc)),
+		Bytes("heap_sys", int64(ms.HeapSys)),
+		Int64("heap_objects", int64(ms.HeapObjects)),
+		Int64("gc", int64(gc)),
+		Duration("gc_pause_max", time.Duration(maxPause)),
+		Duration("gc_pause_total", time.Duration(ms.PauseTotalNs)),
+	)
+	l.output(ctx, 0, LogInfo, "runtime stats")
+}
@@ -0,0 +1,37 @@
+package log
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestRuntimeStats(t *testing.T) {
+	l, lines := newTestLogger(&Config{})
+	r := &runtimeReporter{}
+	runtime.GC()
+	r.report(l)
+
+	if len(*lines) != 1 {
+		t.Fatalf("runtime stats should be logged. [lines:%v]", *lines)
+	}
+
+	line := (*lines)[0]
+
+	for _, field := range []string{"[INFO]", "runtime||goroutines=", "||heap_alloc=", "||gc=", "||gc_pause_max=", "||runtime stats"} {
+		if !strings.Contains(line, field) {
+			t.Fatalf("runtime stats should contain %v. [line:%v]", field, line)
+		}
+	}
+
+	if strings.Contains(line, "||gc=0||") {
+		t.Fatalf("gc should be counted since last report. [line:%v]", line)
+	}
+
+	l, lines = newTestLogger(&Config{LogLevel: "warn"})
+	r.report(l)
+
+	if len(*lines) != 0 {
+		t.Fatalf("runtime stats should follow log level. [lines:%v]", *lines)
+	}
+}
@@ -0,0 +1,111 @@
+package log
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// sampleGates 记录 Once 和 Every 在每个调用位置的状态，key 是调用位置的 pc。
+var sampleGates sync.Map
+
+// sampledLogger 只在 allow 返回 true 时输出日志。
+type sampledLogger struct {
+	pc       uintptr
+	interval time.Duration // interval 为 0 时表示 Once。
+}
+
+// Once 返回一个在当前调用位置只输出一次日志的 Logger，适合废弃警告等只需要提醒一次的日志：
+//
+//	log.Once().Warnf(ctx, "config field %v is deprecated", name)
+//
+// 同一个调用位置共享状态，不同调用位置互不影响，Close 不会做任何事情。
+func Once() Logger {
+	pc := callerPC(1)
+	return &sampledLogger{pc: pc}
+}
+
+// Every 返回一个在当前调用位置每隔 interval 最多输出一条日志的 Logger，适合重试循环等可能刷屏的日志：
+//
+//	log.Every(time.Minute).Warnf(ctx, "retrying: %v", err)
+//
+// 同一个调用位置共享状态，不同调用位置互不影响，Close 不会做任何事情。
+func Every(interval time.Duration) Logger {
+	pc := callerPC(1)
+	return &sampledLogger{pc: pc, interval: interval}
+}
+
+func (l *sampledLogger) allow() bool {
+	if l.interval <= 0 {
+		_, loaded := sampleGates.LoadOrStore(l.pc, new(int64))
+		return !loaded
+	}
+
+	v, _ := sampleGates.LoadOrStore(l.pc, new(int64))
+	last := v.(*int64)
+	now := time.Now().UnixNano()
+	prev := atomic.LoadInt64(last)
+
+	if prev != 0 && now-prev < int64(l.interval) {
+		return false
+	}
+
+	return atomic.CompareAndSwapInt64(last, prev, now)
+}
+
+func (l *sampledLogger) Debugf(ctx context.Context, fmt string, args ...interface{}) {
+	if l.allow() {
+		defaultLogger().log(ctx, 0, LogDebug, fmt, args...)
+	}
+}
+
+func (l *sampledLogger) Infof(ctx context.Context, fmt string, args ...interface{}) {
+	if l.allow() {
+		defaultLogger().log(ctx, 0, LogInfo, fmt, args...)
+	}
+}
+
+func (l *sampledLogger) Tracef(ctx context.Context, fmt string, args ...interface{}) {
+	if l.allow() {
+		defaultLogger().log(ctx, 0, LogTrace, fmt, args...)
+	}
+}
+
+func (l *sampledLogger) Warnf(ctx context.Context, fmt string, args ...interface{}) {
+	if l.allow() {
+		defaultLogger().log(ctx, 0, LogWarn, fmt, args...)
+	}
+}
+
+func (l *sampledLogger) Errorf(ctx context.Context, fmt string, args ...interface{}) {
+	if l.allow() {
+		defaultLogger().log(ctx, 0, LogError, fmt, args...)
+	}
+}
+
+// Panicf 只采样日志，无论是否被采样都会 panic。
+func (l *sampledLogger) Panicf(ctx context.Context, format string, args ...interface{}) {
+	if l.allow() {
+		defaultLogger().panicf(ctx, 0, format, args...)
+	}
+
+	panic(fmt.Sprintf(format, args...))
+}
+
+func (l *sampledLogger) Fatalf(ctx context.Context, fmt string, args ...interface{}) {
+	if l.allow() {
+		defaultLogger().log(ctx, 0, LogFatal, fmt, args...)
+	}
+}
+
+func (l *sampledLogger) Printf(ctx context.Context, fmt string, args ...interface{}) {
+	if l.allow() {
+		defaultLogger().log(ctx, 0, logPrint, fmt, args...)
+	}
+}
+
+func (l *sampledLogger) Close() error {
+	return nil
+}
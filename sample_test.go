@@ -0,0 +1,48 @@
+package log
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOnceAndEvery(t *testing.T) {
+	l, lines := newTestLogger(&Config{})
+	setDefaultLogger(l)
+	defer Init(nil)
+
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		Once().Warnf(ctx, "once %v", i)
+		Every(time.Hour).Infof(ctx, "every %v", i)
+	}
+
+	Once().Warnf(ctx, "another site")
+	l.Flush()
+
+	expected := []string{"once 0", "every 0", "another site"}
+
+	if len(*lines) != len(expected) {
+		t.Fatalf("unexpected lines. [lines:%v]", *lines)
+	}
+
+	for i, msg := range expected {
+		if line := (*lines)[i]; !strings.HasSuffix(line, "||"+msg+"\n") || !strings.Contains(line, "sample_test.go:") {
+			t.Fatalf("invalid line. [line:%v] [expected:%v]", line, msg)
+		}
+	}
+
+	every := &sampledLogger{pc: 1, interval: time.Millisecond}
+
+	if !every.allow() || every.allow() {
+		t.Fatalf("every must allow only the first call in interval.")
+	}
+
+	time.Sleep(2 * time.Millisecond)
+
+	if !every.allow() {
+		t.Fatalf("every must allow after interval.")
+	}
+}
@@ -0,0 +1,28 @@
+package log
+
+import "io"
+
+// SetOutput 让默认日志之后输出的日志都写入 w，代替日志文件或 stdout，Sinks、TeeWriters 和终端回显不受影响，
+// 适合在重新挂载日志目录时临时把日志转到其他地方，或者临时捕获日志用于调试。
+// w 为 nil 时恢复写入原来的日志文件。切换是原子的，每条日志要么完整的写入 w，要么写入原来的文件。
+// 重新 Init 之后需要重新设置，Close 不会关闭 w。
+func SetOutput(w io.Writer) {
+	defaultLogger().SetOutput(w)
+}
+
+// SetOutput 让 l 之后输出的日志都写入 w，w 为 nil 时恢复写入原来的日志文件，详见 SetOutput。
+func (l *logger) SetOutput(w io.Writer) {
+	var redirect *teeWriter
+
+	if w != nil {
+		redirect = &teeWriter{w: w}
+	}
+
+	l.redirect.Store(redirect)
+}
+
+// redirected 返回 SetOutput 设置的 writer，没有设置时返回 nil。
+func (l *logger) redirected() *teeWriter {
+	w, _ := l.redirect.Load().(*teeWriter)
+	return w
+}
@@ -0,0 +1,37 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestSetOutput(t *testing.T) {
+	tee := &bytes.Buffer{}
+	l, lines := newTestLogger(&Config{})
+	l.addTeeWriter(tee)
+	setDefaultLogger(l)
+	defer Init(nil)
+
+	ctx := context.Background()
+	Infof(ctx, "before")
+
+	out := &bytes.Buffer{}
+	SetOutput(out)
+	Infof(ctx, "redirected")
+	SetOutput(nil)
+	Infof(ctx, "restored")
+
+	if len(*lines) != 2 || !strings.HasSuffix((*lines)[0], "||before\n") || !strings.HasSuffix((*lines)[1], "||restored\n") {
+		t.Fatalf("redirected log must not be written to routes. [lines:%v]", *lines)
+	}
+
+	if !strings.HasSuffix(out.String(), "||redirected\n") || strings.Count(out.String(), "\n") != 1 {
+		t.Fatalf("log must be redirected. [out:%v]", out.String())
+	}
+
+	if strings.Count(tee.String(), "\n") != 3 {
+		t.Fatalf("tee writers must not be affected. [tee:%v]", tee.String())
+	}
+}
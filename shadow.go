@@ -0,0 +1,53 @@
+package log
+
+import "io"
+
+// openShadow 为每个日志文件打开一个对应的影子文件，影子文件使用 ShadowFormat 格式，
+// 路由规则和原文件完全一致，方便迁移日志格式时新旧两种格式同时存在。
+func (l *logger) openShadow(config *Config, routeConfigs []Route, bufferedLines int) {
+	suffix := config.ShadowSuffix
+
+	if suffix == "" {
+		suffix = "." + config.ShadowFormat
+	}
+
+	writers := map[string]io.Writer{}
+
+	for i, rc := range routeConfigs {
+		w, ok := writers[rc.Path]
+
+		if !ok {
+			path := rc.Path + suffix
+			aw, file := acquireWriter(path, newFileOptions(config, bufferedLines))
+			l.files = append(l.files, file)
+			l.writers = append(l.writers, aw)
+			l.paths = append(l.paths, path)
+			writers[rc.Path] = aw
+			w = aw
+		}
+
+		l.shadowRoutes = append(l.shadowRoutes, newRoute(rc, i == 0, w))
+	}
+
+	l.shadowEncoder = NewEncoder(config.ShadowFormat)
+}
+
+// writeShadow 用 shadowEncoder 重新编码 entry 并写入所有匹配的影子文件。
+func (l *logger) writeShadow(level Level, entry *Entry) {
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	l.shadowEncoder.Encode(buf, entry)
+	buf.WriteByte('\n')
+	line := buf.Bytes()
+
+	if len(line) > maxLogLine {
+		line = line[:maxLogLine]
+	}
+
+	for i := range l.shadowRoutes {
+		if r := &l.shadowRoutes[i]; r.match(level) {
+			r.write(0, line)
+		}
+	}
+}
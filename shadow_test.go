@@ -0,0 +1,72 @@
+package log
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestShadowFormat(t *testing.T) {
+	dir := filepath.Join(os.TempDir(), "go-log-shadow-test")
+	os.RemoveAll(dir)
+	defer os.RemoveAll(dir)
+
+	logPath := filepath.Join(dir, "all.log")
+	errorPath := filepath.Join(dir, "error.log")
+	l := newLogger(&Config{
+		LogLevel:     "debug",
+		LogPath:      logPath,
+		ErrorLogPath: errorPath,
+		AuditLogPath: filepath.Join(dir, "audit.log"),
+		ShadowFormat: FormatJSON,
+	})
+
+	ctx := context.Background()
+	l.Infof(ctx, "info")
+	l.Errorf(ctx, "error")
+	l.Close()
+
+	expected := map[string][]string{
+		logPath:   {"info", "error"},
+		errorPath: {"error"},
+	}
+
+	for p, messages := range expected {
+		text := readLines(t, p)
+		shadow := readLines(t, p+".json")
+
+		if len(text) != len(messages) || len(shadow) != len(messages) {
+			t.Fatalf("unexpected line count in %v. [text:%v] [shadow:%v]", p, text, shadow)
+		}
+
+		for i, msg := range messages {
+			if !strings.HasSuffix(text[i], "||"+msg) {
+				t.Fatalf("text line must end with message. [line:%v] [msg:%v]", text[i], msg)
+			}
+
+			var obj map[string]interface{}
+
+			if err := json.Unmarshal([]byte(shadow[i]), &obj); err != nil {
+				t.Fatalf("shadow line must be JSON. [line:%v] [err:%v]", shadow[i], err)
+			}
+
+			if obj["msg"] != msg {
+				t.Fatalf("unexpected shadow message. [line:%v] [msg:%v]", shadow[i], msg)
+			}
+		}
+	}
+}
+
+func readLines(t *testing.T, p string) []string {
+	data, err := ioutil.ReadFile(p)
+
+	if err != nil {
+		t.Fatalf("fail to read %v. [err:%v]", p, err)
+	}
+
+	return strings.Split(strings.TrimSpace(string(data)), "\n")
+}
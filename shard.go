@@ -0,0 +1,51 @@
+package log
+
+import "sync/atomic"
+
+// shardKey 计算一条日志所在的分片。
+// 相同 tag 的日志总是写入同一个分片，保证同一个请求的日志有序；
+// 没有 tag 的日志按照调用位置选择分片，保证同一个位置输出的日志有序。
+func (l *logger) shardKey(tag string, pc uintptr) uint32 {
+	if tag == "" {
+		return uint32(pc>>4) ^ uint32(pc>>20)
+	}
+
+	// FNV-1a。
+	h := uint32(2166136261)
+
+	for i := 0; i < len(tag); i++ {
+		h ^= uint32(tag[i])
+		h *= 16777619
+	}
+
+	return h
+}
+
+// sharedCloser 让多个 AsyncWriter 共享同一个 writer，所有 AsyncWriter 都关闭之后才关闭 writer。
+type sharedCloser struct {
+	logFile
+	refs int32
+}
+
+func newSharedCloser(file logFile, refs int) *sharedCloser {
+	return &sharedCloser{
+		logFile: file,
+		refs:    int32(refs),
+	}
+}
+
+func (c *sharedCloser) Close() error {
+	if atomic.AddInt32(&c.refs, -1) != 0 {
+		return nil
+	}
+
+	return c.logFile.Close()
+}
+
+func (c *sharedCloser) Flush() error {
+	if f, ok := c.logFile.(flusher); ok {
+		return f.Flush()
+	}
+
+	return nil
+}
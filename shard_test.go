@@ -0,0 +1,54 @@
+package log
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestShards(t *testing.T) {
+	dir := filepath.Join(os.TempDir(), "go-log-shards-test")
+	os.RemoveAll(dir)
+	defer os.RemoveAll(dir)
+
+	logPath := filepath.Join(dir, "all.log")
+	l := newLogger(&Config{
+		LogPath:      logPath,
+		ErrorLogPath: logPath,
+		Shards:       4,
+	})
+
+	if len(l.writers) != 4 {
+		t.Fatalf("there should be 4 writers. [writers:%v]", len(l.writers))
+	}
+
+	ctx := WithTag(context.Background(), "req")
+
+	for i := 0; i < 100; i++ {
+		l.Infof(ctx, "line %v", i)
+	}
+
+	l.Close()
+	data, err := ioutil.ReadFile(logPath)
+
+	if err != nil {
+		t.Fatalf("fail to read log file. [err:%v]", err)
+	}
+
+	// 相同 tag 的日志必须有序。
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+
+	if len(lines) != 100 {
+		t.Fatalf("invalid line count. [count:%v]", len(lines))
+	}
+
+	for i, line := range lines {
+		if !strings.HasSuffix(line, "||line "+strconv.Itoa(i)) {
+			t.Fatalf("lines with the same tag should be ordered. [i:%v] [line:%v]", i, line)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package log
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+)
+
+// sidecar 协议用于将日志交给同机的 sidecar 进程处理，应用进程只负责写入，
+// 日志的采集、解析和上报都由 sidecar 完成。
+//
+// 协议非常简单，数据流由若干帧组成，每帧代表一条日志：
+//
+//	+----------------------+---------------------+
+//	| length (4B, 大端序)  | payload (length 字节) |
+//	+----------------------+---------------------+
+//
+// payload 是按照 Config.Format 编码后的一条日志，不包含结尾的换行符，也不会被截断。
+// 读取方可以直接使用 FrameReader 解析数据流。
+
+const (
+	frameHeaderSize = 4
+
+	// MaxFrameSize 是一帧 payload 的最大长度。
+	MaxFrameSize = 1 << 24
+)
+
+var errFrameTooLarge = errors.New("go-log: frame is too large")
+
+// frameWriter 将每次 Write 的数据封装成一帧写入内部 writer。
+type frameWriter struct {
+	writer io.WriteCloser
+	buf    []byte
+}
+
+var _ io.WriteCloser = new(frameWriter)
+
+// NewFrameWriter 创建一个按照 sidecar 协议写数据的 writer，每次 Write 调用写入一帧。
+func NewFrameWriter(writer io.WriteCloser) io.WriteCloser {
+	return &frameWriter{
+		writer: writer,
+	}
+}
+
+func (w *frameWriter) Write(data []byte) (written int, err error) {
+	if len(data) > MaxFrameSize {
+		err = errFrameTooLarge
+		return
+	}
+
+	// 头和 payload 一次写入，保证写 pipe 时一帧不会被其他写入者打断。
+	w.buf = append(w.buf[:0], 0, 0, 0, 0)
+	binary.BigEndian.PutUint32(w.buf, uint32(len(data)))
+	w.buf = append(w.buf, data...)
+
+	if _, err = w.writer.Write(w.buf); err != nil {
+		return
+	}
+
+	written = len(data)
+	return
+}
+
+func (w *frameWriter) Close() error {
+	return w.writer.Close()
+}
+
+// FrameReader 按照 sidecar 协议读取数据流。
+type FrameReader struct {
+	reader *bufio.Reader
+	header [frameHeaderSize]byte
+}
+
+// NewFrameReader 创建一个 FrameReader。
+func NewFrameReader(reader io.Reader) *FrameReader {
+	return &FrameReader{
+		reader: bufio.NewReader(reader),
+	}
+}
+
+// Next 读取下一帧的 payload，数据流结束时返回 io.EOF。
+// 如果数据流在一帧中间结束，返回 io.ErrUnexpectedEOF。
+func (r *FrameReader) Next() (payload []byte, err error) {
+	if _, err = io.ReadFull(r.reader, r.header[:]); err != nil {
+		return
+	}
+
+	size := binary.BigEndian.Uint32(r.header[:])
+
+	if size > MaxFrameSize {
+		err = errFrameTooLarge
+		return
+	}
+
+	payload = make([]byte, size)
+
+	if _, err = io.ReadFull(r.reader, payload); err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+
+	return
+}
+
+// pipeFile 在第一次写入的时候才打开文件。
+// 打开一个没有读取方的命名管道会阻塞，延迟打开可以避免阻塞 Init。
+type pipeFile struct {
+	path string
+	file *os.File
+}
+
+func (p *pipeFile) Write(data []byte) (int, error) {
+	if p.file == nil {
+		f, err := os.OpenFile(p.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+
+		if err != nil {
+			return 0, err
+		}
+
+		p.file = f
+	}
+
+	return p.file.Write(data)
+}
+
+func (p *pipeFile) Close() error {
+	if p.file == nil {
+		return nil
+	}
+
+	return p.file.Close()
+}
@@ -0,0 +1,38 @@
+package log
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestFrameWriterAndReader(t *testing.T) {
+	buf := &bytes.Buffer{}
+	w := NewFrameWriter(dummyCloser{Writer: buf})
+	lines := []string{"line1", "", "line3 with more data\n"}
+
+	for _, line := range lines {
+		if _, err := w.Write([]byte(line)); err != nil {
+			t.Fatalf("fail to write frame. [err:%v]", err)
+		}
+	}
+
+	r := NewFrameReader(buf)
+	expected := []string{"line1", "", "line3 with more data\n"}
+
+	for i, e := range expected {
+		payload, err := r.Next()
+
+		if err != nil {
+			t.Fatalf("fail to read frame %v. [err:%v]", i, err)
+		}
+
+		if string(payload) != e {
+			t.Fatalf("invalid frame %v. [expected:%v] [actual:%v]", i, e, string(payload))
+		}
+	}
+
+	if _, err := r.Next(); err != io.EOF {
+		t.Fatalf("reader should reach EOF. [err:%v]", err)
+	}
+}
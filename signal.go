@@ -0,0 +1,95 @@
+package log
+
+import (
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+// HandleSignals 在收到 sigs 中任意一个信号时刷新并关闭默认日志，保证进程退出前最后的日志可以落盘，
+// 之后的日志会和 Close 一样输出到 stdout。如果 sigs 为空，默认处理 SIGTERM 和 SIGINT。
+//
+// HandleSignals 不会退出进程，也不影响应用自己通过 signal.Notify 注册的处理逻辑，进程何时退出由应用决定。
+// 注意，如果应用没有处理这些信号，调用 HandleSignals 之后进程收到信号时不会再退出，这时应该使用 HandleSignalsAndExit。
+//
+// 返回的 stop 函数用来取消信号处理。
+func HandleSignals(sigs ...os.Signal) (stop func()) {
+	return handleSignals(sigs, nil)
+}
+
+// HandleSignalsAndExit 和 HandleSignals 一样在收到信号时刷新并关闭默认日志，然后直接调用 os.Exit 退出进程，
+// 退出码是 1。应用自己的退出逻辑不会执行，只适合没有其他清理工作的程序。
+func HandleSignalsAndExit(sigs ...os.Signal) (stop func()) {
+	return handleSignals(sigs, func(sig os.Signal) {
+		os.Exit(1)
+	})
+}
+
+func handleSignals(sigs []os.Signal, exit func(sig os.Signal)) (stop func()) {
+	if len(sigs) == 0 {
+		sigs = []os.Signal{syscall.SIGTERM, os.Interrupt}
+	}
+
+	ch := make(chan os.Signal, 1)
+	done := make(chan bool)
+	signal.Notify(ch, sigs...)
+
+	go func() {
+		select {
+		case sig := <-ch:
+			Flush()
+			Close()
+
+			if exit != nil {
+				exit(sig)
+			}
+
+		case <-done:
+		}
+	}()
+
+	var once sync.Once
+
+	return func() {
+		once.Do(func() {
+			signal.Stop(ch)
+			close(done)
+		})
+	}
+}
+
+// HandleRotateSignals 在收到 sigs 中任意一个信号时调用 Rotate 重新打开日志文件，
+// 这是 logrotate 等日志切割工具约定的工作方式。如果 sigs 为空，默认处理 SIGHUP。
+//
+// 返回的 stop 函数用来取消信号处理。
+func HandleRotateSignals(sigs ...os.Signal) (stop func()) {
+	if len(sigs) == 0 {
+		sigs = []os.Signal{syscall.SIGHUP}
+	}
+
+	ch := make(chan os.Signal, 1)
+	done := make(chan bool)
+	signal.Notify(ch, sigs...)
+
+	go func() {
+		for {
+			select {
+			case <-ch:
+				Rotate()
+
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+
+	return func() {
+		once.Do(func() {
+			signal.Stop(ch)
+			close(done)
+		})
+	}
+}
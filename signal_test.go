@@ -0,0 +1,100 @@
+//go:build !windows
+// +build !windows
+
+package log
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestHandleRotateSignals(t *testing.T) {
+	dir := filepath.Join(os.TempDir(), "go-log-rotate-test")
+	os.RemoveAll(dir)
+	defer os.RemoveAll(dir)
+
+	logPath := filepath.Join(dir, "all.log")
+	Init(&Config{
+		LogPath:      logPath,
+		ErrorLogPath: logPath,
+	})
+	defer Init(nil)
+
+	stop := HandleRotateSignals()
+	defer stop()
+
+	Printf(context.Background(), "before rotate")
+	Flush()
+
+	// 模拟 logrotate：先移走日志文件，再发送 SIGHUP。
+	moved := logPath + ".1"
+
+	if err := os.Rename(logPath, moved); err != nil {
+		t.Fatalf("fail to move log file. [err:%v]", err)
+	}
+
+	syscall.Kill(os.Getpid(), syscall.SIGHUP)
+
+	deadline := time.Now().Add(time.Second)
+
+	for time.Now().Before(deadline) {
+		if _, err := os.Stat(logPath); err == nil {
+			return
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Fatalf("log file should be reopened after SIGHUP.")
+}
+
+func TestHandleSignals(t *testing.T) {
+	dir := filepath.Join(os.TempDir(), "go-log-signal-test")
+	os.RemoveAll(dir)
+	defer os.RemoveAll(dir)
+
+	logPath := filepath.Join(dir, "all.log")
+	Init(&Config{
+		LogPath:      logPath,
+		ErrorLogPath: logPath,
+	})
+	defer Init(nil)
+
+	// 应用自己注册的信号处理不能受到影响。
+	app := make(chan os.Signal, 1)
+	signal.Notify(app, syscall.SIGUSR1)
+	defer signal.Stop(app)
+
+	l := defaultLogger()
+	stop := HandleSignals(syscall.SIGUSR1)
+	defer stop()
+
+	Printf(context.Background(), "before signal")
+	syscall.Kill(os.Getpid(), syscall.SIGUSR1)
+
+	select {
+	case <-app:
+	case <-time.After(time.Second):
+		t.Fatalf("application must receive the signal.")
+	}
+
+	deadline := time.Now().Add(time.Second)
+
+	for defaultLogger() == l {
+		if time.Now().After(deadline) {
+			t.Fatalf("default logger should be closed after signal.")
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if lines := readLines(t, logPath); len(lines) != 1 || !strings.HasSuffix(lines[0], "before signal") {
+		t.Fatalf("log must be flushed before exit. [lines:%v]", lines)
+	}
+}
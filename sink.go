@@ -0,0 +1,13 @@
+package log
+
+import "io"
+
+// Sink 是一个日志输出目标，直接接收编码之前的日志。
+// Write 会在输出日志的 goroutine 中同步调用，实现时不能阻塞太久，也不能修改 entry。
+// 如果 Sink 自带缓冲区，可以实现 Flush() error 方法，刷新日志时会一起调用。
+type Sink interface {
+	io.Closer
+
+	// Write 处理一条日志。
+	Write(entry *Entry) error
+}
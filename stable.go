@@ -0,0 +1,89 @@
+package log
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// stableFields 返回按照 key 排序并且统一了值格式的字段，不会修改 fields。
+// 整数统一成 int64 或 uint64，有限的浮点数统一用 strconv 的 'g' 格式，时间使用日志的时间格式，
+// 这样同一个值在所有编码中的输出都相同。
+func (l *logger) stableFields(fields []Info) []Info {
+	if len(fields) == 0 {
+		return fields
+	}
+
+	stable := make([]Info, len(fields))
+
+	for i, info := range fields {
+		stable[i] = Info{Key: info.Key, Value: l.stableValue(info.Value)}
+	}
+
+	sort.SliceStable(stable, func(i, j int) bool {
+		return stable[i].Key < stable[j].Key
+	})
+	return stable
+}
+
+func (l *logger) stableValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case int:
+		return int64(v)
+	case int8:
+		return int64(v)
+	case int16:
+		return int64(v)
+	case int32:
+		return int64(v)
+	case uint:
+		return uint64(v)
+	case uint8:
+		return uint64(v)
+	case uint16:
+		return uint64(v)
+	case uint32:
+		return uint64(v)
+	case float32:
+		return stableFloat(float64(v), 32)
+	case float64:
+		return stableFloat(v, 64)
+	case time.Time:
+		return l.timeLayout().format(v)
+	default:
+		return value
+	}
+}
+
+// stableNumber 是格式化好的数字，文本格式和 JSON 格式会输出相同的内容，JSON 中不加引号。
+type stableNumber string
+
+func (n stableNumber) String() string {
+	return string(n)
+}
+
+// stableFloat 将有限的浮点数转换成 stableNumber，NaN 和 Inf 保持原样。
+func stableFloat(f float64, bitSize int) interface{} {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return f
+	}
+
+	return stableNumber(strconv.FormatFloat(f, 'g', -1, bitSize))
+}
+
+// timeLayout 返回日志使用的时间格式。
+func (l *logger) timeLayout() timeLayout {
+	switch e := l.encoder.(type) {
+	case textEncoder:
+		return e.layout
+	case jsonEncoder:
+		return e.layout
+	case logfmtEncoder:
+		return e.layout
+	case tsvEncoder:
+		return e.layout
+	default:
+		return ""
+	}
+}
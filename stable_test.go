@@ -0,0 +1,35 @@
+package log
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStableFields(t *testing.T) {
+	now, _ := time.Parse(logTimeFormat, "2019-07-03T12:34:56.789+08:00")
+	ctx := WithMoreInfo(context.Background(),
+		Info{Key: "b", Value: 1e21},
+		Info{Key: "a", Value: float32(1.5)},
+		Info{Key: "c", Value: now},
+		Info{Key: "a", Value: int8(2)},
+	)
+	cases := map[string]string{
+		FormatText: `a=1.5||a=2||b=1e+21||c=2019-07-03T12:34:56.789+08:00||msg`,
+		FormatJSON: `"a":1.5,"a":2,"b":1e+21,"c":"2019-07-03T12:34:56.789+08:00","msg":"msg"}`,
+	}
+
+	for format, expected := range cases {
+		l, lines := newTestLogger(&Config{
+			Format:       format,
+			StableFields: true,
+		})
+		l.Infof(ctx, "msg")
+		l.Flush()
+
+		if len(*lines) != 1 || !strings.Contains((*lines)[0], expected) {
+			t.Fatalf("invalid stable fields. [format:%v] [lines:%v] [expected:%v]", format, *lines, expected)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package log
+
+import "os"
+
+// 服务信息在日志中的字段名。
+const (
+	ServiceKey  = "service"
+	VersionKey  = "version"
+	HostnameKey = "host"
+	PIDKey      = "pid"
+
+	// SequenceKey 是日志序号的字段名。
+	SequenceKey = "seq"
+)
+
+// newStamps 根据配置生成每条日志都会带上的服务信息，顺序固定为 service、version、host、pid。
+func newStamps(config *Config) []Info {
+	var stamps []Info
+
+	if config.Service != "" {
+		stamps = append(stamps, Info{Key: ServiceKey, Value: config.Service})
+	}
+
+	if config.Version != "" {
+		stamps = append(stamps, Info{Key: VersionKey, Value: config.Version})
+	}
+
+	if config.Hostname {
+		hostname, err := os.Hostname()
+
+		if err != nil {
+			hostname = "unknown"
+		}
+
+		stamps = append(stamps, Info{Key: HostnameKey, Value: hostname})
+	}
+
+	if config.PID {
+		stamps = append(stamps, Info{Key: PIDKey, Value: os.Getpid()})
+	}
+
+	return stamps
+}
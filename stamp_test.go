@@ -0,0 +1,54 @@
+package log
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestStamps(t *testing.T) {
+	l, lines := newTestLogger(&Config{
+		Service:  "user",
+		Version:  "1.2.3",
+		Hostname: true,
+		PID:      true,
+	})
+
+	ctx := WithMoreInfo(SetRequestID(context.Background(), "abc"), Info{Key: "k", Value: "v"})
+	l.Infof(ctx, "hello")
+	l.Flush()
+
+	hostname, _ := os.Hostname()
+	expected := fmt.Sprintf("service=user||version=1.2.3||host=%v||pid=%v||request_id=abc||k=v||hello", hostname, os.Getpid())
+
+	if len(*lines) != 1 || !strings.Contains((*lines)[0], expected) {
+		t.Fatalf("invalid stamps. [lines:%v] [expected:%v]", *lines, expected)
+	}
+}
+
+func TestSequence(t *testing.T) {
+	l, lines := newTestLogger(&Config{
+		Sequence: true,
+		Filters: []Filter{
+			{Message: "drop", Action: FilterDrop},
+		},
+	})
+
+	ctx := SetRequestID(context.Background(), "abc")
+	l.Infof(ctx, "first")
+	l.Infof(ctx, "drop")
+	l.Infof(ctx, "second")
+	l.Flush()
+
+	if len(*lines) != 2 {
+		t.Fatalf("there should be 2 lines. [lines:%v]", *lines)
+	}
+
+	for i, line := range *lines {
+		if expected := fmt.Sprintf("seq=%v||request_id=abc||", i+1); !strings.Contains(line, expected) {
+			t.Fatalf("invalid sequence. [line:%v] [expected:%v]", line, expected)
+		}
+	}
+}
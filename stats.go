@@ -0,0 +1,136 @@
+package log
+
+import (
+	"sync"
+	"time"
+)
+
+// Statistics 代表日志缓冲区的使用情况。
+type Statistics struct {
+	Writers []WriterStats // Writers 是每个 AsyncWriter 的使用情况，顺序和日志文件的顺序一致。
+	Sinks   []SinkStats   // Sinks 是实现了 SinkStatser 的 Sink 的健康状况，FanOut 中的每个输出目标单独统计。
+}
+
+// WriterStats 代表一个 AsyncWriter 缓冲区的使用情况。
+type WriterStats struct {
+	Len int // Len 是缓冲区中尚未写入的数据条数。
+	Cap int // Cap 是缓冲区的容量。
+
+	Bytes    int // Bytes 是缓冲区中尚未写入的字节数。
+	MaxBytes int // MaxBytes 是缓冲区最多能缓存的字节数，0 表示不限制。
+
+	PeakLen   int // PeakLen 是缓冲区中曾经出现过的最多条数。
+	PeakBytes int // PeakBytes 是缓冲区中曾经出现过的最多字节数。
+}
+
+// SinkStats 代表一个 Sink 的健康状况。
+type SinkStats struct {
+	Name string // Name 是 Sink 的类型，例如 "*log.NetworkSink"。
+
+	Len int // Len 是缓冲区中尚未写入的日志条数，没有缓冲区时为 0。
+	Cap int // Cap 是缓冲区的容量，没有缓冲区时为 0。
+
+	Healthy             bool      // Healthy 表示最近一次写入成功，或者从来没有写入失败过。
+	Connected           bool      // Connected 表示网络类的 Sink 当前是否已经建立连接，其他 Sink 总是 true。
+	ConsecutiveFailures int       // ConsecutiveFailures 是连续写入失败的次数。
+	Failures            int64     // Failures 是写入失败的总次数。
+	Dropped             int64     // Dropped 是因为缓冲区满被丢弃的日志条数。
+	LastError           error     // LastError 是最近一次写入失败的原因。
+	LastErrorTime       time.Time // LastErrorTime 是最近一次写入失败的时间。
+}
+
+// merge 合并同一个 Sink 内部报告的健康状况。
+func (s *SinkStats) merge(other *SinkStats) {
+	s.Len += other.Len
+	s.Cap += other.Cap
+	s.Healthy = s.Healthy && other.Healthy
+	s.Connected = s.Connected && other.Connected
+	s.Failures += other.Failures
+	s.Dropped += other.Dropped
+
+	if other.ConsecutiveFailures > s.ConsecutiveFailures {
+		s.ConsecutiveFailures = other.ConsecutiveFailures
+	}
+
+	if other.LastErrorTime.After(s.LastErrorTime) {
+		s.LastError = other.LastError
+		s.LastErrorTime = other.LastErrorTime
+	}
+}
+
+// SinkStatser 可以由 Sink 实现，用于在 Stats 中报告自己的健康状况。
+type SinkStatser interface {
+	SinkStats() []SinkStats
+}
+
+// Usage 返回所有缓冲区中最高的占用比例，取值范围是 [0, 1]，包括 Sink 的缓冲区。
+func (s Statistics) Usage() float64 {
+	usage := 0.0
+
+	for _, w := range s.Writers {
+		if w.Cap == 0 {
+			continue
+		}
+
+		if u := float64(w.Len) / float64(w.Cap); u > usage {
+			usage = u
+		}
+	}
+
+	for _, sink := range s.Sinks {
+		if sink.Cap == 0 {
+			continue
+		}
+
+		if u := float64(sink.Len) / float64(sink.Cap); u > usage {
+			usage = u
+		}
+	}
+
+	return usage
+}
+
+// sinkHealth 记录一个 Sink 的写入结果。
+type sinkHealth struct {
+	mu                  sync.Mutex
+	consecutiveFailures int
+	failures            int64
+	dropped             int64
+	lastError           error
+	lastErrorTime       time.Time
+}
+
+func (h *sinkHealth) record(err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if err == nil {
+		h.consecutiveFailures = 0
+		return
+	}
+
+	h.consecutiveFailures++
+	h.failures++
+	h.lastError = err
+	h.lastErrorTime = time.Now()
+}
+
+func (h *sinkHealth) drop() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.dropped++
+}
+
+// fill 将健康状况填入 stats。
+func (h *sinkHealth) fill(stats *SinkStats) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	stats.Healthy = h.consecutiveFailures == 0
+	stats.ConsecutiveFailures = h.consecutiveFailures
+	stats.Failures = h.failures
+	stats.Dropped = h.dropped
+	stats.LastError = h.lastError
+	stats.LastErrorTime = h.lastErrorTime
+}
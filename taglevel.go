@@ -0,0 +1,155 @@
+package log
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// TagLevelOverride 是 SetTagLevel 设置的一个临时日志级别。
+type TagLevelOverride struct {
+	Tag     string    // Tag 是日志的 tag。
+	Level   Level     // Level 是这个 tag 的日志级别。
+	Expires time.Time // Expires 是自动恢复的时间，为零值时一直生效直到调用 ResetTagLevel。
+}
+
+type tagOverride struct {
+	TagLevelOverride
+	timer *time.Timer
+}
+
+// tagOverrideSnapshot 是当前所有临时日志级别的快照，输出日志时无锁读取。
+type tagOverrideSnapshot struct {
+	levels  map[string]Level
+	verbose Level // verbose 是所有临时日志级别中最详细的级别。
+}
+
+var (
+	tagOverrides = struct {
+		sync.Mutex
+		m map[string]*tagOverride
+	}{
+		m: map[string]*tagOverride{},
+	}
+
+	tagOverridesSnapshot atomic.Value // *tagOverrideSnapshot
+)
+
+// SetTagLevel 临时修改 tag 的日志级别，优先于 Config 中的 TagLevels、ModuleLevels 和 LogLevel，
+// 重新 Init 之后依然生效，ttl 之后自动恢复，ttl 不大于 0 时一直生效直到调用 ResetTagLevel。
+// 一般用于在线上临时打开某个业务的调试日志，例如：
+//
+//	log.SetTagLevel("payment", log.LogDebug, 10*time.Minute)
+func SetTagLevel(tag string, level Level, ttl time.Duration) {
+	tagOverrides.Lock()
+	defer tagOverrides.Unlock()
+
+	if old, ok := tagOverrides.m[tag]; ok && old.timer != nil {
+		old.timer.Stop()
+	}
+
+	o := &tagOverride{
+		TagLevelOverride: TagLevelOverride{
+			Tag:   tag,
+			Level: level,
+		},
+	}
+
+	if ttl > 0 {
+		o.Expires = time.Now().Add(ttl)
+		o.timer = time.AfterFunc(ttl, func() {
+			tagOverrides.Lock()
+			defer tagOverrides.Unlock()
+
+			// 过期之前可能已经被重新设置过。
+			if tagOverrides.m[tag] == o {
+				delete(tagOverrides.m, tag)
+				publishTagOverrides()
+			}
+		})
+	}
+
+	tagOverrides.m[tag] = o
+	publishTagOverrides()
+}
+
+// ResetTagLevel 取消 SetTagLevel 对 tag 的设置，恢复使用配置中的日志级别。
+func ResetTagLevel(tag string) {
+	tagOverrides.Lock()
+	defer tagOverrides.Unlock()
+
+	if o, ok := tagOverrides.m[tag]; ok {
+		if o.timer != nil {
+			o.timer.Stop()
+		}
+
+		delete(tagOverrides.m, tag)
+		publishTagOverrides()
+	}
+}
+
+// TagLevelOverrides 返回当前所有生效的 SetTagLevel 设置，按照 tag 排序。
+func TagLevelOverrides() []TagLevelOverride {
+	tagOverrides.Lock()
+	defer tagOverrides.Unlock()
+
+	overrides := make([]TagLevelOverride, 0, len(tagOverrides.m))
+
+	for _, o := range tagOverrides.m {
+		overrides = append(overrides, o.TagLevelOverride)
+	}
+
+	sort.Slice(overrides, func(i, j int) bool {
+		return overrides[i].Tag < overrides[j].Tag
+	})
+
+	return overrides
+}
+
+// publishTagOverrides 更新快照，调用时必须持有 tagOverrides 的锁。
+func publishTagOverrides() {
+	if len(tagOverrides.m) == 0 {
+		tagOverridesSnapshot.Store((*tagOverrideSnapshot)(nil))
+		return
+	}
+
+	snapshot := &tagOverrideSnapshot{
+		levels: make(map[string]Level, len(tagOverrides.m)),
+	}
+
+	for tag, o := range tagOverrides.m {
+		snapshot.levels[tag] = o.Level
+
+		if !o.Level.within(snapshot.verbose) {
+			snapshot.verbose = o.Level
+		}
+	}
+
+	tagOverridesSnapshot.Store(snapshot)
+}
+
+// tagOverrideLevel 返回 ctx 中的 tag 被 SetTagLevel 设置的日志级别。
+func tagOverrideLevel(ctx context.Context) (level Level, ok bool) {
+	snapshot, _ := tagOverridesSnapshot.Load().(*tagOverrideSnapshot)
+
+	if snapshot == nil {
+		return
+	}
+
+	level, ok = snapshot.levels[tag(ctx)]
+	return
+}
+
+// tagOverrideEnabled 判断 ctx 中的 tag 是否被 SetTagLevel 设置成了需要输出 level 级别的日志。
+func tagOverrideEnabled(ctx context.Context, level Level) bool {
+	snapshot, _ := tagOverridesSnapshot.Load().(*tagOverrideSnapshot)
+
+	if snapshot == nil || !level.within(snapshot.verbose) {
+		return false
+	}
+
+	max, ok := snapshot.levels[tag(ctx)]
+	return ok && level.within(max)
+}
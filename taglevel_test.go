@@ -0,0 +1,53 @@
+package log
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSetTagLevel(t *testing.T) {
+	l, lines := newTestLogger(&Config{LogLevel: "info"})
+	setDefaultLogger(l)
+	defer Init(nil)
+
+	payment := WithTag(context.Background(), "payment")
+	order := WithTag(context.Background(), "order")
+
+	SetTagLevel("payment", LogDebug, 50*time.Millisecond)
+	SetTagLevel("order", LogError, 0)
+	defer ResetTagLevel("order")
+
+	Debugf(payment, "payment debug")
+	Debugf(order, "order debug")
+	Infof(order, "order info")
+
+	if len(*lines) != 1 {
+		t.Fatalf("only payment debug log should be written. [lines:%v]", *lines)
+	}
+
+	if overrides := TagLevelOverrides(); len(overrides) != 2 || overrides[0].Tag != "order" || !overrides[0].Expires.IsZero() ||
+		overrides[1].Tag != "payment" || overrides[1].Level != LogDebug || overrides[1].Expires.IsZero() {
+		t.Fatalf("unexpected overrides. [overrides:%v]", overrides)
+	}
+
+	// 过期之后自动恢复。
+	deadline := time.Now().Add(time.Second)
+
+	for len(TagLevelOverrides()) != 1 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	Debugf(payment, "payment debug after ttl")
+
+	if len(*lines) != 1 || DebugEnabled(payment) {
+		t.Fatalf("tag level should be reverted after ttl. [lines:%v]", *lines)
+	}
+
+	ResetTagLevel("order")
+	Infof(order, "order info after reset")
+
+	if len(*lines) != 2 || len(TagLevelOverrides()) != 0 {
+		t.Fatalf("tag level should be reset. [lines:%v]", *lines)
+	}
+}
@@ -0,0 +1,59 @@
+package log
+
+// maxTeeFiles 是 teeFile 最多支持的文件数量，受限于 mask 的位数。
+const maxTeeFiles = 8
+
+// teeFile 将一个缓冲区中的日志分发到多个文件。
+// 每条数据的第一个字节是 mask，第 i 位为 1 表示需要写入第 i 个文件，剩下的部分是日志内容。
+type teeFile struct {
+	files []logFile
+}
+
+func newTeeFile(files []logFile) *teeFile {
+	return &teeFile{
+		files: files,
+	}
+}
+
+func (t *teeFile) Write(data []byte) (written int, err error) {
+	if len(data) == 0 {
+		return
+	}
+
+	mask := data[0]
+
+	for i, f := range t.files {
+		if mask&(1<<uint(i)) == 0 {
+			continue
+		}
+
+		if _, e := f.Write(data[1:]); e != nil {
+			err = e
+		}
+	}
+
+	written = len(data)
+	return
+}
+
+func (t *teeFile) Flush() (err error) {
+	for _, f := range t.files {
+		if fl, ok := f.(flusher); ok {
+			if e := fl.Flush(); e != nil {
+				err = e
+			}
+		}
+	}
+
+	return
+}
+
+func (t *teeFile) Close() (err error) {
+	for _, f := range t.files {
+		if e := f.Close(); e != nil {
+			err = e
+		}
+	}
+
+	return
+}
@@ -0,0 +1,66 @@
+package log
+
+import (
+	"io"
+	"sync"
+)
+
+// teeWriter 包装一个额外接收日志的 writer，保证同一时间只有一个 goroutine 写入。
+type teeWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (t *teeWriter) Write(line []byte) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.w.Write(line)
+}
+
+// AddWriter 让默认日志把之后输出的每一行日志都额外写入 w，不影响日志文件和 Sinks，
+// 适合在集成测试中捕获日志，或者把日志实时推送给调试工具。
+// 写入的内容和日志文件中的完全一样，包括结尾的换行符，w 的错误会被忽略，w 不能保留传入的 slice。
+// 调用返回的函数可以停止写入 w，重新 Init 之后需要重新添加，Close 不会关闭 w。
+func AddWriter(w io.Writer) (remove func()) {
+	return defaultLogger().addTeeWriter(w)
+}
+
+func (l *logger) addTeeWriter(w io.Writer) func() {
+	tw := &teeWriter{w: w}
+
+	l.teeMu.Lock()
+	defer l.teeMu.Unlock()
+
+	writers := l.teeWritersOf()
+	updated := make([]*teeWriter, 0, len(writers)+1)
+	updated = append(updated, writers...)
+	l.teeWriters.Store(append(updated, tw))
+
+	return func() {
+		l.teeMu.Lock()
+		defer l.teeMu.Unlock()
+
+		writers := l.teeWritersOf()
+		updated := make([]*teeWriter, 0, len(writers))
+
+		for _, w := range writers {
+			if w != tw {
+				updated = append(updated, w)
+			}
+		}
+
+		l.teeWriters.Store(updated)
+	}
+}
+
+func (l *logger) teeWritersOf() []*teeWriter {
+	writers, _ := l.teeWriters.Load().([]*teeWriter)
+	return writers
+}
+
+func (l *logger) writeTee(line []byte) {
+	for _, w := range l.teeWritersOf() {
+		w.Write(line)
+	}
+}
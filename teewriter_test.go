@@ -0,0 +1,37 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestTeeWriters(t *testing.T) {
+	tee := &bytes.Buffer{}
+	l, lines := newTestLogger(&Config{TeeWriters: []io.Writer{tee}})
+	setDefaultLogger(l)
+	defer Init(nil)
+
+	ctx := context.Background()
+	Infof(ctx, "first")
+
+	added := &bytes.Buffer{}
+	remove := AddWriter(added)
+	Infof(ctx, "second")
+	remove()
+	Infof(ctx, "third")
+
+	if len(*lines) != 3 {
+		t.Fatalf("all logs must be written. [lines:%v]", *lines)
+	}
+
+	if expected := strings.Join(*lines, ""); tee.String() != expected {
+		t.Fatalf("tee writer must receive the same lines. [expected:%v] [actual:%v]", expected, tee.String())
+	}
+
+	if added.String() != (*lines)[1] {
+		t.Fatalf("added writer must only receive lines before removed. [expected:%v] [actual:%v]", (*lines)[1], added.String())
+	}
+}
@@ -0,0 +1,55 @@
+package log
+
+import (
+	"context"
+	"time"
+)
+
+// ElapsedKey 是耗时在日志中的字段名。
+const ElapsedKey = "elapsed"
+
+// Start 开始计时，调用返回的 done 时输出一条 Trace 日志，日志内容是 name，并带上 ElapsedKey 字段记录耗时。
+// 日志中的调用者是调用 Start 的位置。一般这样使用：
+//
+//	done := log.Start(ctx, "load user")
+//	defer done()
+func Start(ctx context.Context, name string) (done func()) {
+	pc := callerPC(1)
+	return startTimer(ctx, pc, name, 0)
+}
+
+// StartSlow 和 Start 一样开始计时，但只有耗时不小于 threshold 时才输出日志，用于记录慢操作。
+func StartSlow(ctx context.Context, name string, threshold time.Duration) (done func()) {
+	pc := callerPC(1)
+	return startTimer(ctx, pc, name, threshold)
+}
+
+// Since 立即输出一条 Trace 日志，记录从 start 到现在的耗时。
+func Since(ctx context.Context, start time.Time, name string) {
+	pc := callerPC(1)
+	logElapsed(ctx, pc, name, time.Since(start))
+}
+
+func startTimer(ctx context.Context, pc uintptr, name string, threshold time.Duration) func() {
+	start := time.Now()
+
+	return func() {
+		elapsed := time.Since(start)
+
+		if elapsed < threshold {
+			return
+		}
+
+		logElapsed(ctx, pc, name, elapsed)
+	}
+}
+
+func logElapsed(ctx context.Context, pc uintptr, name string, elapsed time.Duration) {
+	l := defaultLogger()
+
+	if l.disabled(ctx, LogTrace) || !l.enabled(ctx, pc, LogTrace) {
+		return
+	}
+
+	l.output(WithMoreInfo(ctx, Info{Key: ElapsedKey, Value: elapsed}), pc, LogTrace, name)
+}
@@ -0,0 +1,33 @@
+package log
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStart(t *testing.T) {
+	l, lines := newTestLogger(&Config{})
+	setDefaultLogger(l)
+	defer Init(nil)
+
+	ctx := context.Background()
+	done := Start(ctx, "load user")
+	done()
+
+	slow := StartSlow(ctx, "fast op", time.Hour)
+	slow()
+
+	Since(ctx, time.Now(), "since")
+	l.Flush()
+
+	if len(*lines) != 2 {
+		t.Fatalf("there should be 2 lines. [lines:%v]", *lines)
+	}
+
+	if line := (*lines)[0]; !strings.Contains(line, "[TRACE]") || !strings.Contains(line, "timer_test.go:16@") ||
+		!strings.Contains(line, "elapsed=") || !strings.HasSuffix(line, "load user\n") {
+		t.Fatalf("invalid timer log. [line:%v]", line)
+	}
+}
@@ -0,0 +1,171 @@
+package log
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// validateConfig 检查配置是否合法，并创建日志文件所在的目录。
+func validateConfig(config *Config) error {
+	if config == nil {
+		return nil
+	}
+
+	switch config.Output {
+	case "", OutputFile, OutputStdout, OutputDiscard, OutputSidecar, OutputJournal:
+	default:
+		return fmt.Errorf("go-log: unknown output %q", config.Output)
+	}
+
+	switch config.Format {
+	case "", FormatText, FormatJSON, FormatLogfmt, FormatTSV, FormatBinary:
+	default:
+		return fmt.Errorf("go-log: unknown format %q", config.Format)
+	}
+
+	switch config.ShadowFormat {
+	case "", FormatText, FormatJSON, FormatLogfmt, FormatTSV:
+	default:
+		return fmt.Errorf("go-log: unknown shadow format %q", config.ShadowFormat)
+	}
+
+	switch config.Console {
+	case "", ConsoleAuto, ConsoleAlways, ConsoleNever:
+	default:
+		return fmt.Errorf("go-log: unknown console mode %q", config.Console)
+	}
+
+	switch config.CallerPath {
+	case "", CallerBase, CallerPackage, CallerFull:
+	default:
+		return fmt.Errorf("go-log: unknown caller path %q", config.CallerPath)
+	}
+
+	switch config.FileBackend {
+	case "", FileBackendLumberjack, FileBackendAppend, FileBackendDated:
+	default:
+		return fmt.Errorf("go-log: unknown file backend %q", config.FileBackend)
+	}
+
+	switch config.Compress {
+	case "", CompressGzip, CompressZstd:
+	default:
+		return fmt.Errorf("go-log: unknown compress method %q", config.Compress)
+	}
+
+	// FileBackendDated 在写入时自己切换文件，压缩流无法在切换时结束，新文件会缺少压缩头。
+	if config.Compress != "" && config.FileBackend == FileBackendDated {
+		return fmt.Errorf("go-log: compress is not supported by file backend %q", FileBackendDated)
+	}
+
+	// SinglePipeline 用 mask 的每一位代表一个文件，最多支持 maxTeeFiles 个文件。
+	if config.SinglePipeline {
+		paths := map[string]bool{}
+
+		for _, rc := range config.Routes {
+			paths[rc.Path] = true
+		}
+
+		if len(paths) > maxTeeFiles {
+			return fmt.Errorf("go-log: single pipeline supports at most %v log files", maxTeeFiles)
+		}
+	}
+
+	for level, name := range config.LevelNames {
+		if _, err := ParseLevel(level); err != nil {
+			return err
+		}
+
+		if name == "" {
+			return fmt.Errorf("go-log: empty name for log level %q", level)
+		}
+	}
+
+	levels := []string{config.LogLevel, config.ErrorLogLevel, config.DiskDegradeLevel, config.ConsoleLevel}
+
+	for _, rc := range config.Routes {
+		levels = append(levels, rc.MinLevel, rc.MaxLevel)
+	}
+
+	for _, level := range config.ModuleLevels {
+		levels = append(levels, level)
+	}
+
+	for _, level := range config.TagLevels {
+		levels = append(levels, level)
+	}
+
+	for _, fc := range config.Filters {
+		if fc.Action == "" || fc.Action == FilterDrop {
+			continue
+		}
+
+		level, err := ParseLevel(fc.Action)
+
+		if err != nil {
+			return err
+		}
+
+		if !validFilterLevel(level) {
+			return fmt.Errorf("go-log: filter action %q must not be more severe than error", fc.Action)
+		}
+	}
+
+	for _, level := range levels {
+		if level == "" {
+			continue
+		}
+
+		if _, err := ParseLevel(level); err != nil {
+			return err
+		}
+	}
+
+	for _, rc := range config.Routes {
+		if rc.Path == "" {
+			return fmt.Errorf("go-log: route path must not be empty")
+		}
+	}
+
+	c := withDefaults(config)
+	paths := configPaths(c)
+
+	if auditPath := c.AuditLogPath; auditPath != "" {
+		for _, p := range paths {
+			if filepath.Clean(p) == filepath.Clean(auditPath) {
+				return fmt.Errorf("go-log: audit log path conflicts with log path %q", p)
+			}
+		}
+
+		paths = append(paths, auditPath)
+	}
+
+	for _, p := range paths {
+		if err := os.MkdirAll(filepath.Dir(p), newFileModes(config).dirMode()); err != nil {
+			return fmt.Errorf("go-log: fail to create log directory for %q: %v", p, err)
+		}
+	}
+
+	return nil
+}
+
+// configPaths 返回配置中所有普通日志文件的路径，只有写文件的时候才有路径。
+// config 需要是 withDefaults 的返回值。
+func configPaths(config *Config) []string {
+	if config.Output != OutputFile {
+		return nil
+	}
+
+	if len(config.Routes) == 0 {
+		return []string{config.LogPath, config.ErrorLogPath}
+	}
+
+	paths := make([]string, 0, len(config.Routes))
+
+	for _, rc := range config.Routes {
+		paths = append(paths, rc.Path)
+	}
+
+	return paths
+}
@@ -0,0 +1,64 @@
+package log
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestInitE(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-log-validate")
+
+	if err != nil {
+		t.Fatalf("fail to create temp dir. [err:%v]", err)
+	}
+
+	defer os.RemoveAll(dir)
+	defer Init(nil)
+
+	invalid := []*Config{
+		{LogLevel: "verbose"},
+		{Output: "syslog"},
+		{Format: "xml"},
+		{Compress: "lz4"},
+		{Compress: CompressGzip, FileBackend: FileBackendDated},
+		{ModuleLevels: map[string]string{"a/b": "loud"}},
+		{Routes: []Route{{MinLevel: "error"}}},
+		{Filters: []Filter{{Message: "x", Action: "loud"}}},
+		{SinglePipeline: true, Routes: []Route{
+			{Path: filepath.Join(dir, "1.log")}, {Path: filepath.Join(dir, "2.log")}, {Path: filepath.Join(dir, "3.log")},
+			{Path: filepath.Join(dir, "4.log")}, {Path: filepath.Join(dir, "5.log")}, {Path: filepath.Join(dir, "6.log")},
+			{Path: filepath.Join(dir, "7.log")}, {Path: filepath.Join(dir, "8.log")}, {Path: filepath.Join(dir, "9.log")},
+		}},
+		{Filters: []Filter{{Message: "x", Action: "fatal"}}},
+		{Filters: []Filter{{Message: "x", Action: "panic"}}},
+		{
+			LogPath:      filepath.Join(dir, "all.log"),
+			ErrorLogPath: filepath.Join(dir, "error.log"),
+			AuditLogPath: filepath.Join(dir, "all.log"),
+		},
+	}
+
+	for i, config := range invalid {
+		if err := InitE(config); err == nil {
+			t.Fatalf("invalid config should be rejected. [i:%v]", i)
+		}
+	}
+
+	config := &Config{
+		LogPath:      filepath.Join(dir, "a", "all.log"),
+		ErrorLogPath: filepath.Join(dir, "b", "error.log"),
+		AuditLogPath: filepath.Join(dir, "c", "audit.log"),
+	}
+
+	if err := InitE(config); err != nil {
+		t.Fatalf("fail to init. [err:%v]", err)
+	}
+
+	for _, sub := range []string{"a", "b", "c"} {
+		if fi, err := os.Stat(filepath.Join(dir, sub)); err != nil || !fi.IsDir() {
+			t.Fatalf("log directory should be created. [sub:%v] [err:%v]", sub, err)
+		}
+	}
+}
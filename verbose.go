@@ -0,0 +1,35 @@
+package log
+
+import "context"
+
+// Verbose 是 V 的返回值，为 true 时表示对应的详细程度已经开启。
+type Verbose bool
+
+// V 返回详细程度为 level 的 Verbose，只有 level 不大于 Config.Verbosity 时才会输出日志。
+// 适合框架内部非常啰嗦的日志，例如逐个数据包的日志，开启 debug 级别也不会默认输出这些日志：
+//
+//	log.V(2).Debugf(ctx, "packet received: %v", packet)
+//
+// 日志依然要满足日志级别的限制，V(2).Debugf 需要同时开启 debug 级别和 Verbosity >= 2。
+func V(level int) Verbose {
+	return Verbose(level <= defaultLogger().verbosity)
+}
+
+// Enabled 返回这个详细程度是否开启，用于在构造代价很高的日志参数之前判断。
+func (v Verbose) Enabled() bool {
+	return bool(v)
+}
+
+// Debugf 在详细程度开启时输出调试日志。
+func (v Verbose) Debugf(ctx context.Context, fmt string, args ...interface{}) {
+	if v {
+		defaultLogger().log(ctx, 0, LogDebug, fmt, args...)
+	}
+}
+
+// Infof 在详细程度开启时输出普通日志。
+func (v Verbose) Infof(ctx context.Context, fmt string, args ...interface{}) {
+	if v {
+		defaultLogger().log(ctx, 0, LogInfo, fmt, args...)
+	}
+}
@@ -0,0 +1,30 @@
+package log
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestVerbose(t *testing.T) {
+	l, lines := newTestLogger(&Config{
+		LogLevel:  "debug",
+		Verbosity: 1,
+	})
+	setDefaultLogger(l)
+	defer Init(nil)
+
+	ctx := context.Background()
+	V(0).Infof(ctx, "v0")
+	V(1).Debugf(ctx, "v1")
+	V(2).Debugf(ctx, "v2")
+	l.Flush()
+
+	if V(2).Enabled() || !V(1).Enabled() {
+		t.Fatalf("invalid verbosity.")
+	}
+
+	if len(*lines) != 2 || !strings.HasSuffix((*lines)[0], "||v0\n") || !strings.Contains((*lines)[1], "verbose_test.go:19@") {
+		t.Fatalf("unexpected lines. [lines:%v]", *lines)
+	}
+}
@@ -0,0 +1,49 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"runtime"
+	"strconv"
+)
+
+const (
+	// WorkerKey 是 worker 标签在日志中的字段名。
+	WorkerKey = "worker"
+
+	// GoroutineKey 是 goroutine ID 在日志中的字段名。
+	GoroutineKey = "goroutine"
+)
+
+type logWorker struct{}
+
+var keyLogWorker logWorker
+
+// WithWorker 在 ctx 里面保存一个 worker 标签，例如 "consumer-3"，
+// 标签会作为 WorkerKey 字段输出到每条日志中，方便区分 worker pool 中交错输出的日志。
+func WithWorker(ctx context.Context, label string) context.Context {
+	return context.WithValue(ctx, keyLogWorker, label)
+}
+
+// Worker 返回 ctx 里面保存的 worker 标签，没有时返回空字符串。
+func Worker(ctx context.Context) string {
+	label, _ := ctx.Value(keyLogWorker).(string)
+	return label
+}
+
+var goroutinePrefix = []byte("goroutine ")
+
+// goroutineID 从调用栈的第一行 "goroutine 123 [running]:" 中解析当前 goroutine 的 ID。
+// Go 没有提供获取 goroutine ID 的 API，这个方法有一定开销，只在 Config.Goroutine 开启时使用。
+func goroutineID() uint64 {
+	var buf [64]byte
+	data := buf[:runtime.Stack(buf[:], false)]
+	data = bytes.TrimPrefix(data, goroutinePrefix)
+
+	if idx := bytes.IndexByte(data, ' '); idx >= 0 {
+		data = data[:idx]
+	}
+
+	id, _ := strconv.ParseUint(string(data), 10, 64)
+	return id
+}
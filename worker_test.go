@@ -0,0 +1,50 @@
+package log
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestWorker(t *testing.T) {
+	l, lines := newTestLogger(&Config{
+		Goroutine: true,
+	})
+
+	var wg sync.WaitGroup
+	ids := make(chan uint64, 2)
+
+	for _, label := range []string{"worker-1", "worker-2"} {
+		wg.Add(1)
+		go func(label string) {
+			defer wg.Done()
+			ids <- goroutineID()
+			l.Infof(WithWorker(context.Background(), label), "hello")
+		}(label)
+	}
+
+	wg.Wait()
+	l.Flush()
+	close(ids)
+
+	id1, id2 := <-ids, <-ids
+
+	if id1 == 0 || id2 == 0 || id1 == id2 {
+		t.Fatalf("invalid goroutine ids. [id1:%v] [id2:%v]", id1, id2)
+	}
+
+	if len(*lines) != 2 {
+		t.Fatalf("there should be 2 lines. [lines:%v]", *lines)
+	}
+
+	for _, line := range *lines {
+		if !strings.Contains(line, "worker=worker-") || !strings.Contains(line, "goroutine=") {
+			t.Fatalf("line must contain worker and goroutine. [line:%v]", line)
+		}
+	}
+
+	if w := Worker(context.Background()); w != "" {
+		t.Fatalf("worker must be empty. [worker:%v]", w)
+	}
+}
@@ -0,0 +1,121 @@
+package log
+
+import (
+	"sync"
+
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// pooledWriter 是一个可以被多个日志实例共享的 AsyncWriter。
+type pooledWriter struct {
+	writer *AsyncWriter
+	file   logFile
+	opts   fileOptions
+	refs   int
+}
+
+// writerPool 记录所有打开的日志文件，重新 Init 时相同路径的文件会直接复用，
+// 避免关闭旧文件和打开新文件之间并发写入的日志丢失。
+var writerPool = struct {
+	sync.Mutex
+	paths   map[string]*pooledWriter
+	writers map[*AsyncWriter]*pooledWriter
+}{
+	paths:   map[string]*pooledWriter{},
+	writers: map[*AsyncWriter]*pooledWriter{},
+}
+
+// fileOptions 是打开日志文件的选项，相同路径的文件只有选项相同时才能复用。
+type fileOptions struct {
+	compress string
+	backend  string
+	modes    fileModes
+	size     int
+}
+
+func newFileOptions(config *Config, size int) fileOptions {
+	return fileOptions{
+		compress: config.Compress,
+		backend:  config.FileBackend,
+		modes:    newFileModes(config),
+		size:     size,
+	}
+}
+
+// openLogFile 打开 path 对应的日志文件，compress 不为空时写入的同时压缩。
+func openLogFile(path string, opts fileOptions) logFile {
+	var file logFile
+
+	switch opts.backend {
+	case FileBackendAppend:
+		file = newAppendFile(path, opts.modes)
+	case FileBackendDated:
+		file = newDatedFile(path, opts.modes)
+	default:
+		// 出错时不影响打开日志，写入时 lumberjack 会再次尝试创建文件并报告错误。
+		opts.modes.prepare(path)
+		file = &lumberjack.Logger{
+			Filename: path,
+			MaxSize:  maxLogFileSize,
+		}
+	}
+
+	// 配置不合法时 Init 依然会创建日志，这里也不能压缩 FileBackendDated 的文件，详见 validateConfig。
+	if opts.compress != "" && opts.backend != FileBackendDated {
+		if cf := newCompressFile(file, opts.compress); cf != nil {
+			return cf
+		}
+	}
+
+	return file
+}
+
+// acquireWriter 返回写入 path 的 AsyncWriter，如果已经用相同的设置打开过这个文件就复用之前的 AsyncWriter。
+// 用完之后必须调用 releaseWriter。
+func acquireWriter(path string, opts fileOptions) (*AsyncWriter, logFile) {
+	writerPool.Lock()
+	defer writerPool.Unlock()
+
+	if pw, ok := writerPool.paths[path]; ok && pw.opts == opts {
+		pw.refs++
+		return pw.writer, pw.file
+	}
+
+	file := openLogFile(path, opts)
+	pw := &pooledWriter{
+		writer: NewAsyncWriter(file, opts.size),
+		file:   file,
+		opts:   opts,
+		refs:   1,
+	}
+	writerPool.paths[path] = pw
+	writerPool.writers[pw.writer] = pw
+	return pw.writer, file
+}
+
+// releaseWriter 释放一次 w 的引用，返回 true 表示已经没有日志实例在使用 w，需要关闭。
+// 不是 acquireWriter 创建的 w 总是返回 true。
+func releaseWriter(w *AsyncWriter) bool {
+	writerPool.Lock()
+	defer writerPool.Unlock()
+
+	pw, ok := writerPool.writers[w]
+
+	if !ok {
+		return true
+	}
+
+	if pw.refs--; pw.refs > 0 {
+		return false
+	}
+
+	delete(writerPool.writers, w)
+
+	for path, p := range writerPool.paths {
+		if p == pw {
+			delete(writerPool.paths, path)
+		}
+	}
+
+	return true
+}
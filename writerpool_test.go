@@ -0,0 +1,47 @@
+package log
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReuseWriterOnReInit(t *testing.T) {
+	dir := filepath.Join(os.TempDir(), "go-log-writerpool-test")
+	os.RemoveAll(dir)
+	defer os.RemoveAll(dir)
+	defer Init(nil)
+
+	logPath := filepath.Join(dir, "all.log")
+	config := &Config{
+		LogPath:      logPath,
+		ErrorLogPath: filepath.Join(dir, "error.log"),
+		AuditLogPath: filepath.Join(dir, "audit.log"),
+	}
+	ctx := context.Background()
+
+	Init(config)
+	old := defaultLogger()
+	old.Infof(ctx, "first")
+
+	Init(config)
+	l := defaultLogger()
+
+	if old.writers[0] != l.writers[0] {
+		t.Fatalf("writer of the same path must be reused.")
+	}
+
+	// 旧的日志实例已经关闭，但是正在使用旧实例的调用依然可以写入。
+	old.Infof(ctx, "in-flight")
+	l.Infof(ctx, "second")
+	Close()
+
+	if lines := readLines(t, logPath); len(lines) != 3 {
+		t.Fatalf("all lines must be written. [lines:%v]", lines)
+	}
+
+	if _, ok := writerPool.paths[logPath]; ok {
+		t.Fatalf("writer must be released after close. [path:%v]", logPath)
+	}
+}